@@ -50,6 +50,8 @@ const (
 	cfgNofile                = "no-file"
 	cfgKeepFile              = "keep-file"
 	cfgRelabelKey            = "loki-relabel-config"
+	cfgMaxLineSizeKey        = "max-line-size"
+	cfgMaxLineSizeTruncate   = "max-line-size-truncate"
 
 	swarmServiceLabelKey = "com.docker.swarm.service.name"
 	swarmStackLabelKey   = "com.docker.stack.namespace"
@@ -65,6 +67,9 @@ const (
 
 	defaultExternalLabels = "container_name={{.Name}}"
 	defaultHostLabelName  = model.LabelName("host")
+
+	// truncatedLineMarker is appended to lines truncated to the max-line-size.
+	truncatedLineMarker = "...(truncated)"
 )
 
 var (
@@ -84,6 +89,11 @@ type config struct {
 	labels       model.LabelSet
 	clientConfig client.Config
 	pipeline     PipelineConfig
+
+	// maxLineSize is the maximum length in bytes of the lines sent to Loki, zero means unlimited.
+	// Longer lines are truncated when maxLineSizeTruncate is set, dropped otherwise.
+	maxLineSize         int
+	maxLineSizeTruncate bool
 }
 
 type PipelineConfig struct {
@@ -115,6 +125,8 @@ func validateDriverOpt(loggerInfo logger.Info) error {
 		case cfgRelabelKey:
 		case cfgNofile:
 		case cfgKeepFile:
+		case cfgMaxLineSizeKey:
+		case cfgMaxLineSizeTruncate:
 		case "labels":
 		case "env":
 		case "env-regex":
@@ -177,6 +189,19 @@ func parseConfig(logCtx logger.Info) (*config, error) {
 		return nil, err
 	}
 
+	// parse max line size
+	var maxLineSize int
+	if err := parseInt(cfgMaxLineSizeKey, logCtx, func(i int) { maxLineSize = i }); err != nil {
+		return nil, err
+	}
+	if maxLineSize < 0 {
+		return nil, fmt.Errorf("%s: option %s must not be negative", driverName, cfgMaxLineSizeKey)
+	}
+	maxLineSizeTruncate, err := parseBoolean(cfgMaxLineSizeTruncate, logCtx, true)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid option %s format: %s", driverName, cfgMaxLineSizeTruncate, err)
+	}
+
 	// parse http & tls config
 	if tlsCAFile, ok := logCtx.Config[cfgTLSCAFileKey]; ok {
 		clientConfig.Client.TLSConfig.CAFile = tlsCAFile
@@ -301,9 +326,11 @@ func parseConfig(logCtx logger.Info) (*config, error) {
 		return nil, err
 	}
 	return &config{
-		labels:       labels,
-		clientConfig: clientConfig,
-		pipeline:     pipeline,
+		labels:              labels,
+		clientConfig:        clientConfig,
+		pipeline:            pipeline,
+		maxLineSize:         maxLineSize,
+		maxLineSizeTruncate: maxLineSizeTruncate,
 	}, nil
 }
 
@@ -321,7 +348,7 @@ func parsePipeline(logCtx logger.Info) (PipelineConfig, error) {
 	}
 	if okString {
 		if err := yaml.UnmarshalStrict([]byte(pipelineString), &pipeline.PipelineStages); err != nil {
-			return pipeline, err
+			return pipeline, fmt.Errorf("error parsing %s: %s", cfgPipelineStagesKey, err)
 		}
 	}
 	return pipeline, nil
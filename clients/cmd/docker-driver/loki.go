@@ -25,6 +25,9 @@ type loki struct {
 	labels  model.LabelSet
 	logger  log.Logger
 
+	maxLineSize         int
+	maxLineSizeTruncate bool
+
 	closed bool
 	mutex  sync.RWMutex
 
@@ -38,6 +41,14 @@ func New(logCtx logger.Info, logger log.Logger) (logger.Logger, error) {
 	if err != nil {
 		return nil, err
 	}
+	// build the pipeline first so a malformed stage config doesn't leave a client running.
+	var pipeline *stages.Pipeline
+	if len(cfg.pipeline.PipelineStages) != 0 {
+		pipeline, err = stages.NewPipeline(logger, cfg.pipeline.PipelineStages, &jobName, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: invalid pipeline stages", driverName)
+		}
+	}
 	m := client.NewMetrics(prometheus.DefaultRegisterer, nil)
 	c, err := client.New(m, cfg.clientConfig, nil, logger)
 	if err != nil {
@@ -45,11 +56,7 @@ func New(logCtx logger.Info, logger log.Logger) (logger.Logger, error) {
 	}
 	var handler api.EntryHandler = c
 	var stop = func() {}
-	if len(cfg.pipeline.PipelineStages) != 0 {
-		pipeline, err := stages.NewPipeline(logger, cfg.pipeline.PipelineStages, &jobName, prometheus.DefaultRegisterer)
-		if err != nil {
-			return nil, err
-		}
+	if pipeline != nil {
 		handler = pipeline.Wrap(c)
 		stop = handler.Stop
 	}
@@ -59,6 +66,9 @@ func New(logCtx logger.Info, logger log.Logger) (logger.Logger, error) {
 		logger:  logger,
 		handler: handler,
 		stop:    stop,
+
+		maxLineSize:         cfg.maxLineSize,
+		maxLineSizeTruncate: cfg.maxLineSizeTruncate,
 	}, nil
 }
 
@@ -74,6 +84,10 @@ func (l *loki) Log(m *logger.Message) error {
 	if len(bytes.Fields(m.Line)) == 0 {
 		return nil
 	}
+	line, ok := l.limitLineSize(m.Line)
+	if !ok {
+		return nil
+	}
 	lbs := l.labels.Clone()
 	if m.Source != "" {
 		lbs["source"] = model.LabelValue(m.Source)
@@ -82,12 +96,27 @@ func (l *loki) Log(m *logger.Message) error {
 		Labels: lbs,
 		Entry: logproto.Entry{
 			Timestamp: m.Timestamp,
-			Line:      string(m.Line),
+			Line:      line,
 		},
 	}
 	return nil
 }
 
+// limitLineSize truncates the line to the max line size, marking it as truncated.
+// It returns false if the line is too long and must be dropped instead.
+func (l *loki) limitLineSize(line []byte) (string, bool) {
+	if l.maxLineSize == 0 || len(line) <= l.maxLineSize {
+		return string(line), true
+	}
+	if !l.maxLineSizeTruncate {
+		return "", false
+	}
+	if l.maxLineSize <= len(truncatedLineMarker) {
+		return string(line[:l.maxLineSize]), true
+	}
+	return string(line[:l.maxLineSize-len(truncatedLineMarker)]) + truncatedLineMarker, true
+}
+
 // Log implements `logger.Logger`
 func (l *loki) Name() string {
 	return driverName
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/docker/docker/daemon/logger"
 	"github.com/stretchr/testify/require"
 
+	"github.com/pao214/loki/pkg/logproto"
+	"github.com/pao214/loki/pkg/util"
 	util_log "github.com/pao214/loki/pkg/util/log"
 )
 
@@ -24,3 +29,105 @@ func Test_loki_LogWhenClosed(t *testing.T) {
 	require.Nil(t, l.Close())
 	require.NotNil(t, l.Log(msg))
 }
+
+func Test_loki_PipelineStages(t *testing.T) {
+	pushes := make(chan logproto.PushRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req logproto.PushRequest
+		if err := util.ParseProtoReader(r.Context(), r.Body, int(r.ContentLength), math.MaxInt32, &req, util.RawSnappy); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		pushes <- req
+	}))
+	defer server.Close()
+
+	l, err := New(logger.Info{
+		ContainerName: "/app",
+		Config: map[string]string{
+			"loki-url":             server.URL,
+			"loki-batch-wait":      "10ms",
+			"loki-pipeline-stages": `[{"json": {"expressions": {"level": "level"}}}, {"labels": {"level": null}}]`,
+		},
+	}, util_log.Logger)
+	require.NoError(t, err)
+
+	msg := logger.NewMessage()
+	msg.Line = []byte(`{"level": "warn", "msg": "disk almost full"}`)
+	msg.Timestamp = time.Now()
+	require.NoError(t, l.Log(msg))
+	require.NoError(t, l.Close())
+	close(pushes)
+
+	var streams []logproto.Stream
+	for req := range pushes {
+		streams = append(streams, req.Streams...)
+	}
+	require.Len(t, streams, 1)
+	require.Contains(t, streams[0].Labels, `level="warn"`)
+	require.Contains(t, streams[0].Labels, `container_name="app"`)
+}
+
+func Test_loki_InvalidPipelineStages(t *testing.T) {
+	_, err := New(logger.Info{
+		Config: map[string]string{
+			"loki-url":             "http://localhost:3000",
+			"loki-pipeline-stages": `[{"json": {"source": 1}}]`,
+		},
+	}, util_log.Logger)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid pipeline stages")
+	require.Contains(t, err.Error(), "invalid json stage config")
+}
+
+func Test_loki_MaxLineSize(t *testing.T) {
+	for _, tc := range []struct {
+		truncate string
+		expected []string
+	}{
+		{truncate: "true", expected: []string{"short line", "a very long...(truncated)"}},
+		{truncate: "false", expected: []string{"short line"}},
+	} {
+		t.Run("truncate="+tc.truncate, func(t *testing.T) {
+			pushes := make(chan logproto.PushRequest, 10)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req logproto.PushRequest
+				if err := util.ParseProtoReader(r.Context(), r.Body, int(r.ContentLength), math.MaxInt32, &req, util.RawSnappy); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				pushes <- req
+			}))
+			defer server.Close()
+
+			l, err := New(logger.Info{
+				Config: map[string]string{
+					"loki-url":               server.URL,
+					"loki-batch-wait":        "10ms",
+					"max-line-size":          "25",
+					"max-line-size-truncate": tc.truncate,
+				},
+			}, util_log.Logger)
+			require.NoError(t, err)
+
+			for _, line := range []string{"short line", "a very long line going over the limit"} {
+				msg := logger.NewMessage()
+				msg.Line = []byte(line)
+				msg.Timestamp = time.Now()
+				require.NoError(t, l.Log(msg))
+			}
+			require.NoError(t, l.Close())
+			close(pushes)
+
+			var lines []string
+			for req := range pushes {
+				for _, s := range req.Streams {
+					for _, e := range s.Entries {
+						lines = append(lines, e.Line)
+					}
+				}
+			}
+			require.Equal(t, tc.expected, lines)
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"path/filepath"
 
 	"github.com/docker/go-plugins-helpers/sdk"
 	"github.com/go-kit/log"
@@ -17,7 +18,7 @@ import (
 	util_log "github.com/pao214/loki/pkg/util/log"
 )
 
-const socketAddress = "/run/docker/plugins/loki.sock"
+const defaultSocketAddress = "/run/docker/plugins/loki.sock"
 
 var logLevel logging.Level
 
@@ -38,17 +39,48 @@ func main() {
 
 	handlers(&h, newDriver(logger))
 
-	pprofPort := os.Getenv("PPROF_PORT")
-	if pprofPort != "" {
+	if pprofAddr := pprofAddress(); pprofAddr != "" {
 		go func() {
-			err := http.ListenAndServe(fmt.Sprintf(":%s", pprofPort), nil)
+			err := http.ListenAndServe(pprofAddr, nil)
 			logger.Log("msg", "http server stopped", "err", err)
 		}()
 	}
 
-	if err := h.ServeUnix(socketAddress, 0); err != nil {
+	addr, err := socketAddress()
+	if err != nil {
 		panic(err)
 	}
+	if err := h.ServeUnix(addr, 0); err != nil {
+		panic(err)
+	}
+}
+
+// pprofAddress returns the address the pprof server listens on, or an empty string if
+// pprof is disabled. PPROF_ADDR takes precedence over PPROF_PORT, which listens on all interfaces.
+func pprofAddress() string {
+	if addr := os.Getenv("PPROF_ADDR"); addr != "" {
+		return addr
+	}
+	if port := os.Getenv("PPROF_PORT"); port != "" {
+		return fmt.Sprintf(":%s", port)
+	}
+	return ""
+}
+
+// socketAddress returns the path of the plugin socket, which can be overridden with
+// PLUGIN_SOCKET, e.g. for rootless Docker. Its parent directory is created if missing.
+func socketAddress() (string, error) {
+	addr := os.Getenv("PLUGIN_SOCKET")
+	if addr == "" {
+		addr = defaultSocketAddress
+	}
+	if !filepath.IsAbs(addr) {
+		return "", fmt.Errorf("plugin socket path must be absolute: %s", addr)
+	}
+	if err := os.MkdirAll(filepath.Dir(addr), 0755); err != nil {
+		return "", fmt.Errorf("failed to create plugin socket directory: %w", err)
+	}
+	return addr, nil
 }
 
 func newLogger(lvl logging.Level) log.Logger {
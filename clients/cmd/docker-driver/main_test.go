@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_socketAddress(t *testing.T) {
+	t.Run("override", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "rootless", "plugins", "loki.sock")
+		t.Setenv("PLUGIN_SOCKET", path)
+
+		addr, err := socketAddress()
+		require.NoError(t, err)
+		require.Equal(t, path, addr)
+
+		info, err := os.Stat(filepath.Dir(path))
+		require.NoError(t, err)
+		require.True(t, info.IsDir())
+	})
+
+	t.Run("relative path", func(t *testing.T) {
+		t.Setenv("PLUGIN_SOCKET", "loki.sock")
+
+		_, err := socketAddress()
+		require.Error(t, err)
+	})
+}
+
+func Test_pprofAddress(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		addr     string
+		port     string
+		expected string
+	}{
+		{name: "disabled"},
+		{name: "port only", port: "6060", expected: ":6060"},
+		{name: "address only", addr: "127.0.0.1:6060", expected: "127.0.0.1:6060"},
+		{name: "address takes precedence", addr: "127.0.0.1:6060", port: "7070", expected: "127.0.0.1:6060"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("PPROF_ADDR", tc.addr)
+			t.Setenv("PPROF_PORT", tc.port)
+			require.Equal(t, tc.expected, pprofAddress())
+		})
+	}
+}
@@ -4,27 +4,32 @@ import (
 	"fmt"
 
 	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/pao214/loki/clients/pkg/promtail/client"
 )
 
 type bufferConfig struct {
-	buffer     bool
-	bufferType string
-	dqueConfig dqueConfig
+	buffer       bool
+	bufferType   string
+	dqueConfig   dqueConfig
+	memoryConfig memoryConfig
 }
 
 var defaultBufferConfig = bufferConfig{
-	buffer:     false,
-	bufferType: "dque",
-	dqueConfig: defaultDqueConfig,
+	buffer:       false,
+	bufferType:   "dque",
+	dqueConfig:   defaultDqueConfig,
+	memoryConfig: defaultMemoryConfig,
 }
 
 // NewBuffer makes a new buffered Client.
-func NewBuffer(cfg *config, logger log.Logger, metrics *client.Metrics, streamLagLabels []string) (client.Client, error) {
+func NewBuffer(cfg *config, logger log.Logger, metrics *client.Metrics, reg prometheus.Registerer, streamLagLabels []string) (client.Client, error) {
 	switch cfg.bufferConfig.bufferType {
 	case "dque":
-		return newDque(cfg, logger, metrics, streamLagLabels)
+		return newDque(cfg, logger, metrics, reg, streamLagLabels)
+	case "memory":
+		return newMemory(cfg, logger, metrics, streamLagLabels)
 	default:
 		return nil, fmt.Errorf("failed to parse bufferType: %s", cfg.bufferConfig.bufferType)
 	}
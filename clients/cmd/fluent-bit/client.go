@@ -2,14 +2,16 @@ package main
 
 import (
 	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/pao214/loki/clients/pkg/promtail/client"
 )
 
 // NewClient creates a new client based on the fluentbit configuration.
-func NewClient(cfg *config, logger log.Logger, metrics *client.Metrics, streamLagLabels []string) (client.Client, error) {
+// The metrics of the buffer, if any, are registered with reg.
+func NewClient(cfg *config, logger log.Logger, metrics *client.Metrics, reg prometheus.Registerer, streamLagLabels []string) (client.Client, error) {
 	if cfg.bufferConfig.buffer {
-		return NewBuffer(cfg, logger, metrics, streamLagLabels)
+		return NewBuffer(cfg, logger, metrics, reg, streamLagLabels)
 	}
 	return client.New(metrics, cfg.clientConfig, streamLagLabels, logger)
 }
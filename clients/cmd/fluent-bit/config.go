@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -52,6 +53,25 @@ type config struct {
 	lineFormat           format
 	dropSingleKey        bool
 	labelMap             map[string]interface{}
+	tenantIDMap          []tenantIDMapping
+}
+
+// tenantIDMapping routes the records whose fluent-bit tag matches tagPattern to
+// the tenant tenantID.
+type tenantIDMapping struct {
+	tagPattern string
+	tenantID   string
+}
+
+// tenantIDForTag returns the tenant of the first mapping matching tag, or an
+// empty string to use the default TenantID.
+func (c *config) tenantIDForTag(tag string) string {
+	for _, m := range c.tenantIDMap {
+		if ok, _ := path.Match(m.tagPattern, tag); ok {
+			return m.tenantID
+		}
+	}
+	return ""
 }
 
 func parseConfig(cfg ConfigGetter) (*config, error) {
@@ -74,6 +94,21 @@ func parseConfig(cfg ConfigGetter) (*config, error) {
 	// cfg.Get will return empty string if not set, which is handled by the client library as no tenant
 	res.clientConfig.TenantID = cfg.Get("TenantID")
 
+	// comma separated list of tag_pattern=tenant pairs, the first matching pattern wins.
+	tenantIDMap := cfg.Get("TenantIDMap")
+	if tenantIDMap != "" {
+		for _, pair := range strings.Split(tenantIDMap, ",") {
+			kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+			if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+				return nil, fmt.Errorf("invalid TenantIDMap entry, expected tag_pattern=tenant: %s", pair)
+			}
+			if _, err := path.Match(kv[0], ""); err != nil {
+				return nil, fmt.Errorf("invalid TenantIDMap tag pattern %s: %v", kv[0], err)
+			}
+			res.tenantIDMap = append(res.tenantIDMap, tenantIDMapping{tagPattern: kv[0], tenantID: kv[1]})
+		}
+	}
+
 	batchWait := cfg.Get("BatchWait")
 	if batchWait != "" {
 		// first try to parse as seconds format.
@@ -259,6 +294,44 @@ func parseConfig(cfg ConfigGetter) (*config, error) {
 		res.bufferConfig.dqueConfig.queueName = queueName
 	}
 
+	// dque max size (number of records)
+	queueMaxSize := cfg.Get("DqueMaxSize")
+	if queueMaxSize != "" {
+		res.bufferConfig.dqueConfig.queueMaxSize, err = strconv.Atoi(queueMaxSize)
+		if err != nil {
+			return nil, fmt.Errorf("impossible to convert string to integer DqueMaxSize: %v", queueMaxSize)
+		}
+	}
+
+	// dque behavior when full
+	queueFullPolicy := cfg.Get("DqueFullPolicy")
+	switch queueFullPolicy {
+	case "":
+	case dropOldestPolicy, blockPolicy:
+		res.bufferConfig.dqueConfig.queueFullPolicy = queueFullPolicy
+	default:
+		return nil, fmt.Errorf("invalid string DqueFullPolicy: %v", queueFullPolicy)
+	}
+
+	// memory buffer capacity (number of records)
+	memoryBufferCapacity := cfg.Get("MemoryBufferCapacity")
+	if memoryBufferCapacity != "" {
+		res.bufferConfig.memoryConfig.capacity, err = strconv.Atoi(memoryBufferCapacity)
+		if err != nil {
+			return nil, fmt.Errorf("impossible to convert string to integer MemoryBufferCapacity: %v", memoryBufferCapacity)
+		}
+	}
+
+	// memory buffer behavior when full
+	memoryBufferDropPolicy := cfg.Get("MemoryBufferDropPolicy")
+	switch memoryBufferDropPolicy {
+	case "":
+	case dropOldestPolicy, blockPolicy:
+		res.bufferConfig.memoryConfig.dropPolicy = memoryBufferDropPolicy
+	default:
+		return nil, fmt.Errorf("invalid string MemoryBufferDropPolicy: %v", memoryBufferDropPolicy)
+	}
+
 	res.clientConfig.Client.TLSConfig.CAFile = cfg.Get("ca_file")
 	res.clientConfig.Client.TLSConfig.CertFile = cfg.Get("cert_file")
 	res.clientConfig.Client.TLSConfig.KeyFile = cfg.Get("key_file")
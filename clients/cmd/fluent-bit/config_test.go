@@ -54,6 +54,7 @@ func Test_parseConfig(t *testing.T) {
 			map[string]string{
 				"URL":           "http://somewhere.com:3100/loki/api/v1/push",
 				"TenantID":      "my-tenant-id",
+				"TenantIDMap":   "kube.team-a.*=team-a, kube.*=kube",
 				"LineFormat":    "key_value",
 				"LogLevel":      "warn",
 				"Labels":        `{app="foo"}`,
@@ -82,6 +83,7 @@ func Test_parseConfig(t *testing.T) {
 				labelKeys:     []string{"foo", "bar"},
 				removeKeys:    []string{"buzz", "fuzz"},
 				dropSingleKey: false,
+				tenantIDMap:   []tenantIDMapping{{tagPattern: "kube.team-a.*", tenantID: "team-a"}, {tagPattern: "kube.*", tenantID: "kube"}},
 			},
 			false},
 		{"with label map",
@@ -143,6 +145,12 @@ func Test_parseConfig(t *testing.T) {
 		{"bad MaxBackoff", map[string]string{"MaxBackoff": "5ma"}, nil, true},
 		{"bad MaxRetries", map[string]string{"MaxRetries": "a"}, nil, true},
 		{"bad labelmap file", map[string]string{"LabelMapPath": "a"}, nil, true},
+		{"bad MemoryBufferCapacity", map[string]string{"MemoryBufferCapacity": "a"}, nil, true},
+		{"bad MemoryBufferDropPolicy", map[string]string{"MemoryBufferDropPolicy": "a"}, nil, true},
+		{"bad DqueMaxSize", map[string]string{"DqueMaxSize": "a"}, nil, true},
+		{"bad DqueFullPolicy", map[string]string{"DqueFullPolicy": "a"}, nil, true},
+		{"bad TenantIDMap entry", map[string]string{"TenantIDMap": "kube.*"}, nil, true},
+		{"bad TenantIDMap pattern", map[string]string{"TenantIDMap": "kube.[=kube"}, nil, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -201,6 +209,9 @@ func assertConfig(t *testing.T, expected, actual *config) {
 	if !reflect.DeepEqual(expected.labelMap, actual.labelMap) {
 		t.Errorf("incorrect labelMap want:%v got:%v", expected.labelMap, actual.labelMap)
 	}
+	if !reflect.DeepEqual(expected.tenantIDMap, actual.tenantIDMap) {
+		t.Errorf("incorrect tenantIDMap want:%v got:%v", expected.tenantIDMap, actual.tenantIDMap)
+	}
 }
 
 func mustParseURL(u string) flagext.URLValue {
@@ -9,6 +9,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/joncrlsn/dque"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 
 	"github.com/pao214/loki/clients/pkg/promtail/api"
@@ -17,11 +18,51 @@ import (
 	"github.com/pao214/loki/pkg/logproto"
 )
 
+type dqueMetrics struct {
+	depth   *prometheus.GaugeVec
+	dropped *prometheus.CounterVec
+}
+
+// newDqueMetrics makes the dque metrics, shared by the plugin instances registering them with the same registerer.
+func newDqueMetrics(reg prometheus.Registerer) *dqueMetrics {
+	m := &dqueMetrics{
+		depth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "fluentbit_loki",
+			Name:      "dque_depth",
+			Help:      "Number of records currently held in the dque buffer.",
+		}, []string{"name"}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "fluentbit_loki",
+			Name:      "dque_dropped_records_total",
+			Help:      "Number of records dropped from the dque buffer by the drop-oldest policy because it was full.",
+		}, []string{"name"}),
+	}
+	if reg != nil {
+		m.depth = mustRegisterOrGet(reg, m.depth).(*prometheus.GaugeVec)
+		m.dropped = mustRegisterOrGet(reg, m.dropped).(*prometheus.CounterVec)
+	}
+	return m
+}
+
+func mustRegisterOrGet(reg prometheus.Registerer, c prometheus.Collector) prometheus.Collector {
+	if err := reg.Register(c); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return are.ExistingCollector
+		}
+		panic(err)
+	}
+	return c
+}
+
 type dqueConfig struct {
 	queueDir         string
 	queueSegmentSize int
 	queueSync        bool
 	queueName        string
+	// queueMaxSize caps the number of queued records, 0 means unbounded.
+	queueMaxSize int
+	// queueFullPolicy is either dropOldestPolicy or blockPolicy.
+	queueFullPolicy string
 }
 
 var defaultDqueConfig = dqueConfig{
@@ -29,6 +70,8 @@ var defaultDqueConfig = dqueConfig{
 	queueSegmentSize: 500,
 	queueSync:        false,
 	queueName:        "dque",
+	queueMaxSize:     0,
+	queueFullPolicy:  dropOldestPolicy,
 }
 
 type dqueEntry struct {
@@ -48,40 +91,75 @@ type dqueClient struct {
 	once    sync.Once
 	wg      sync.WaitGroup
 	entries chan api.Entry
+
+	maxSize     int
+	segmentSize int
+	dropOldest  bool
+	depth       prometheus.Gauge
+	dropped     prometheus.Counter
+	// cond is signaled when records are dequeued or the client is stopped,
+	// to wake up an enqueuer blocked on a full queue.
+	mtx    sync.Mutex
+	cond   *sync.Cond
+	closed bool
 }
 
 // New makes a new dque loki client
-func newDque(cfg *config, logger log.Logger, metrics *client.Metrics, streamLagLabels []string) (client.Client, error) {
+func newDque(cfg *config, logger log.Logger, metrics *client.Metrics, reg prometheus.Registerer, streamLagLabels []string) (client.Client, error) {
 	var err error
 
-	q := &dqueClient{
-		logger: log.With(logger, "component", "queue", "name", cfg.bufferConfig.dqueConfig.queueName),
-	}
-
-	err = os.MkdirAll(cfg.bufferConfig.dqueConfig.queueDir, 0644)
+	q, err := newDqueBuffer(cfg.bufferConfig.dqueConfig, logger, reg)
 	if err != nil {
-		return nil, fmt.Errorf("cannot create queue directory: %s", err)
+		return nil, err
 	}
 
-	q.queue, err = dque.NewOrOpen(cfg.bufferConfig.dqueConfig.queueName, cfg.bufferConfig.dqueConfig.queueDir, cfg.bufferConfig.dqueConfig.queueSegmentSize, dqueEntryBuilder)
+	q.loki, err = client.New(metrics, cfg.clientConfig, streamLagLabels, logger)
 	if err != nil {
 		return nil, err
 	}
 
-	if !cfg.bufferConfig.dqueConfig.queueSync {
-		_ = q.queue.TurboOn()
+	q.wg.Add(2)
+	go q.enqueuer()
+	go q.dequeuer()
+	return q, nil
+}
+
+// newDqueBuffer validates cfg and opens the queue, without starting to process records.
+// Its metrics are registered with reg.
+func newDqueBuffer(cfg dqueConfig, logger log.Logger, reg prometheus.Registerer) (*dqueClient, error) {
+	if cfg.queueMaxSize < 0 {
+		return nil, fmt.Errorf("dque max size must not be negative: %d", cfg.queueMaxSize)
+	}
+	if cfg.queueFullPolicy != dropOldestPolicy && cfg.queueFullPolicy != blockPolicy {
+		return nil, fmt.Errorf("invalid dque full policy: %s", cfg.queueFullPolicy)
 	}
 
-	q.loki, err = client.New(metrics, cfg.clientConfig, streamLagLabels, logger)
+	metrics := newDqueMetrics(reg)
+	q := &dqueClient{
+		logger:      log.With(logger, "component", "queue", "name", cfg.queueName),
+		entries:     make(chan api.Entry),
+		maxSize:     cfg.queueMaxSize,
+		segmentSize: cfg.queueSegmentSize,
+		dropOldest:  cfg.queueFullPolicy == dropOldestPolicy,
+		depth:       metrics.depth.WithLabelValues(cfg.queueName),
+		dropped:     metrics.dropped.WithLabelValues(cfg.queueName),
+	}
+	q.cond = sync.NewCond(&q.mtx)
+
+	err := os.MkdirAll(cfg.queueDir, 0644)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("cannot create queue directory: %s", err)
 	}
 
-	q.entries = make(chan api.Entry)
+	q.queue, err = dque.NewOrOpen(cfg.queueName, cfg.queueDir, cfg.queueSegmentSize, dqueEntryBuilder)
+	if err != nil {
+		return nil, err
+	}
 
-	q.wg.Add(2)
-	go q.enqueuer()
-	go q.dequeuer()
+	if !cfg.queueSync {
+		_ = q.queue.TurboOn()
+	}
+	q.depth.Set(float64(q.queue.Size()))
 	return q, nil
 }
 
@@ -99,6 +177,7 @@ func (c *dqueClient) dequeuer() {
 				continue
 			}
 		}
+		c.dequeued()
 
 		// Assert type of the response to an Item pointer so we can work with it
 		record, ok := entry.(*dqueEntry)
@@ -117,9 +196,27 @@ func (c *dqueClient) dequeuer() {
 	}
 }
 
+// dequeued updates the depth and wakes up a blocked enqueuer after records
+// left the queue.
+func (c *dqueClient) dequeued() {
+	c.depth.Set(float64(c.queue.Size()))
+	c.mtx.Lock()
+	c.cond.Broadcast()
+	c.mtx.Unlock()
+}
+
+// close marks the client closed, releasing a blocked enqueuer.
+func (c *dqueClient) close() {
+	c.mtx.Lock()
+	c.closed = true
+	c.mtx.Unlock()
+	c.cond.Broadcast()
+}
+
 // Stop the client
 func (c *dqueClient) Stop() {
 	c.once.Do(func() {
+		c.close()
 		close(c.entries)
 		c.queue.Close()
 		c.loki.Stop()
@@ -135,6 +232,7 @@ func (c *dqueClient) Chan() chan<- api.Entry {
 // Stop the client
 func (c *dqueClient) StopNow() {
 	c.once.Do(func() {
+		c.close()
 		close(c.entries)
 		c.queue.Close()
 		c.loki.StopNow()
@@ -145,8 +243,42 @@ func (c *dqueClient) StopNow() {
 func (c *dqueClient) enqueuer() {
 	defer c.wg.Done()
 	for e := range c.entries {
-		if err := c.queue.Enqueue(&dqueEntry{e.Labels, e.Timestamp, e.Line}); err != nil {
-			level.Warn(c.logger).Log("msg", fmt.Sprintf("cannot enqueue record %s:", e.Line), "err", err)
+		c.enqueue(e)
+	}
+}
+
+// enqueue adds e to the queue, first making room for it when the queue is full
+// by either dropping the oldest segment worth of records or waiting for the
+// dequeuer.
+func (c *dqueClient) enqueue(e api.Entry) {
+	if c.maxSize > 0 {
+		if c.dropOldest {
+			c.dropOldestSegment()
+		} else {
+			c.mtx.Lock()
+			for c.queue.Size() >= c.maxSize && !c.closed {
+				c.cond.Wait()
+			}
+			c.mtx.Unlock()
+		}
+	}
+
+	if err := c.queue.Enqueue(&dqueEntry{e.Labels, e.Timestamp, e.Line}); err != nil {
+		level.Warn(c.logger).Log("msg", fmt.Sprintf("cannot enqueue record %s:", e.Line), "err", err)
+	}
+	c.depth.Set(float64(c.queue.Size()))
+}
+
+func (c *dqueClient) dropOldestSegment() {
+	if c.queue.Size() < c.maxSize {
+		return
+	}
+	var dropped int
+	for ; dropped < c.segmentSize; dropped++ {
+		if _, err := c.queue.Dequeue(); err != nil {
+			break
 		}
 	}
+	c.dropped.Add(float64(dropped))
+	level.Warn(c.logger).Log("msg", "dque full, dropped oldest records", "count", dropped)
 }
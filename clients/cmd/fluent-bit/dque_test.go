@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func dqueTestConfig(t *testing.T, policy string) dqueConfig {
+	return dqueConfig{
+		queueDir:         t.TempDir(),
+		queueSegmentSize: 2,
+		queueName:        "test-" + policy,
+		queueMaxSize:     4,
+		queueFullPolicy:  policy,
+	}
+}
+
+func dequeueLines(t *testing.T, c *dqueClient, n int) []string {
+	var lines []string
+	for i := 0; i < n; i++ {
+		e, err := c.queue.Dequeue()
+		require.NoError(t, err)
+		c.dequeued()
+		lines = append(lines, e.(*dqueEntry).Line)
+	}
+	return lines
+}
+
+func Test_dqueBuffer_dropOldest(t *testing.T) {
+	c, err := newDqueBuffer(dqueTestConfig(t, dropOldestPolicy), log.NewNopLogger(), prometheus.NewRegistry())
+	require.NoError(t, err)
+	defer c.queue.Close()
+
+	for i := 0; i < 6; i++ {
+		c.enqueue(memoryTestEntry(i))
+	}
+
+	// the fifth record found the queue full and dropped the oldest segment
+	require.Equal(t, 4, c.queue.Size())
+	require.Equal(t, 4.0, testutil.ToFloat64(c.depth))
+	require.Equal(t, 2.0, testutil.ToFloat64(c.dropped))
+	require.Equal(t, []string{"2", "3", "4", "5"}, dequeueLines(t, c, 4))
+	require.Equal(t, 0.0, testutil.ToFloat64(c.depth))
+}
+
+func Test_dqueBuffer_block(t *testing.T) {
+	c, err := newDqueBuffer(dqueTestConfig(t, blockPolicy), log.NewNopLogger(), prometheus.NewRegistry())
+	require.NoError(t, err)
+	defer c.queue.Close()
+
+	for i := 0; i < 4; i++ {
+		c.enqueue(memoryTestEntry(i))
+	}
+
+	enqueued := make(chan struct{})
+	go func() {
+		c.enqueue(memoryTestEntry(4))
+		close(enqueued)
+	}()
+
+	select {
+	case <-enqueued:
+		t.Fatal("enqueue should block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.Equal(t, []string{"0"}, dequeueLines(t, c, 1))
+	select {
+	case <-enqueued:
+	case <-time.After(time.Second):
+		t.Fatal("enqueue should unblock once there's room in the queue")
+	}
+
+	require.Equal(t, []string{"1", "2", "3", "4"}, dequeueLines(t, c, 4))
+	require.Equal(t, 0.0, testutil.ToFloat64(c.dropped))
+}
+
+func Test_dqueBuffer_invalidConfig(t *testing.T) {
+	cfg := dqueTestConfig(t, "foo")
+	_, err := newDqueBuffer(cfg, log.NewNopLogger(), prometheus.NewRegistry())
+	require.Error(t, err)
+
+	cfg = dqueTestConfig(t, blockPolicy)
+	cfg.queueMaxSize = -1
+	_, err = newDqueBuffer(cfg, log.NewNopLogger(), prometheus.NewRegistry())
+	require.Error(t, err)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/go-logfmt/logfmt"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/weaveworks/common/logging"
 
@@ -36,8 +37,10 @@ type loki struct {
 	logger log.Logger
 }
 
-func newPlugin(cfg *config, logger log.Logger, metrics *client.Metrics) (*loki, error) {
-	client, err := NewClient(cfg, logger, metrics, nil)
+// newPlugin makes a plugin instance, registering its metrics with reg.
+func newPlugin(cfg *config, logger log.Logger, reg prometheus.Registerer) (*loki, error) {
+	metrics := client.NewMetrics(reg, nil)
+	client, err := NewClient(cfg, logger, metrics, reg, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +52,8 @@ func newPlugin(cfg *config, logger log.Logger, metrics *client.Metrics) (*loki,
 }
 
 // sendRecord send fluentbit records to loki as an entry.
-func (l *loki) sendRecord(r map[interface{}]interface{}, ts time.Time) error {
+// The tag of the records selects their tenant when a TenantIDMap is configured.
+func (l *loki) sendRecord(r map[interface{}]interface{}, ts time.Time, tag string) error {
 	records := toStringMap(r)
 	level.Debug(l.logger).Log("msg", "processing records", "records", fmt.Sprintf("%+v", records))
 	lbs := model.LabelSet{}
@@ -67,6 +71,10 @@ func (l *loki) sendRecord(r map[interface{}]interface{}, ts time.Time) error {
 	if len(records) == 0 {
 		return nil
 	}
+	// the client sends entries with this reserved label to its tenant
+	if tenantID := l.cfg.tenantIDForTag(tag); tenantID != "" {
+		lbs[client.ReservedLabelTenantID] = model.LabelValue(tenantID)
+	}
 	if l.cfg.dropSingleKey && len(records) == 1 {
 		for _, v := range records {
 			l.client.Chan() <- api.Entry{
@@ -178,7 +186,8 @@ func extractLabels(records map[string]interface{}, keys []string) model.LabelSet
 	return res
 }
 
-// mapLabels convert records into labels using a json map[string]interface{} mapping
+// mapLabels convert records into labels using a json map[string]interface{} mapping.
+// Keys of the mapping can also be dotted paths (e.g. "kubernetes.labels.app") to nested record values.
 func mapLabels(records map[string]interface{}, mapping map[string]interface{}, res model.LabelSet) {
 	for k, v := range mapping {
 		switch nextKey := v.(type) {
@@ -190,7 +199,7 @@ func mapLabels(records map[string]interface{}, mapping map[string]interface{}, r
 			}
 		// we found a value in the mapping meaning we need to save the corresponding record value for the given key.
 		case string:
-			if value, ok := getRecordValue(k, records); ok {
+			if value, ok := getRecordPathValue(k, records); ok {
 				lName := model.LabelName(nextKey)
 				lValue := model.LabelValue(value)
 				if lValue.IsValid() && lName.IsValid() {
@@ -201,6 +210,27 @@ func mapLabels(records map[string]interface{}, mapping map[string]interface{}, r
 	}
 }
 
+// getRecordPathValue returns the record value for key. If the record has no such key,
+// key is treated as a dotted path into nested records.
+func getRecordPathValue(key string, records map[string]interface{}) (string, bool) {
+	if value, ok := getRecordValue(key, records); ok {
+		return value, true
+	}
+
+	path := strings.Split(key, ".")
+	if len(path) == 1 {
+		return "", false
+	}
+	for _, k := range path[:len(path)-1] {
+		next, ok := records[k].(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		records = next
+	}
+	return getRecordValue(path[len(path)-1], records)
+}
+
 func getRecordValue(key string, records map[string]interface{}) (string, bool) {
 	if value, ok := records[key]; ok {
 		switch typedVal := value.(type) {
@@ -2,11 +2,17 @@ package main
 
 import (
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/go-kit/log"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 
 	"github.com/pao214/loki/clients/pkg/promtail/api"
@@ -91,7 +97,7 @@ func Test_loki_sendRecord(t *testing.T) {
 				client: rec,
 				logger: logger,
 			}
-			err := l.sendRecord(tt.record, now)
+			err := l.sendRecord(tt.record, now, "")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("sendRecord() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -105,6 +111,45 @@ func Test_loki_sendRecord(t *testing.T) {
 	}
 }
 
+func Test_loki_sendRecordTenantIDMap(t *testing.T) {
+	var (
+		mtx       sync.Mutex
+		tenantIDs []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		tenantIDs = append(tenantIDs, req.Header.Get("X-Scope-OrgID"))
+	}))
+	defer server.Close()
+
+	cfg, err := parseConfig(fakeConfig{
+		"URL":         server.URL,
+		"TenantID":    "default",
+		"TenantIDMap": "kube.team-a.*=team-a,kube.*=kube",
+		"LabelKeys":   "foo",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := newPlugin(cfg, log.NewNopLogger(), prometheus.NewRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{"kube.team-a.app", "kube.team-b.app", "syslog"} {
+		if err := l.sendRecord(map[interface{}]interface{}{"foo": "bar", "log": tag}, now, tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.client.Stop()
+
+	sort.Strings(tenantIDs)
+	if want := []string{"default", "kube", "team-a"}; !reflect.DeepEqual(want, tenantIDs) {
+		t.Errorf("sendRecord() want tenants:%v got:%v", want, tenantIDs)
+	}
+}
+
 func Test_createLine(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -295,6 +340,44 @@ func Test_labelMapping(t *testing.T) {
 				"label": "value",
 			},
 		},
+		{
+			"dotted path",
+			map[string]interface{}{
+				"kubernetes": map[string]interface{}{
+					"namespace_name": "prod",
+					"labels": map[string]interface{}{
+						"app": "promtail",
+					},
+				},
+				"dotted.key": "exact",
+			},
+			map[string]interface{}{
+				"kubernetes.labels.app":     "app",
+				"kubernetes.namespace_name": "namespace",
+				"dotted.key":                "dotted",
+			},
+			model.LabelSet{
+				"app":       "promtail",
+				"namespace": "prod",
+				"dotted":    "exact",
+			},
+		},
+		{
+			"dotted path missing keys",
+			map[string]interface{}{
+				"kubernetes": map[string]interface{}{
+					"namespace_name": "prod",
+				},
+				"stream": "stdout",
+			},
+			map[string]interface{}{
+				"kubernetes.labels.app": "app",
+				"kubernetes.pod_name":   "pod",
+				"stream.nope":           "nope",
+				"missing.key":           "missing",
+			},
+			model.LabelSet{},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/pao214/loki/clients/pkg/promtail/api"
+	"github.com/pao214/loki/clients/pkg/promtail/client"
+)
+
+const (
+	// dropOldestPolicy evicts the oldest buffered record to make room for a new one.
+	dropOldestPolicy = "drop-oldest"
+	// blockPolicy blocks the output until there's room in the buffer.
+	blockPolicy = "block"
+)
+
+type memoryConfig struct {
+	capacity   int
+	dropPolicy string
+}
+
+var defaultMemoryConfig = memoryConfig{
+	capacity:   10000,
+	dropPolicy: dropOldestPolicy,
+}
+
+// memoryClient buffers records in a bounded in-memory ring before handing them to the loki client.
+// Buffered records are lost if the process exits.
+type memoryClient struct {
+	logger log.Logger
+	loki   client.Client
+	once   sync.Once
+	wg     sync.WaitGroup
+
+	entries chan api.Entry
+
+	dropOldest bool
+	mtx        sync.Mutex
+	cond       *sync.Cond
+	ring       []api.Entry
+	head, size int
+	closed     bool
+	stopNow    bool
+}
+
+// newMemory makes a new in-memory buffered loki client
+func newMemory(cfg *config, logger log.Logger, metrics *client.Metrics, streamLagLabels []string) (client.Client, error) {
+	loki, err := client.New(metrics, cfg.clientConfig, streamLagLabels, logger)
+	if err != nil {
+		return nil, err
+	}
+	return newMemoryWithClient(cfg.bufferConfig.memoryConfig, logger, loki)
+}
+
+func newMemoryWithClient(cfg memoryConfig, logger log.Logger, loki client.Client) (*memoryClient, error) {
+	c, err := newMemoryBuffer(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	c.loki = loki
+
+	c.wg.Add(2)
+	go c.enqueuer()
+	go c.dequeuer()
+	return c, nil
+}
+
+// newMemoryBuffer validates cfg and allocates the ring, without starting to process records.
+func newMemoryBuffer(cfg memoryConfig, logger log.Logger) (*memoryClient, error) {
+	if cfg.capacity <= 0 {
+		return nil, fmt.Errorf("memory buffer capacity must be positive: %d", cfg.capacity)
+	}
+	if cfg.dropPolicy != dropOldestPolicy && cfg.dropPolicy != blockPolicy {
+		return nil, fmt.Errorf("invalid memory buffer drop policy: %s", cfg.dropPolicy)
+	}
+
+	c := &memoryClient{
+		logger:     log.With(logger, "component", "memory-buffer"),
+		entries:    make(chan api.Entry),
+		dropOldest: cfg.dropPolicy == dropOldestPolicy,
+		ring:       make([]api.Entry, cfg.capacity),
+	}
+	c.cond = sync.NewCond(&c.mtx)
+	return c, nil
+}
+
+func (c *memoryClient) enqueuer() {
+	defer c.wg.Done()
+	for e := range c.entries {
+		c.push(e)
+	}
+
+	c.mtx.Lock()
+	c.closed = true
+	c.mtx.Unlock()
+	c.cond.Broadcast()
+}
+
+func (c *memoryClient) push(e api.Entry) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for c.size == len(c.ring) {
+		if c.dropOldest {
+			level.Warn(c.logger).Log("msg", "memory buffer full, dropping oldest record")
+			c.head = (c.head + 1) % len(c.ring)
+			c.size--
+			break
+		}
+		if c.stopNow {
+			return
+		}
+		c.cond.Wait()
+	}
+
+	c.ring[(c.head+c.size)%len(c.ring)] = e
+	c.size++
+	c.cond.Broadcast()
+}
+
+// pop blocks until a record is available, returning false once the buffer
+// is closed and drained or stopped.
+func (c *memoryClient) pop() (api.Entry, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for c.size == 0 && !c.closed && !c.stopNow {
+		c.cond.Wait()
+	}
+	if c.stopNow || c.size == 0 {
+		return api.Entry{}, false
+	}
+
+	e := c.ring[c.head]
+	c.ring[c.head] = api.Entry{}
+	c.head = (c.head + 1) % len(c.ring)
+	c.size--
+	c.cond.Broadcast()
+	return e, true
+}
+
+func (c *memoryClient) dequeuer() {
+	defer c.wg.Done()
+	for {
+		e, ok := c.pop()
+		if !ok {
+			return
+		}
+		c.loki.Chan() <- e
+	}
+}
+
+func (c *memoryClient) Chan() chan<- api.Entry {
+	return c.entries
+}
+
+// Stop the client, flushing buffered records first
+func (c *memoryClient) Stop() {
+	c.once.Do(func() {
+		close(c.entries)
+		c.wg.Wait()
+		c.loki.Stop()
+	})
+}
+
+// StopNow stops the client, dropping buffered records
+func (c *memoryClient) StopNow() {
+	c.once.Do(func() {
+		c.mtx.Lock()
+		c.stopNow = true
+		c.mtx.Unlock()
+		c.cond.Broadcast()
+
+		close(c.entries)
+		// wait for the dequeuer to hand over any in-flight record before
+		// stopping the loki client, which closes its channel.
+		c.wg.Wait()
+		c.loki.StopNow()
+	})
+}
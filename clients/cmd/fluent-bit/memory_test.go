@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pao214/loki/clients/pkg/promtail/api"
+	"github.com/pao214/loki/clients/pkg/promtail/client/fake"
+
+	"github.com/pao214/loki/pkg/logproto"
+)
+
+func memoryTestEntry(i int) api.Entry {
+	return api.Entry{
+		Labels: model.LabelSet{"job": "test"},
+		Entry: logproto.Entry{
+			Timestamp: time.Unix(int64(i), 0),
+			Line:      fmt.Sprint(i),
+		},
+	}
+}
+
+func popLines(t *testing.T, c *memoryClient, n int) []string {
+	var lines []string
+	for i := 0; i < n; i++ {
+		e, ok := c.pop()
+		require.True(t, ok)
+		lines = append(lines, e.Line)
+	}
+	return lines
+}
+
+func Test_memoryBuffer_dropOldest(t *testing.T) {
+	c, err := newMemoryBuffer(memoryConfig{capacity: 3, dropPolicy: dropOldestPolicy}, log.NewNopLogger())
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		c.push(memoryTestEntry(i))
+	}
+
+	require.Equal(t, []string{"2", "3", "4"}, popLines(t, c, 3))
+}
+
+func Test_memoryBuffer_block(t *testing.T) {
+	c, err := newMemoryBuffer(memoryConfig{capacity: 2, dropPolicy: blockPolicy}, log.NewNopLogger())
+	require.NoError(t, err)
+
+	c.push(memoryTestEntry(0))
+	c.push(memoryTestEntry(1))
+
+	pushed := make(chan struct{})
+	go func() {
+		c.push(memoryTestEntry(2))
+		close(pushed)
+	}()
+
+	select {
+	case <-pushed:
+		t.Fatal("push should block while the buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.Equal(t, []string{"0"}, popLines(t, c, 1))
+	select {
+	case <-pushed:
+	case <-time.After(time.Second):
+		t.Fatal("push should unblock once there's room in the buffer")
+	}
+
+	require.Equal(t, []string{"1", "2"}, popLines(t, c, 2))
+}
+
+func Test_memoryBuffer_invalidConfig(t *testing.T) {
+	_, err := newMemoryBuffer(memoryConfig{capacity: 0, dropPolicy: dropOldestPolicy}, log.NewNopLogger())
+	require.Error(t, err)
+
+	_, err = newMemoryBuffer(memoryConfig{capacity: 1, dropPolicy: "foo"}, log.NewNopLogger())
+	require.Error(t, err)
+}
+
+func Test_memoryClient_StopFlushes(t *testing.T) {
+	for _, policy := range []string{dropOldestPolicy, blockPolicy} {
+		t.Run(policy, func(t *testing.T) {
+			loki := fake.New(func() {})
+			c, err := newMemoryWithClient(memoryConfig{capacity: 10, dropPolicy: policy}, log.NewNopLogger(), loki)
+			require.NoError(t, err)
+
+			for i := 0; i < 5; i++ {
+				c.Chan() <- memoryTestEntry(i)
+			}
+			c.Stop()
+
+			var lines []string
+			for _, e := range loki.Received() {
+				lines = append(lines, e.Line)
+			}
+			require.Equal(t, []string{"0", "1", "2", "3", "4"}, lines)
+		})
+	}
+}
@@ -16,7 +16,6 @@ import (
 	_ "github.com/pao214/loki/pkg/util/build"
 )
 import (
-	"github.com/pao214/loki/clients/pkg/promtail/client"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -63,6 +62,7 @@ func FLBPluginInit(ctx unsafe.Pointer) int {
 	paramLogger := log.With(logger, "[flb-go]", "provided parameter")
 	level.Info(paramLogger).Log("URL", conf.clientConfig.URL)
 	level.Info(paramLogger).Log("TenantID", conf.clientConfig.TenantID)
+	level.Info(paramLogger).Log("TenantIDMap", fmt.Sprintf("%+v", conf.tenantIDMap))
 	level.Info(paramLogger).Log("BatchWait", fmt.Sprintf("%.3fs", conf.clientConfig.BatchWait.Seconds()))
 	level.Info(paramLogger).Log("BatchSize", conf.clientConfig.BatchSize)
 	level.Info(paramLogger).Log("Timeout", fmt.Sprintf("%.3fs", conf.clientConfig.Timeout.Seconds()))
@@ -82,13 +82,14 @@ func FLBPluginInit(ctx unsafe.Pointer) int {
 	level.Info(paramLogger).Log("DqueDir", conf.bufferConfig.dqueConfig.queueDir)
 	level.Info(paramLogger).Log("DqueSegmentSize", conf.bufferConfig.dqueConfig.queueSegmentSize)
 	level.Info(paramLogger).Log("DqueSync", conf.bufferConfig.dqueConfig.queueSync)
+	level.Info(paramLogger).Log("DqueMaxSize", conf.bufferConfig.dqueConfig.queueMaxSize)
+	level.Info(paramLogger).Log("DqueFullPolicy", conf.bufferConfig.dqueConfig.queueFullPolicy)
 	level.Info(paramLogger).Log("ca_file", conf.clientConfig.Client.TLSConfig.CAFile)
 	level.Info(paramLogger).Log("cert_file", conf.clientConfig.Client.TLSConfig.CertFile)
 	level.Info(paramLogger).Log("key_file", conf.clientConfig.Client.TLSConfig.KeyFile)
 	level.Info(paramLogger).Log("insecure_skip_verify", conf.clientConfig.Client.TLSConfig.InsecureSkipVerify)
 
-	m := client.NewMetrics(prometheus.DefaultRegisterer, nil)
-	plugin, err := newPlugin(conf, logger, m)
+	plugin, err := newPlugin(conf, logger, prometheus.DefaultRegisterer)
 	if err != nil {
 		level.Error(logger).Log("newPlugin", err)
 		return output.FLB_ERROR
@@ -103,7 +104,7 @@ func FLBPluginInit(ctx unsafe.Pointer) int {
 }
 
 //export FLBPluginFlushCtx
-func FLBPluginFlushCtx(ctx, data unsafe.Pointer, length C.int, _ *C.char) int {
+func FLBPluginFlushCtx(ctx, data unsafe.Pointer, length C.int, tag *C.char) int {
 	plugin := output.FLBPluginGetContext(ctx).(*loki)
 	if plugin == nil {
 		level.Error(logger).Log("[flb-go]", "plugin not initialized")
@@ -134,7 +135,7 @@ func FLBPluginFlushCtx(ctx, data unsafe.Pointer, length C.int, _ *C.char) int {
 			timestamp = time.Now()
 		}
 
-		err := plugin.sendRecord(record, timestamp)
+		err := plugin.sendRecord(record, timestamp, C.GoString(tag))
 		if err != nil {
 			level.Error(plugin.logger).Log("msg", "error sending record to Loki", "error", err)
 			return output.FLB_ERROR
@@ -13,6 +13,9 @@ import (
 type Entry struct {
 	Labels model.LabelSet
 	logproto.Entry
+	// StructuredMetadata are name/value pairs attached to the entry rather
+	// than to its stream.
+	StructuredMetadata []logproto.LabelAdapter
 }
 
 type InstrumentedEntryHandler interface {
@@ -9,6 +9,7 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/snappy"
 	"github.com/prometheus/common/model"
+	"google.golang.org/protobuf/encoding/protowire"
 
 	"github.com/pao214/loki/clients/pkg/promtail/api"
 
@@ -20,9 +21,12 @@ import (
 // and entries in a single batch request. In case of multi-tenant Promtail, log
 // streams for each tenant are stored in a dedicated batch.
 type batch struct {
-	streams   map[string]*logproto.Stream
-	bytes     int
-	createdAt time.Time
+	streams map[string]*logproto.Stream
+	// structuredMetadata holds the structured metadata of the entries by
+	// stream, indexed like the stream entries. Streams without any are absent.
+	structuredMetadata map[string][][]logproto.LabelAdapter
+	bytes              int
+	createdAt          time.Time
 }
 
 func newBatch(entries ...api.Entry) *batch {
@@ -46,15 +50,27 @@ func (b *batch) add(entry api.Entry) {
 
 	// Append the entry to an already existing stream (if any)
 	labels := labelsMapToString(entry.Labels, ReservedLabelTenantID)
-	if stream, ok := b.streams[labels]; ok {
+	stream, ok := b.streams[labels]
+	if ok {
 		stream.Entries = append(stream.Entries, entry.Entry)
-		return
+	} else {
+		// Add the entry as a new stream
+		stream = &logproto.Stream{
+			Labels:  labels,
+			Entries: []logproto.Entry{entry.Entry},
+		}
+		b.streams[labels] = stream
 	}
 
-	// Add the entry as a new stream
-	b.streams[labels] = &logproto.Stream{
-		Labels:  labels,
-		Entries: []logproto.Entry{entry.Entry},
+	if len(entry.StructuredMetadata) > 0 {
+		if b.structuredMetadata == nil {
+			b.structuredMetadata = map[string][][]logproto.LabelAdapter{}
+		}
+		metadata := b.structuredMetadata[labels]
+		for len(metadata) < len(stream.Entries)-1 {
+			metadata = append(metadata, nil)
+		}
+		b.structuredMetadata[labels] = append(metadata, entry.StructuredMetadata)
 	}
 }
 
@@ -94,7 +110,15 @@ func (b *batch) age() time.Duration {
 // the encoded bytes and the number of encoded entries
 func (b *batch) encode() ([]byte, int, error) {
 	req, entriesCount := b.createPushRequest()
-	buf, err := proto.Marshal(req)
+	var (
+		buf []byte
+		err error
+	)
+	if len(b.structuredMetadata) == 0 {
+		buf, err = proto.Marshal(req)
+	} else {
+		buf, err = b.marshalWithStructuredMetadata(req)
+	}
 	if err != nil {
 		return nil, 0, err
 	}
@@ -102,6 +126,41 @@ func (b *batch) encode() ([]byte, int, error) {
 	return buf, entriesCount, nil
 }
 
+// marshalWithStructuredMetadata marshals the push request like proto.Marshal
+// but adds the structured metadata of the entries as their repeated field 3,
+// which logproto.Entry doesn't carry.
+func (b *batch) marshalWithStructuredMetadata(req *logproto.PushRequest) ([]byte, error) {
+	var buf []byte
+	for _, stream := range req.Streams {
+		metadata := b.structuredMetadata[stream.Labels]
+
+		streamBuf := protowire.AppendTag(nil, 1, protowire.BytesType)
+		streamBuf = protowire.AppendString(streamBuf, stream.Labels)
+		for i := range stream.Entries {
+			entryBuf, err := stream.Entries[i].Marshal()
+			if err != nil {
+				return nil, err
+			}
+			if i < len(metadata) {
+				for j := range metadata[i] {
+					pairBuf, err := metadata[i][j].Marshal()
+					if err != nil {
+						return nil, err
+					}
+					entryBuf = protowire.AppendTag(entryBuf, 3, protowire.BytesType)
+					entryBuf = protowire.AppendBytes(entryBuf, pairBuf)
+				}
+			}
+			streamBuf = protowire.AppendTag(streamBuf, 2, protowire.BytesType)
+			streamBuf = protowire.AppendBytes(streamBuf, entryBuf)
+		}
+
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, streamBuf)
+	}
+	return buf, nil
+}
+
 // creates push request and returns it, together with number of entries
 func (b *batch) createPushRequest() (*logproto.PushRequest, int) {
 	req := logproto.PushRequest{
@@ -23,6 +23,7 @@ import (
 
 	"github.com/pao214/loki/clients/pkg/promtail/api"
 
+	"github.com/pao214/loki/pkg/logproto"
 	lokiutil "github.com/pao214/loki/pkg/util"
 	"github.com/pao214/loki/pkg/util/build"
 )
@@ -48,6 +49,7 @@ type Metrics struct {
 	droppedBytes     *prometheus.CounterVec
 	sentEntries      *prometheus.CounterVec
 	droppedEntries   *prometheus.CounterVec
+	dryRunDropped    *prometheus.CounterVec
 	requestDuration  *prometheus.HistogramVec
 	batchRetries     *prometheus.CounterVec
 	countersWithHost []*prometheus.CounterVec
@@ -82,6 +84,11 @@ func NewMetrics(reg prometheus.Registerer, streamLagLabels []string) *Metrics {
 		Name:      "dropped_entries_total",
 		Help:      "Number of log entries dropped because failed to be sent to the ingester after all retries.",
 	}, []string{HostLabel})
+	m.dryRunDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "dry_run_dropped_entries_total",
+		Help:      "Number of log entries dropped by the dry-run logger because printing them fell behind.",
+	}, []string{HostLabel})
 	m.requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: "promtail",
 		Name:      "request_duration_seconds",
@@ -111,6 +118,7 @@ func NewMetrics(reg prometheus.Registerer, streamLagLabels []string) *Metrics {
 		m.droppedBytes = mustRegisterOrGet(reg, m.droppedBytes).(*prometheus.CounterVec)
 		m.sentEntries = mustRegisterOrGet(reg, m.sentEntries).(*prometheus.CounterVec)
 		m.droppedEntries = mustRegisterOrGet(reg, m.droppedEntries).(*prometheus.CounterVec)
+		m.dryRunDropped = mustRegisterOrGet(reg, m.dryRunDropped).(*prometheus.CounterVec)
 		m.requestDuration = mustRegisterOrGet(reg, m.requestDuration).(*prometheus.HistogramVec)
 		m.batchRetries = mustRegisterOrGet(reg, m.batchRetries).(*prometheus.CounterVec)
 		m.streamLag = mustRegisterOrGet(reg, m.streamLag).(*prometheus.GaugeVec)
@@ -169,6 +177,11 @@ func newClient(metrics *Metrics, cfg Config, streamLagLabels []string, logger lo
 	if cfg.URL.URL == nil {
 		return nil, errors.New("client needs target URL")
 	}
+	switch cfg.StructuredMetadata {
+	case "", StructuredMetadataDrop, StructuredMetadataLabels, StructuredMetadataSend:
+	default:
+		return nil, fmt.Errorf("invalid structured metadata handling %q, must be one of %s, %s or %s", cfg.StructuredMetadata, StructuredMetadataDrop, StructuredMetadataLabels, StructuredMetadataSend)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -178,16 +191,12 @@ func newClient(metrics *Metrics, cfg Config, streamLagLabels []string, logger lo
 		entries:         make(chan api.Entry),
 		metrics:         metrics,
 		streamLagLabels: streamLagLabels,
-		name:            asSha256(cfg),
+		name:            clientName(cfg),
 
 		externalLabels: cfg.ExternalLabels.LabelSet,
 		ctx:            ctx,
 		cancel:         cancel,
 	}
-	if cfg.Name != "" {
-		c.name = cfg.Name
-	}
-
 	err := cfg.Client.Validate()
 	if err != nil {
 		return nil, err
@@ -297,6 +306,14 @@ func (c *client) Chan() chan<- api.Entry {
 	return c.entries
 }
 
+// clientName returns the configured name of the client, or a hash of its config if unset.
+func clientName(cfg Config) string {
+	if cfg.Name != "" {
+		return cfg.Name
+	}
+	return asSha256(cfg)
+}
+
 func asSha256(o interface{}) string {
 	h := sha256.New()
 	h.Write([]byte(fmt.Sprintf("%v", o)))
@@ -442,10 +459,30 @@ func (c *client) processEntry(e api.Entry) (api.Entry, string) {
 	if len(c.externalLabels) > 0 {
 		e.Labels = c.externalLabels.Merge(e.Labels)
 	}
+	if len(e.StructuredMetadata) > 0 && c.cfg.StructuredMetadata != StructuredMetadataSend {
+		if c.cfg.StructuredMetadata == StructuredMetadataLabels {
+			e.Labels = structuredMetadataToLabels(e.Labels, e.StructuredMetadata)
+		}
+		e.StructuredMetadata = nil
+	}
 	tenantID := c.getTenantID(e.Labels)
 	return e, tenantID
 }
 
+// structuredMetadataToLabels returns a copy of lbls with the structured
+// metadata added, the stream labels take precedence over the metadata.
+func structuredMetadataToLabels(lbls model.LabelSet, metadata []logproto.LabelAdapter) model.LabelSet {
+	res := lbls.Clone()
+	for _, pair := range metadata {
+		name := model.LabelName(pair.Name)
+		if _, ok := res[name]; ok || !name.IsValid() {
+			continue
+		}
+		res[name] = model.LabelValue(pair.Value)
+	}
+	return res
+}
+
 func (c *client) UnregisterLatencyMetric(labels prometheus.Labels) {
 	labels[HostLabel] = c.cfg.URL.Host
 	c.metrics.streamLag.Delete(labels)
@@ -7,10 +7,12 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/golang/snappy"
 	"github.com/grafana/dskit/backoff"
 	"github.com/grafana/dskit/flagext"
 	"github.com/prometheus/client_golang/prometheus"
@@ -19,6 +21,7 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
 
 	"github.com/pao214/loki/clients/pkg/promtail/api"
 
@@ -78,6 +81,31 @@ func TestClient_Handle(t *testing.T) {
 				promtail_dropped_entries_total{host="__HOST__"} 0
 			`,
 		},
+		"send the batch as soon as the next entry would exceed the batch size": {
+			clientBatchSize:      9,
+			clientBatchWait:      100 * time.Millisecond,
+			clientMaxRetries:     3,
+			serverResponseStatus: 200,
+			inputEntries:         []api.Entry{logEntries[0], logEntries[1]},
+			expectedReqs: []receivedReq{
+				{
+					tenantID: "",
+					pushReq:  logproto.PushRequest{Streams: []logproto.Stream{{Labels: "{}", Entries: []logproto.Entry{logEntries[0].Entry}}}},
+				},
+				{
+					tenantID: "",
+					pushReq:  logproto.PushRequest{Streams: []logproto.Stream{{Labels: "{}", Entries: []logproto.Entry{logEntries[1].Entry}}}},
+				},
+			},
+			expectedMetrics: `
+				# HELP promtail_sent_entries_total Number of log entries sent to the ingester.
+				# TYPE promtail_sent_entries_total counter
+				promtail_sent_entries_total{host="__HOST__"} 2.0
+				# HELP promtail_dropped_entries_total Number of log entries dropped because failed to be sent to the ingester after all retries.
+				# TYPE promtail_dropped_entries_total counter
+				promtail_dropped_entries_total{host="__HOST__"} 0
+			`,
+		},
 		"batch log entries together until the batch wait time is reached": {
 			clientBatchSize:      10,
 			clientBatchWait:      100 * time.Millisecond,
@@ -445,6 +473,67 @@ func TestClient_StopNow(t *testing.T) {
 	}
 }
 
+func TestClient_RetryBackoff(t *testing.T) {
+	const failures = 3
+	minBackoff := 10 * time.Millisecond
+
+	var (
+		mtx      sync.Mutex
+		received []time.Time
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		received = append(received, time.Now())
+		if len(received) <= failures {
+			rw.WriteHeader(500)
+			return
+		}
+		rw.WriteHeader(200)
+	}))
+	defer server.Close()
+
+	serverURL := flagext.URLValue{}
+	require.NoError(t, serverURL.Set(server.URL))
+
+	reg := prometheus.NewRegistry()
+	c, err := New(NewMetrics(reg, nil), Config{
+		URL:            serverURL,
+		BatchWait:      10 * time.Millisecond,
+		BatchSize:      10,
+		Client:         config.HTTPClientConfig{},
+		BackoffConfig:  backoff.Config{MinBackoff: minBackoff, MaxBackoff: time.Second, MaxRetries: failures + 2},
+		ExternalLabels: lokiflag.LabelSet{},
+		Timeout:        1 * time.Second,
+	}, nil, log.NewNopLogger())
+	require.NoError(t, err)
+
+	c.Chan() <- logEntries[0]
+	c.Stop()
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	require.Len(t, received, failures+1)
+
+	// every wait is at least the backoff for its retry, which doubles each time
+	for i := 1; i < len(received); i++ {
+		require.GreaterOrEqual(t, received[i].Sub(received[i-1]), minBackoff<<(i-1))
+	}
+
+	expectedMetrics := strings.Replace(`
+		# HELP promtail_batch_retries_total Number of times batches has had to be retried.
+		# TYPE promtail_batch_retries_total counter
+		promtail_batch_retries_total{host="__HOST__"} 3.0
+		# HELP promtail_sent_entries_total Number of log entries sent to the ingester.
+		# TYPE promtail_sent_entries_total counter
+		promtail_sent_entries_total{host="__HOST__"} 1.0
+		# HELP promtail_dropped_entries_total Number of log entries dropped because failed to be sent to the ingester after all retries.
+		# TYPE promtail_dropped_entries_total counter
+		promtail_dropped_entries_total{host="__HOST__"} 0
+	`, "__HOST__", serverURL.Host, -1)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "promtail_batch_retries_total", "promtail_sent_entries_total", "promtail_dropped_entries_total"))
+}
+
 func createServerHandler(receivedReqsChan chan receivedReq, status int) http.HandlerFunc {
 	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		// Parse the request
@@ -494,3 +583,133 @@ func Test_Tripperware(t *testing.T) {
 	c.Stop()
 	require.True(t, called)
 }
+
+func TestClient_StructuredMetadata(t *testing.T) {
+	metadata := []logproto.LabelAdapter{{Name: "trace_id", Value: "1234"}, {Name: "app", Value: "bar"}}
+	entries := []api.Entry{
+		{
+			Labels:             model.LabelSet{"app": "foo"},
+			Entry:              logproto.Entry{Timestamp: time.Unix(1, 0).UTC(), Line: "line1"},
+			StructuredMetadata: metadata,
+		},
+		{
+			Labels: model.LabelSet{"app": "foo"},
+			Entry:  logproto.Entry{Timestamp: time.Unix(2, 0).UTC(), Line: "line2"},
+		},
+	}
+
+	for _, tc := range []struct {
+		mode             string
+		expectedLabels   string
+		expectedMetadata [][]logproto.LabelAdapter
+	}{
+		{
+			mode:             StructuredMetadataSend,
+			expectedLabels:   `{app="foo"}`,
+			expectedMetadata: [][]logproto.LabelAdapter{metadata, nil},
+		},
+		{
+			mode:             StructuredMetadataLabels,
+			expectedLabels:   `{app="foo", trace_id="1234"}`,
+			expectedMetadata: [][]logproto.LabelAdapter{nil, nil},
+		},
+		{
+			mode:             StructuredMetadataDrop,
+			expectedLabels:   `{app="foo"}`,
+			expectedMetadata: [][]logproto.LabelAdapter{nil, nil},
+		},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			bodies := make(chan []byte, 10)
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				body, err := io.ReadAll(req.Body)
+				if err != nil {
+					rw.WriteHeader(500)
+					return
+				}
+				bodies <- body
+			}))
+			defer server.Close()
+
+			serverURL := flagext.URLValue{}
+			require.NoError(t, serverURL.Set(server.URL))
+
+			c, err := New(NewMetrics(prometheus.NewRegistry(), nil), Config{
+				URL:                serverURL,
+				BatchWait:          time.Hour,
+				BatchSize:          BatchSize,
+				BackoffConfig:      backoff.Config{MinBackoff: 1 * time.Millisecond, MaxBackoff: 2 * time.Millisecond, MaxRetries: 1},
+				Timeout:            time.Second,
+				StructuredMetadata: tc.mode,
+			}, nil, log.NewNopLogger())
+			require.NoError(t, err)
+
+			for _, e := range entries {
+				c.Chan() <- e
+			}
+			c.Stop()
+			close(bodies)
+
+			var (
+				streams  []string
+				received [][]logproto.LabelAdapter
+			)
+			for body := range bodies {
+				buf, err := snappy.Decode(nil, body)
+				require.NoError(t, err)
+
+				// The request must still be readable by Loki versions ignoring
+				// the structured metadata.
+				var pushReq logproto.PushRequest
+				require.NoError(t, pushReq.Unmarshal(buf))
+				for _, s := range pushReq.Streams {
+					streams = append(streams, s.Labels)
+				}
+				received = append(received, decodeStructuredMetadata(t, buf)...)
+			}
+			require.Contains(t, streams, tc.expectedLabels)
+			require.ElementsMatch(t, tc.expectedMetadata, received)
+		})
+	}
+
+	_, err := New(nilMetrics, Config{URL: flagext.URLValue{URL: &url.URL{Host: "loki:3100"}}, StructuredMetadata: "flatten"}, nil, log.NewNopLogger())
+	require.Error(t, err)
+}
+
+// decodeStructuredMetadata returns the structured metadata of every entry of
+// the encoded push request, in order.
+func decodeStructuredMetadata(t *testing.T, buf []byte) [][]logproto.LabelAdapter {
+	var res [][]logproto.LabelAdapter
+	forEachField(t, buf, 1, func(stream []byte) {
+		forEachField(t, stream, 2, func(entry []byte) {
+			var metadata []logproto.LabelAdapter
+			forEachField(t, entry, 3, func(pairBuf []byte) {
+				var pair logproto.LabelAdapter
+				require.NoError(t, pair.Unmarshal(pairBuf))
+				metadata = append(metadata, logproto.LabelAdapter{Name: string([]byte(pair.Name)), Value: string([]byte(pair.Value))})
+			})
+			res = append(res, metadata)
+		})
+	})
+	return res
+}
+
+// forEachField calls f with the value of each length delimited field num of
+// the protobuf message buf.
+func forEachField(t *testing.T, buf []byte, num protowire.Number, f func([]byte)) {
+	for len(buf) > 0 {
+		n, typ, l := protowire.ConsumeTag(buf)
+		require.GreaterOrEqual(t, l, 0)
+		buf = buf[l:]
+		if n == num && typ == protowire.BytesType {
+			v, l := protowire.ConsumeBytes(buf)
+			require.GreaterOrEqual(t, l, 0)
+			f(v)
+			buf = buf[l:]
+			continue
+		}
+		l = protowire.ConsumeFieldValue(n, typ, buf)
+		require.GreaterOrEqual(t, l, 0)
+		buf = buf[l:]
+	}
+}
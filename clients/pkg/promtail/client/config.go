@@ -22,9 +22,23 @@ const (
 	Timeout        = 10 * time.Second
 )
 
+// Ways of handling the structured metadata of entries.
+const (
+	// StructuredMetadataDrop discards the structured metadata, for Loki
+	// versions not supporting it.
+	StructuredMetadataDrop = "drop"
+	// StructuredMetadataLabels adds the structured metadata to the stream labels.
+	StructuredMetadataLabels = "labels"
+	// StructuredMetadataSend sends the structured metadata along with the entries.
+	StructuredMetadataSend = "send"
+)
+
 type Configs struct {
 	StreamLagLabels dskit_flagext.StringSliceCSV `yaml:"stream_lag_labels,omitempty"`
-	Configs         []Config                     `yaml:"configs"`
+	// DryRunDropOnBackpressure makes the dry-run logger drop entries instead of
+	// blocking when it can't keep up with them.
+	DryRunDropOnBackpressure bool     `yaml:"dry_run_drop_on_backpressure,omitempty"`
+	Configs                  []Config `yaml:"configs"`
 }
 
 // Config describes configuration for a HTTP pusher client.
@@ -44,6 +58,10 @@ type Config struct {
 	// The tenant ID to use when pushing logs to Loki (empty string means
 	// single tenant mode)
 	TenantID string `yaml:"tenant_id"`
+
+	// How to handle the structured metadata of entries, one of drop, labels
+	// or send. Only Loki versions supporting it accept send.
+	StructuredMetadata string `yaml:"structured_metadata"`
 }
 
 // RegisterFlags with prefix registers flags where every name is prefixed by
@@ -53,6 +71,7 @@ func (c *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.DurationVar(&c.BatchWait, prefix+"client.batch-wait", BatchWait, "Maximum wait period before sending batch.")
 	f.IntVar(&c.BatchSize, prefix+"client.batch-size-bytes", BatchSize, "Maximum batch size to accrue before sending. ")
 	// Default backoff schedule: 0.5s, 1s, 2s, 4s, 8s, 16s, 32s, 64s, 128s, 256s(4.267m) For a total time of 511.5s(8.5m) before logs are lost
+	// Each wait is jittered up to twice the scheduled value.
 	f.IntVar(&c.BackoffConfig.MaxRetries, prefix+"client.max-retries", MaxRetries, "Maximum number of retires when sending batches.")
 	f.DurationVar(&c.BackoffConfig.MinBackoff, prefix+"client.min-backoff", MinBackoff, "Initial backoff time between retries.")
 	f.DurationVar(&c.BackoffConfig.MaxBackoff, prefix+"client.max-backoff", MaxBackoff, "Maximum backoff time between retries.")
@@ -60,6 +79,7 @@ func (c *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.Var(&c.ExternalLabels, prefix+"client.external-labels", "list of external labels to add to each log (e.g: --client.external-labels=lb1=v1,lb2=v2)")
 
 	f.StringVar(&c.TenantID, prefix+"client.tenant-id", "", "Tenant ID to use when pushing logs to Loki.")
+	f.StringVar(&c.StructuredMetadata, prefix+"client.structured-metadata", StructuredMetadataDrop, "How to handle the structured metadata of entries: drop it, add it to the stream labels (labels) or send it to Loki (send), which requires Loki to support it.")
 }
 
 // RegisterFlags registers flags.
@@ -82,9 +102,10 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 				MaxRetries: MaxRetries,
 				MinBackoff: MinBackoff,
 			},
-			BatchSize: BatchSize,
-			BatchWait: BatchWait,
-			Timeout:   Timeout,
+			BatchSize:          BatchSize,
+			BatchWait:          BatchWait,
+			Timeout:            Timeout,
+			StructuredMetadata: StructuredMetadataDrop,
 		}
 	}
 
@@ -48,9 +48,10 @@ func Test_Config(t *testing.T) {
 					MaxRetries: MaxRetries,
 					MinBackoff: MinBackoff,
 				},
-				BatchSize: BatchSize,
-				BatchWait: BatchWait,
-				Timeout:   Timeout,
+				BatchSize:          BatchSize,
+				BatchWait:          BatchWait,
+				Timeout:            Timeout,
+				StructuredMetadata: StructuredMetadataDrop,
 			},
 		},
 		{
@@ -64,9 +65,10 @@ func Test_Config(t *testing.T) {
 					MaxRetries: 20,
 					MinBackoff: 5 * time.Second,
 				},
-				BatchSize: 100 * 2048,
-				BatchWait: 5 * time.Second,
-				Timeout:   5 * time.Second,
+				BatchSize:          100 * 2048,
+				BatchWait:          5 * time.Second,
+				Timeout:            5 * time.Second,
+				StructuredMetadata: StructuredMetadataDrop,
 			},
 		},
 	}
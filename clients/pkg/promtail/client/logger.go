@@ -2,13 +2,17 @@ package client
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"runtime"
 	"sync"
 	"text/tabwriter"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	"gopkg.in/yaml.v2"
 
 	"github.com/pao214/loki/clients/pkg/promtail/api"
@@ -26,18 +30,37 @@ func init() {
 	}
 }
 
+// loggerBufferSize is the number of entries buffered by a logger dropping
+// entries on backpressure before it starts dropping them.
+const loggerBufferSize = 1024
+
 type logger struct {
 	*tabwriter.Writer
 	sync.Mutex
 	entries chan api.Entry
+	// in receives the entries when dropping on backpressure, they are
+	// forwarded to entries unless its buffer is full.
+	in chan api.Entry
+
+	batchSize int
+	batchWait time.Duration
+
+	metrics         *Metrics
+	streamLagLabels []string
+	// clients holds the host and client labels of the configured clients,
+	// the stream lag is reported as if each of them had sent the entries.
+	clients []prometheus.Labels
 
 	once sync.Once
+	wg   sync.WaitGroup
 }
 
 // NewLogger creates a new client logger that logs entries instead of sending them.
-func NewLogger(metrics *Metrics, streamLogLabels []string, log log.Logger, cfgs ...Config) (Client, error) {
+// When dropOnBackpressure is set, entries are dropped instead of blocking the
+// sender once the logger can't keep up.
+func NewLogger(metrics *Metrics, streamLagLabels []string, dropOnBackpressure bool, log log.Logger, cfgs ...Config) (Client, error) {
 	// make sure the clients config is valid
-	c, err := NewMulti(metrics, streamLogLabels, log, cfgs...)
+	c, err := NewMulti(metrics, streamLagLabels, log, cfgs...)
 	if err != nil {
 		return nil, err
 	}
@@ -52,32 +75,146 @@ func NewLogger(metrics *Metrics, streamLogLabels []string, log log.Logger, cfgs
 		fmt.Println("----------------------")
 		fmt.Println(string(yaml))
 	}
-	entries := make(chan api.Entry)
+	return newLogger(os.Stdout, metrics, streamLagLabels, dropOnBackpressure, cfgs...), nil
+}
+
+// newLogger makes a logger writing to w, cfgs must not be empty.
+func newLogger(w io.Writer, metrics *Metrics, streamLagLabels []string, dropOnBackpressure bool, cfgs ...Config) *logger {
 	l := &logger{
-		Writer:  tabwriter.NewWriter(os.Stdout, 0, 8, 0, '\t', 0),
-		entries: entries,
+		Writer:          tabwriter.NewWriter(w, 0, 8, 0, '\t', 0),
+		entries:         make(chan api.Entry),
+		batchSize:       cfgs[0].BatchSize,
+		batchWait:       cfgs[0].BatchWait,
+		metrics:         metrics,
+		streamLagLabels: streamLagLabels,
+	}
+	// entries are batched like the most eager of the configured clients would
+	for _, cfg := range cfgs {
+		if cfg.BatchSize < l.batchSize {
+			l.batchSize = cfg.BatchSize
+		}
+		if cfg.BatchWait < l.batchWait {
+			l.batchWait = cfg.BatchWait
+		}
+		l.clients = append(l.clients, prometheus.Labels{HostLabel: cfg.URL.Host, ClientLabel: clientName(cfg)})
+	}
+	if dropOnBackpressure {
+		l.entries = make(chan api.Entry, loggerBufferSize)
+		l.in = make(chan api.Entry)
+		go l.forward()
 	}
+	l.wg.Add(1)
 	go l.run()
-	return l, nil
+	return l
 }
 
+// Stop the logger, printing any buffered entries first.
 func (l *logger) Stop() {
-	l.once.Do(func() { close(l.entries) })
+	l.once.Do(func() {
+		if l.in != nil {
+			close(l.in)
+			return
+		}
+		close(l.entries)
+	})
+	l.wg.Wait()
 }
 
 func (l *logger) Chan() chan<- api.Entry {
+	if l.in != nil {
+		return l.in
+	}
 	return l.entries
 }
 
+// forward passes the received entries on to be printed, dropping them when the
+// buffer is full rather than blocking the sender.
+func (l *logger) forward() {
+	defer close(l.entries)
+	for e := range l.in {
+		select {
+		case l.entries <- e:
+		default:
+			for _, client := range l.clients {
+				l.metrics.dryRunDropped.WithLabelValues(client[HostLabel]).Inc()
+			}
+		}
+	}
+}
+
+// run buffers entries and prints them once adding an entry would grow the
+// batch over BatchSize bytes or the oldest buffered entry is BatchWait old,
+// mirroring how the client batches pushes.
 func (l *logger) run() {
-	for e := range l.entries {
-		fmt.Fprint(l.Writer, blue.Sprint(e.Timestamp.Format("2006-01-02T15:04:05.999999999-0700")))
-		fmt.Fprint(l.Writer, "\t")
-		fmt.Fprint(l.Writer, yellow.Sprint(e.Labels.String()))
-		fmt.Fprint(l.Writer, "\t")
-		fmt.Fprint(l.Writer, e.Line)
-		fmt.Fprint(l.Writer, "\n")
+	defer l.wg.Done()
+
+	// same check frequency as the client, see client.run
+	checkFrequency := l.batchWait / 10
+	if checkFrequency < 10*time.Millisecond {
+		checkFrequency = 10 * time.Millisecond
+	}
+	maxWaitCheck := time.NewTicker(checkFrequency)
+	defer maxWaitCheck.Stop()
+
+	var (
+		size      int
+		createdAt time.Time
+	)
+	flush := func() {
 		l.Flush()
+		size = 0
+	}
+
+	for {
+		select {
+		case e, ok := <-l.entries:
+			if !ok {
+				flush()
+				return
+			}
+			if size > 0 && size+len(e.Line) > l.batchSize {
+				flush()
+			}
+			if size == 0 {
+				createdAt = time.Now()
+			}
+			size += len(e.Line)
+			l.write(e)
+			l.updateStreamLag(e)
+
+		case <-maxWaitCheck.C:
+			if size > 0 && time.Since(createdAt) >= l.batchWait {
+				flush()
+			}
+		}
+	}
+}
+
+func (l *logger) write(e api.Entry) {
+	fmt.Fprint(l.Writer, blue.Sprint(e.Timestamp.Format("2006-01-02T15:04:05.999999999-0700")))
+	fmt.Fprint(l.Writer, "\t")
+	fmt.Fprint(l.Writer, yellow.Sprint(e.Labels.String()))
+	fmt.Fprint(l.Writer, "\t")
+	fmt.Fprint(l.Writer, e.Line)
+	fmt.Fprint(l.Writer, "\n")
+}
+
+// updateStreamLag sets the stream lag of the configured clients to the age of e.
+func (l *logger) updateStreamLag(e api.Entry) {
+	if len(l.streamLagLabels) == 0 {
+		return
+	}
+	lag := time.Since(e.Timestamp).Seconds()
+	for _, client := range l.clients {
+		lblSet := make(prometheus.Labels, len(l.streamLagLabels)+len(client))
+		for _, lbl := range l.streamLagLabels {
+			lblSet[lbl] = string(e.Labels[model.LabelName(lbl)])
+		}
+		for name, value := range client {
+			lblSet[name] = value
+		}
+		l.metrics.streamLag.With(lblSet).Set(lag)
 	}
 }
+
 func (l *logger) StopNow() { l.Stop() }
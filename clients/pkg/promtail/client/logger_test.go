@@ -1,11 +1,16 @@
 package client
 
 import (
+	"bytes"
 	"net/url"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	cortexflag "github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 
@@ -16,11 +21,121 @@ import (
 )
 
 func TestNewLogger(t *testing.T) {
-	_, err := NewLogger(nilMetrics, nil, util_log.Logger, []Config{}...)
+	_, err := NewLogger(nilMetrics, nil, false, util_log.Logger, []Config{}...)
 	require.Error(t, err)
 
-	l, err := NewLogger(nilMetrics, nil, util_log.Logger, []Config{{URL: cortexflag.URLValue{URL: &url.URL{Host: "string"}}}}...)
+	l, err := NewLogger(nilMetrics, nil, false, util_log.Logger, []Config{{URL: cortexflag.URLValue{URL: &url.URL{Host: "string"}}}}...)
 	require.NoError(t, err)
 	l.Chan() <- api.Entry{Labels: model.LabelSet{"foo": "bar"}, Entry: logproto.Entry{Timestamp: time.Now(), Line: "entry"}}
 	l.Stop()
 }
+
+// syncBuffer is a bytes.Buffer safe to read while the logger writes to it.
+type syncBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.String()
+}
+
+func TestLogger_Batching(t *testing.T) {
+	t.Run("batch size", func(t *testing.T) {
+		out := &syncBuffer{}
+		l := newLogger(out, nilMetrics, nil, false, Config{URL: cortexflag.URLValue{URL: &url.URL{Host: "string"}}, BatchSize: 10, BatchWait: time.Hour})
+
+		// line1 and line2 fill the batch up to its size exactly
+		l.Chan() <- logEntries[0]
+		l.Chan() <- logEntries[1]
+		time.Sleep(20 * time.Millisecond)
+		require.Empty(t, out.String())
+
+		// line3 doesn't fit, so the batch is printed before buffering it
+		l.Chan() <- logEntries[2]
+		require.Eventually(t, func() bool {
+			return strings.Contains(out.String(), "line2")
+		}, time.Second, 5*time.Millisecond)
+		require.Contains(t, out.String(), "line1")
+		require.NotContains(t, out.String(), "line3")
+
+		l.Stop()
+		require.Contains(t, out.String(), "line3")
+	})
+
+	t.Run("batch wait", func(t *testing.T) {
+		out := &syncBuffer{}
+		l := newLogger(out, nilMetrics, nil, false, Config{URL: cortexflag.URLValue{URL: &url.URL{Host: "string"}}, BatchSize: 1024, BatchWait: 50 * time.Millisecond})
+		defer l.Stop()
+
+		l.Chan() <- logEntries[0]
+		require.Eventually(t, func() bool {
+			return strings.Contains(out.String(), "line1")
+		}, time.Second, 5*time.Millisecond)
+	})
+}
+
+func TestLogger_StreamLag(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg, []string{"app"})
+
+	l, err := NewLogger(metrics, []string{"app"}, false, util_log.Logger, Config{
+		Name: "local",
+		URL:  cortexflag.URLValue{URL: &url.URL{Host: "loki:3100"}},
+	})
+	require.NoError(t, err)
+	l.Chan() <- api.Entry{Labels: model.LabelSet{"app": "foo", "env": "dev"}, Entry: logproto.Entry{Timestamp: time.Now().Add(-time.Minute), Line: "entry"}}
+	l.Stop()
+
+	lag := testutil.ToFloat64(metrics.streamLag.With(prometheus.Labels{HostLabel: "loki:3100", ClientLabel: "local", "app": "foo"}))
+	require.GreaterOrEqual(t, lag, time.Minute.Seconds())
+}
+
+// blockingWriter blocks every write until release is closed, closing blocked
+// on the first one.
+type blockingWriter struct {
+	once    sync.Once
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.blocked) })
+	<-w.release
+	return len(p), nil
+}
+
+func TestLogger_DropOnBackpressure(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry(), nil)
+	out := &blockingWriter{blocked: make(chan struct{}), release: make(chan struct{})}
+	l := newLogger(out, metrics, nil, true, Config{URL: cortexflag.URLValue{URL: &url.URL{Host: "loki:3100"}}, BatchSize: 1, BatchWait: time.Hour})
+
+	// the second entry makes the logger print the first one and block on the writer.
+	l.Chan() <- logEntries[0]
+	l.Chan() <- logEntries[0]
+	select {
+	case <-out.blocked:
+	case <-time.After(time.Second):
+		t.Fatal("logger didn't write the first entry")
+	}
+
+	// the buffer then fills up and the remaining entries are dropped.
+	const dropped = 10
+	for i := 0; i < loggerBufferSize+dropped; i++ {
+		l.Chan() <- logEntries[0]
+	}
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.dryRunDropped.WithLabelValues("loki:3100")) == dropped
+	}, time.Second, 5*time.Millisecond)
+
+	close(out.release)
+	l.Stop()
+}
@@ -68,7 +68,7 @@ func New(cfg config.Config, metrics *client.Metrics, dryRun bool, opts ...Option
 	}
 	var err error
 	if dryRun {
-		promtail.client, err = client.NewLogger(metrics, cfg.ClientConfigs.StreamLagLabels, promtail.logger, cfg.ClientConfigs.Configs...)
+		promtail.client, err = client.NewLogger(metrics, cfg.ClientConfigs.StreamLagLabels, cfg.ClientConfigs.DryRunDropOnBackpressure, promtail.logger, cfg.ClientConfigs.Configs...)
 		if err != nil {
 			return nil, err
 		}
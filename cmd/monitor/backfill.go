@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.uber.org/zap"
+)
+
+const (
+	// Height of the last block checked for bundle inclusion, stored under loki.output_dir
+	lastProcessedFilename = "last_processed_block"
+	getHeadTimeout        = 10 * time.Second
+)
+
+// Source of the blocks produced while the monitor was down
+type BlockSource interface {
+	HeadNumber(ctx context.Context) (uint64, error)
+	BlockByNumber(ctx context.Context, number uint64) (*types.Block, error)
+	Close()
+}
+
+type nodeBlockSource struct {
+	client *ethclient.Client
+}
+
+// Retrieves the blocks from the local polygon node
+func NewNodeBlockSource(cfg *NodeConfig) (BlockSource, error) {
+	if cfg.Host == nil {
+		return nil, errors.New("Please configure node.host!")
+	}
+
+	client, clientErr := ethclient.Dial(fmt.Sprintf("ws://%v", *cfg.Host))
+	if clientErr != nil {
+		return nil, clientErr
+	}
+	return &nodeBlockSource{client: client}, nil
+}
+
+func (s *nodeBlockSource) HeadNumber(ctx context.Context) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, getHeadTimeout)
+	defer cancel()
+	return s.client.BlockNumber(ctx)
+}
+
+func (s *nodeBlockSource) BlockByNumber(ctx context.Context, number uint64) (*types.Block, error) {
+	ctx, cancel := context.WithTimeout(ctx, getBlockTimeout)
+	defer cancel()
+	return s.client.BlockByNumber(ctx, new(big.Int).SetUint64(number))
+}
+
+// Closes the connection to the node
+func (s *nodeBlockSource) Close() {
+	s.client.Close()
+}
+
+// Whether the blocks missed while the monitor was down are backfilled
+func BackfillEnabled(cfg *LokiConfig) bool {
+	return cfg.MaxBackfillBlocks != nil && *cfg.MaxBackfillBlocks > 0
+}
+
+// Processes the blocks between the last processed height and the current head, in order
+// Nothing is backfilled on the first run, when no height was persisted yet
+// At most loki.max_backfill_blocks of the latest blocks are processed, zero disables the backfill
+// Gives up with the context error once ctx is done
+func BackfillBlocks(ctx context.Context, cfg *LokiConfig, source BlockSource, process func(*types.Block), logger *zap.Logger) error {
+	if !BackfillEnabled(cfg) {
+		return nil
+	}
+
+	last, found, readErr := readLastProcessed(cfg)
+	if readErr != nil {
+		return readErr
+	}
+	if !found {
+		return nil
+	}
+
+	head, headErr := source.HeadNumber(ctx)
+	if headErr != nil {
+		return headErr
+	}
+	if head <= last {
+		return nil
+	}
+
+	start := last + 1
+	if maxBlocks := *cfg.MaxBackfillBlocks; head-last > maxBlocks {
+		start = head - maxBlocks + 1
+		logger.Warn(
+			"Too many blocks missed, skipping the oldest ones",
+			zap.Uint64("from", last+1),
+			zap.Uint64("to", start-1),
+		)
+	}
+
+	logger.Info("Backfilling bundle inclusion", zap.Uint64("from", start), zap.Uint64("to", head))
+	for number := start; number <= head; number++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		block, blockErr := source.BlockByNumber(ctx, number)
+		if blockErr != nil {
+			return blockErr
+		}
+		process(block)
+	}
+	return nil
+}
+
+// Returns the persisted height of the last processed block, if any
+func readLastProcessed(cfg *LokiConfig) (uint64, bool, error) {
+	if cfg.OutputDir == nil {
+		return 0, false, errors.New("Please configure loki.output_dir!")
+	}
+
+	content, readErr := os.ReadFile(path.Join(*cfg.OutputDir, lastProcessedFilename))
+	if errors.Is(readErr, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if readErr != nil {
+		return 0, false, readErr
+	}
+
+	number, parseErr := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+	if parseErr != nil {
+		return 0, false, fmt.Errorf("invalid %v: %w", lastProcessedFilename, parseErr)
+	}
+	return number, true, nil
+}
+
+// Persists the height of the last processed block
+// Written to a temporary file first so that a crash doesn't leave a truncated height behind
+func writeLastProcessed(cfg *LokiConfig, number uint64) error {
+	if cfg.OutputDir == nil {
+		return errors.New("Please configure loki.output_dir!")
+	}
+
+	filename := path.Join(*cfg.OutputDir, lastProcessedFilename)
+	tmpFilename := filename + ".tmp"
+	if err := os.WriteFile(tmpFilename, []byte(strconv.FormatUint(number, 10)), 0664); err != nil {
+		return err
+	}
+	return os.Rename(tmpFilename, filename)
+}
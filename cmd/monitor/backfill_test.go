@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeBlockSource struct {
+	head   uint64
+	closed bool
+}
+
+func (s *fakeBlockSource) HeadNumber(_ context.Context) (uint64, error) {
+	return s.head, nil
+}
+
+func (s *fakeBlockSource) BlockByNumber(_ context.Context, number uint64) (*types.Block, error) {
+	if number > s.head {
+		return nil, errors.New("block not found")
+	}
+	return types.NewBlockWithHeader(&types.Header{Number: new(big.Int).SetUint64(number)}), nil
+}
+
+func (s *fakeBlockSource) Close() {
+	s.closed = true
+}
+
+func TestBackfillBlocks(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		lastProcessed *uint64
+		maxBlocks     uint64
+		expected      []uint64
+	}{
+		{name: "gap", lastProcessed: newUint64(10), maxBlocks: 150, expected: []uint64{11, 12, 13}},
+		{name: "bounded", lastProcessed: newUint64(10), maxBlocks: 2, expected: []uint64{12, 13}},
+		{name: "disabled", lastProcessed: newUint64(10), maxBlocks: 0},
+		{name: "up to date", lastProcessed: newUint64(13), maxBlocks: 150},
+		{name: "first run", maxBlocks: 150},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			outputDir := t.TempDir()
+			cfg := &LokiConfig{OutputDir: &outputDir, MaxBackfillBlocks: &tc.maxBlocks}
+			if tc.lastProcessed != nil {
+				require.NoError(t, writeLastProcessed(cfg, *tc.lastProcessed))
+			}
+
+			var processed []uint64
+			err := BackfillBlocks(context.Background(), cfg, &fakeBlockSource{head: 13}, func(block *types.Block) {
+				processed = append(processed, block.NumberU64())
+			}, zap.NewNop())
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, processed)
+		})
+	}
+}
+
+func TestBackfillBlocks_Cancelled(t *testing.T) {
+	outputDir := t.TempDir()
+	maxBlocks := uint64(150)
+	cfg := &LokiConfig{OutputDir: &outputDir, MaxBackfillBlocks: &maxBlocks}
+	require.NoError(t, writeLastProcessed(cfg, 10))
+
+	// stopped after the first block
+	ctx, cancel := context.WithCancel(context.Background())
+	var processed []uint64
+	err := BackfillBlocks(ctx, cfg, &fakeBlockSource{head: 13}, func(block *types.Block) {
+		processed = append(processed, block.NumberU64())
+		cancel()
+	}, zap.NewNop())
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, []uint64{11}, processed)
+}
+
+func TestLastProcessed(t *testing.T) {
+	outputDir := t.TempDir()
+	cfg := &LokiConfig{OutputDir: &outputDir}
+
+	_, found, err := readLastProcessed(cfg)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	require.NoError(t, writeLastProcessed(cfg, 42))
+	number, found, err := readLastProcessed(cfg)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, uint64(42), number)
+}
+
+func newUint64(v uint64) *uint64 {
+	return &v
+}
@@ -11,8 +11,6 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 	"go.uber.org/zap"
 )
 
@@ -20,14 +18,6 @@ const (
 	pollPeriod = 10 * time.Second
 )
 
-var (
-	// Using gauge instead of counter since we do not explicitly increment the "height"
-	latestBlock = promauto.NewGauge(prometheus.GaugeOpts{
-		Name: "polygon_blocknum",
-		Help: "Latest polygon blocknum polled periodically every 10s",
-	})
-)
-
 type AlchemyConfig struct {
 	Host    *string `toml:"host,omitempty"`
 	Version *string `toml:"version,omitempty"`
@@ -56,13 +46,21 @@ type Response struct {
 	Jsonrpc interface{} `json:"jsonrpc"`
 	Id      interface{} `json:id`
 	Result  hexutil.Big `json:"result"`
+	// Set instead of result when the request failed
+	Error *ResponseError `json:"error,omitempty"`
+}
+
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
 }
 
 // Publish the latest block number periodically
 // Returns
 // - error during setup
 // - cancel function to stop the goroutine
-func RunBlocknumPublisher(cfg *AlchemyConfig, logger *zap.Logger) (func(), error) {
+// The time of the latest successful poll is published to state
+func RunBlocknumPublisher(cfg *AlchemyConfig, state *HealthState, metrics *monitorMetrics, logger *zap.Logger) (func(), error) {
 	parsedURL, parseErr := getURL(cfg)
 	if parseErr != nil {
 		return nil, parseErr
@@ -81,10 +79,13 @@ func RunBlocknumPublisher(cfg *AlchemyConfig, logger *zap.Logger) (func(), error
 	go func() {
 		for {
 			// publish block number every 10 seconds
-			publishErr := PublishBlocknum(parsedURL, reqBytes, logger)
+			publishErr := PublishBlocknum(parsedURL, reqBytes, metrics, logger)
 			if publishErr != nil {
 				// log error and continue
 				logger.Debug("Failed to publish block", zap.Error(publishErr))
+			} else {
+				state.SetAlchemyLastSuccess(time.Now())
+				state.MarkBlocknumPublished()
 			}
 
 			// Wait until
@@ -129,7 +130,7 @@ func newRequest() ([]byte, error) {
 }
 
 // Update prometheus metric using the result from alchemy
-func PublishBlocknum(parsedURL string, reqBytes []byte, logger *zap.Logger) error {
+func PublishBlocknum(parsedURL string, reqBytes []byte, metrics *monitorMetrics, logger *zap.Logger) error {
 	// Post http request
 	resp, respErr := http.Post(parsedURL, "application/json", bytes.NewReader(reqBytes))
 	if respErr != nil {
@@ -143,11 +144,14 @@ func PublishBlocknum(parsedURL string, reqBytes []byte, logger *zap.Logger) erro
 	if decErr != nil {
 		return decErr
 	}
+	if jsonResp.Error != nil {
+		return fmt.Errorf("alchemy returned error %d: %s", jsonResp.Error.Code, jsonResp.Error.Message)
+	}
 
 	// Update the latest block number prometheus metric
 	blocknum := jsonResp.Result.ToInt().Uint64()
 	logger.Debug("Prometheus polygon_blocknum metric update", zap.Uint64("blocknum", blocknum))
-	latestBlock.Set(float64(blocknum))
+	metrics.latestBlock.Set(float64(blocknum))
 
 	// No errors
 	return nil
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPublishBlocknum(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	reqBytes, err := newRequest()
+	require.NoError(t, err)
+	metrics := newMonitorMetrics("", prometheus.NewRegistry())
+
+	body = `{"jsonrpc": "2.0", "id": 0, "result": "0x2a"}`
+	require.NoError(t, PublishBlocknum(server.URL, reqBytes, metrics, zap.NewNop()))
+	require.Equal(t, 42.0, testutil.ToFloat64(metrics.latestBlock))
+
+	body = `{"jsonrpc": "2.0", "id": 0, "error": {"code": 429, "message": "Your app has exceeded its compute units per second capacity"}}`
+	err = PublishBlocknum(server.URL, reqBytes, metrics, zap.NewNop())
+	require.EqualError(t, err, "alchemy returned error 429: Your app has exceeded its compute units per second capacity")
+	require.Equal(t, 42.0, testutil.ToFloat64(metrics.latestBlock))
+}
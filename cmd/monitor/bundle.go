@@ -3,20 +3,26 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/url"
 	"os"
 	"path"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/core/types"
+	lru "github.com/hashicorp/golang-lru"
 	strftime "github.com/itchyny/timefmt-go"
 	"github.com/pao214/loki/pkg/logcli/client"
 	"github.com/pao214/loki/pkg/logcli/output"
 	"github.com/pao214/loki/pkg/logcli/query"
+	"github.com/pao214/loki/pkg/loghttp"
+	"github.com/pao214/loki/pkg/logproto"
 	"github.com/prometheus/common/config"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -26,6 +32,8 @@ import (
 
 const (
 	windowPeriod = 5 * time.Minute
+	// Maximum number of blocks waiting for loki to be reachable again
+	maxQueuedBlocks = 1000
 )
 
 type LokiConfig struct {
@@ -33,13 +41,25 @@ type LokiConfig struct {
 	OutputDir *string `toml:"output_dir"`
 	Username  *string `toml:"username"`
 	Password  *string `toml:"password"`
+	// Bearer token to authenticate with, exclusive with the username and password
+	BearerToken *string `toml:"bearer_token"`
+	// Tenant to query, sent as the X-Scope-OrgID header
+	OrgID *string `toml:"org_id"`
+	// Maximum number of blocks missed while the monitor was down to check on startup, zero disables the backfill
+	MaxBackfillBlocks *uint64 `toml:"max_backfill_blocks,omitempty"`
+	// Number of (blocknum, bundle_hash) pairs remembered to avoid logging a bundle twice
+	DedupCacheSize *int `toml:"dedup_cache_size,omitempty"`
 }
 
 func GetDefaultLokiConfig() *LokiConfig {
 	defaultLokiHost := "localhost:3100"
+	defaultMaxBackfillBlocks := uint64(150)
+	defaultDedupCacheSize := 10000
 	return &LokiConfig{
-		Host:      &defaultLokiHost,
-		OutputDir: nil,
+		Host:              &defaultLokiHost,
+		OutputDir:         nil,
+		MaxBackfillBlocks: &defaultMaxBackfillBlocks,
+		DedupCacheSize:    &defaultDedupCacheSize,
 	}
 }
 
@@ -48,7 +68,18 @@ type LogEntry struct {
 	Txns       []string `json:"txns"`
 }
 
-func RunBundleDetector(cfg *LokiConfig, blockCh chan *types.Block, logger *zap.Logger) (func(), error) {
+// Logs the bundles included in the blocks received on blockCh
+// The blocks missed since the last run are retrieved from source, if not nil, and processed first
+// source is closed once the backfill is done
+// Whether loki is reachable is published to state, blocks are queued while it isn't and processed in order once it is
+// The height of the last processed block is persisted only as long as no block was skipped
+func RunBundleDetector(
+	cfg *LokiConfig,
+	source BlockSource,
+	blockCh chan *types.Block,
+	state *HealthState,
+	logger *zap.Logger,
+) (func(), error) {
 	lokiLogger, logErr := newLokiLogger(cfg)
 	if logErr != nil {
 		return nil, logErr
@@ -59,18 +90,118 @@ func RunBundleDetector(cfg *LokiConfig, blockCh chan *types.Block, logger *zap.L
 		return nil, clientErr
 	}
 
+	emitted, emittedErr := newEmittedBundles(cfg)
+	if emittedErr != nil {
+		return nil, emittedErr
+	}
+
 	stopCh := make(chan struct{})
 	stop := func() {
 		stopCh <- struct{}{}
 	}
 
+	// Height of the last processed block, blocks at or below it are skipped
+	var lastProcessed uint64
+	// Whether every block up to lastProcessed was processed, the height is only persisted
+	// while it is so that the backfill picks up the skipped blocks on the next run
+	contiguous := true
+	// Returns false if the block couldn't be processed and must be retried
+	process := func(block *types.Block) bool {
+		if queryErr := LogIncludedBundles(lokiLogger, queryClient, block, emitted, logger); queryErr != nil {
+			state.SetLokiReachable(false)
+			logger.Warn("Couldn't query loki, will retry the block", zap.Uint64("blocknum", block.NumberU64()), zap.Error(queryErr))
+			return false
+		}
+		state.SetLokiReachable(true)
+
+		number := block.NumberU64()
+		if contiguous && lastProcessed != 0 && number != lastProcessed+1 {
+			contiguous = false
+			logger.Warn(
+				"Blocks skipped, no longer persisting the last processed block",
+				zap.Uint64("from", lastProcessed+1),
+				zap.Uint64("to", number-1),
+			)
+		}
+		lastProcessed = number
+		if !contiguous {
+			return true
+		}
+		if writeErr := writeLastProcessed(cfg, lastProcessed); writeErr != nil {
+			logger.Error("Couldn't persist the last processed block", zap.Error(writeErr))
+		}
+		return true
+	}
+
+	// The blocks to process, oldest first
+	// A block that can't be processed is retried along with the next ones, so that blocks are processed in order
+	var queued []*types.Block
+	enqueue := func(block *types.Block) {
+		if len(queued) >= maxQueuedBlocks {
+			// the block is skipped, which stops persisting the height
+			logger.Warn("Too many blocks waiting for loki, skipping block", zap.Uint64("blocknum", block.NumberU64()))
+			return
+		}
+		queued = append(queued, block)
+		for len(queued) > 0 && process(queued[0]) {
+			queued[0] = nil
+			queued = queued[1:]
+		}
+	}
+
+	// The missed blocks are retrieved in the background, and processed in order before the new ones
+	// A nil source disables the backfill
+	ctx, cancel := context.WithCancel(context.Background())
+	var backfillCh chan *types.Block
+	if source != nil {
+		backfillCh = make(chan *types.Block)
+		go func(backfilled chan<- *types.Block) {
+			defer close(backfilled)
+			defer source.Close()
+			backfillErr := BackfillBlocks(ctx, cfg, source, func(block *types.Block) {
+				select {
+				case backfilled <- block:
+				case <-ctx.Done():
+				}
+			}, logger)
+			if backfillErr != nil && !errors.Is(backfillErr, context.Canceled) {
+				logger.Error("Couldn't backfill the missed blocks", zap.Error(backfillErr))
+			}
+		}(backfillCh)
+	}
+
+	processNew := func(block *types.Block) {
+		// already processed or queued by the backfill
+		if block.NumberU64() <= lastProcessed || (len(queued) > 0 && block.NumberU64() <= queued[len(queued)-1].NumberU64()) {
+			return
+		}
+		enqueue(block)
+	}
+
 	go func() {
 		defer lokiLogger.Sync()
+		defer cancel()
 
+		// The new blocks received while backfilling, processed once the backfill is done
+		var pending []*types.Block
 		for {
 			select {
+			case block, ok := <-backfillCh:
+				if ok {
+					enqueue(block)
+					continue
+				}
+				backfillCh = nil
+				for _, block := range pending {
+					processNew(block)
+				}
+				pending = nil
 			case block := <-blockCh:
-				LogIncludedBundles(lokiLogger, queryClient, block, logger)
+				if backfillCh != nil {
+					pending = append(pending, block)
+					continue
+				}
+				processNew(block)
 			case <-stopCh:
 				return
 			}
@@ -97,7 +228,7 @@ func newLokiLogger(cfg *LokiConfig) (*zap.Logger, error) {
 
 // log directory format - base_dir/YYMMDD
 func getOutputPath(cfg *LokiConfig) (string, error) {
-	if cfg.OutputDir != nil {
+	if cfg.OutputDir == nil {
 		return "", errors.New("Please configure loki.output_dir!")
 	}
 	// today's date for filenames
@@ -123,11 +254,15 @@ func newQueryClient(cfg *LokiConfig) (client.Client, error) {
 		return nil, errors.New("Please configure loki.host!")
 	}
 
-	urlObj, urlErr := url.Parse(*cfg.Host)
+	urlObj, urlErr := parseLokiURL(*cfg.Host)
 	if urlErr != nil {
 		return nil, urlErr
 	}
-	client.TLSConfig.ServerName = urlObj.Host
+	client.Address = urlObj.String()
+	// plaintext endpoints don't need any TLS setting
+	if urlObj.Scheme == "https" {
+		client.TLSConfig.ServerName = urlObj.Hostname()
+	}
 
 	if cfg.Username != nil {
 		client.Username = *cfg.Username
@@ -135,23 +270,62 @@ func newQueryClient(cfg *LokiConfig) (client.Client, error) {
 	if cfg.Password != nil {
 		client.Password = *cfg.Password
 	}
+	if cfg.BearerToken != nil {
+		if client.Username != "" || client.Password != "" {
+			return nil, errors.New("Please configure only one of loki.bearer_token and loki.username/loki.password")
+		}
+		client.BearerToken = *cfg.BearerToken
+	}
+	if cfg.OrgID != nil {
+		client.OrgID = *cfg.OrgID
+	}
 
 	return client, nil
 }
 
+// Parses the configured loki host, which is usually a bare host:port.
+// A host without scheme is reached over http if it's local or an IP, https otherwise.
+func parseLokiURL(host string) (*url.URL, error) {
+	if !strings.Contains(host, "://") {
+		scheme := "https"
+		hostname := host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			hostname = h
+		}
+		if hostname == "localhost" || net.ParseIP(hostname) != nil {
+			scheme = "http"
+		}
+		host = scheme + "://" + host
+	}
+
+	urlObj, urlErr := url.Parse(host)
+	if urlErr != nil {
+		return nil, urlErr
+	}
+	if urlObj.Scheme != "http" && urlObj.Scheme != "https" {
+		return nil, fmt.Errorf("invalid loki.host %q: scheme must be http or https", host)
+	}
+	if urlObj.Hostname() == "" {
+		return nil, fmt.Errorf("invalid loki.host %q: missing hostname", host)
+	}
+	return urlObj, nil
+}
+
+// Returns an error if the bundles couldn't be queried from loki
 func LogIncludedBundles(
 	lokiLogger *zap.Logger,
 	queryClient client.Client,
 	block *types.Block,
+	emitted *emittedBundles,
 	logger *zap.Logger,
-) {
+) error {
 	// query bundles
 	blocknum := block.NumberU64()
-	logBytes, logErr := queryBundles(queryClient, blocknum, logger)
+	blockTime := time.Unix(int64(block.Time()), 0)
+	logBytes, logErr := queryBundles(queryClient, blocknum, blockTime, logger)
 	if logErr != nil {
-		return
+		return logErr
 	}
-	logReader := bufio.NewReader(bytes.NewReader(logBytes))
 	txns := block.Transactions()
 
 	// Compute txn hashes
@@ -160,6 +334,22 @@ func LogIncludedBundles(
 		txnHashes = append(txnHashes, txn.Hash().String())
 	}
 
+	logBundles(lokiLogger, logBytes, blocknum, txnHashes, emitted, logger)
+	return nil
+}
+
+// Logs the bundles of the loki query output that are included in the block
+// The bundles already logged for the block are skipped
+func logBundles(
+	lokiLogger *zap.Logger,
+	logBytes []byte,
+	blocknum uint64,
+	txnHashes []string,
+	emitted *emittedBundles,
+	logger *zap.Logger,
+) {
+	logReader := bufio.NewReader(bytes.NewReader(logBytes))
+
 	// Read them line-by-line
 	for {
 		lineBytes, lineErr := logReader.ReadBytes('\n')
@@ -182,6 +372,11 @@ func LogIncludedBundles(
 		}
 
 		if isBundleIncluded(logEntry.Txns, txnHashes) {
+			// Consecutive query windows overlap
+			if !emitted.add(blocknum, logEntry.BundleHash) {
+				continue
+			}
+
 			// Output all included bundles
 			// message ignored in log
 			lokiLogger.Info("",
@@ -192,8 +387,8 @@ func LogIncludedBundles(
 	}
 }
 
-func queryBundles(queryClient client.Client, blocknum uint64, logger *zap.Logger) ([]byte, error) {
-	bundleQuery := newQuery(blocknum)
+func queryBundles(queryClient client.Client, blocknum uint64, blockTime time.Time, logger *zap.Logger) ([]byte, error) {
+	bundleQuery := newQuery(blocknum, blockTime)
 
 	jsonRespBytes := new(bytes.Buffer)
 	outputOptions := &output.LogOutputOptions{
@@ -207,14 +402,37 @@ func queryBundles(queryClient client.Client, blocknum uint64, logger *zap.Logger
 		return nil, outErr
 	}
 
-	bundleQuery.DoQuery(queryClient, out, false)
+	// Not using DoQuery, which exits the process when loki can't be queried
+	resp, queryErr := queryClient.QueryRange(
+		bundleQuery.QueryString,
+		bundleQuery.Limit,
+		bundleQuery.Start,
+		bundleQuery.End,
+		logproto.BACKWARD,
+		bundleQuery.Step,
+		bundleQuery.Interval,
+		bundleQuery.Quiet,
+	)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	streams, ok := resp.Data.Result.(loghttp.Streams)
+	if !ok {
+		return nil, fmt.Errorf("unexpected %v result for the bundle query", resp.Data.ResultType)
+	}
+	for _, stream := range streams {
+		for _, entry := range stream.Entries {
+			out.FormatAndPrintln(entry.Timestamp, stream.Labels, 0, entry.Line)
+		}
+	}
 	return jsonRespBytes.Bytes(), nil
 }
 
-func newQuery(blocknum uint64) *query.Query {
-	// Look for the bundles in the specified window
+func newQuery(blocknum uint64, blockTime time.Time) *query.Query {
+	// Look for the bundles in the specified window before the block
+	// Anchored on the block time so that backfilled blocks find their bundles too
 	end := time.Now()
-	start := end.Add(-windowPeriod)
+	start := blockTime.Add(-windowPeriod)
 
 	// Construct the query
 	q := &query.Query{}
@@ -227,6 +445,34 @@ func newQuery(blocknum uint64) *query.Query {
 	return q
 }
 
+type emittedBundle struct {
+	blocknum   uint64
+	bundleHash string
+}
+
+// Remembers the latest bundles logged as included
+type emittedBundles struct {
+	cache *lru.Cache
+}
+
+func newEmittedBundles(cfg *LokiConfig) (*emittedBundles, error) {
+	if cfg.DedupCacheSize == nil || *cfg.DedupCacheSize <= 0 {
+		return nil, errors.New("Please configure a positive loki.dedup_cache_size!")
+	}
+
+	cache, cacheErr := lru.New(*cfg.DedupCacheSize)
+	if cacheErr != nil {
+		return nil, cacheErr
+	}
+	return &emittedBundles{cache: cache}, nil
+}
+
+// Returns false if the bundle was already logged for the block
+func (e *emittedBundles) add(blocknum uint64, bundleHash string) bool {
+	found, _ := e.cache.ContainsOrAdd(emittedBundle{blocknum: blocknum, bundleHash: bundleHash}, struct{}{})
+	return !found
+}
+
 func isBundleIncluded(bundleTxns []string, blockTxns []string) bool {
 	numBlockTxns := len(blockTxns)
 	numBundleTxns := len(bundleTxns)
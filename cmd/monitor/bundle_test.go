@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pao214/loki/pkg/logcli/client"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewQueryClient(t *testing.T) {
+	for _, tc := range []struct {
+		host       string
+		address    string
+		serverName string
+	}{
+		{host: "localhost:3100", address: "http://localhost:3100"},
+		{host: "https://loki.example.com", address: "https://loki.example.com", serverName: "loki.example.com"},
+		{host: "10.0.0.1:3100", address: "http://10.0.0.1:3100"},
+		{host: "http://loki.example.com:3100", address: "http://loki.example.com:3100"},
+		{host: "https://10.0.0.1:3100", address: "https://10.0.0.1:3100", serverName: "10.0.0.1"},
+		{host: "loki.example.com:443", address: "https://loki.example.com:443", serverName: "loki.example.com"},
+	} {
+		t.Run(tc.host, func(t *testing.T) {
+			host := tc.host
+			c, err := newQueryClient(&LokiConfig{Host: &host})
+			require.NoError(t, err)
+			require.Equal(t, tc.address, c.(*client.DefaultClient).Address)
+			require.Equal(t, tc.serverName, c.(*client.DefaultClient).TLSConfig.ServerName)
+		})
+	}
+}
+
+func TestNewQueryClient_InvalidHost(t *testing.T) {
+	for _, host := range []string{"http://:3100", "ftp://loki.example.com"} {
+		host := host
+		_, err := newQueryClient(&LokiConfig{Host: &host})
+		require.Error(t, err, host)
+	}
+}
+
+func TestNewQueryClient_Auth(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+		_, _ = w.Write([]byte(`{"status": "success", "data": []}`))
+	}))
+	defer server.Close()
+
+	host, token, orgID := server.URL, "secret", "tenant-1"
+	c, err := newQueryClient(&LokiConfig{Host: &host, BearerToken: &token, OrgID: &orgID})
+	require.NoError(t, err)
+
+	end := time.Now()
+	_, err = c.ListLabelNames(true, end.Add(-time.Minute), end)
+	require.NoError(t, err)
+	h := <-headers
+	require.Equal(t, "Bearer secret", h.Get("Authorization"))
+	require.Equal(t, "tenant-1", h.Get("X-Scope-OrgID"))
+
+	username := "user"
+	_, err = newQueryClient(&LokiConfig{Host: &host, Username: &username, BearerToken: &token})
+	require.Error(t, err)
+}
+
+func TestLogBundles_Dedup(t *testing.T) {
+	buf := &bytes.Buffer{}
+	lokiLogger := zap.New(zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(buf),
+		zap.InfoLevel,
+	))
+	size := 10
+	emitted, err := newEmittedBundles(&LokiConfig{DedupCacheSize: &size})
+	require.NoError(t, err)
+
+	txnHashes := []string{"0x1", "0x2", "0x3"}
+	// consecutive windows return some of the same bundles
+	windows := []string{
+		`{"bundle_hash": "0xa", "txns": ["0x1"]}` + "\n" + `{"bundle_hash": "0xb", "txns": ["0x2", "0x3"]}`,
+		`{"bundle_hash": "0xb", "txns": ["0x2", "0x3"]}` + "\n" + `{"bundle_hash": "0xc", "txns": ["0x3"]}`,
+		`{"bundle_hash": "0xc", "txns": ["0x3"]}`,
+	}
+	for _, blocknum := range []uint64{42, 43} {
+		for _, window := range windows {
+			logBundles(lokiLogger, []byte(window), blocknum, txnHashes, emitted, zap.NewNop())
+		}
+	}
+
+	var logged []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		entry := struct {
+			Blocknum   uint64 `json:"blocknum"`
+			BundleHash string `json:"bundle_hash"`
+		}{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		logged = append(logged, fmt.Sprintf("%d/%s", entry.Blocknum, entry.BundleHash))
+	}
+	require.Equal(t, []string{"42/0xa", "42/0xb", "42/0xc", "43/0xa", "43/0xb", "43/0xc"}, logged)
+}
+
+func TestNewEmittedBundles_InvalidSize(t *testing.T) {
+	size := 0
+	_, err := newEmittedBundles(&LokiConfig{DedupCacheSize: &size})
+	require.Error(t, err)
+}
+
+// Answers the queries of the bundle detector with no bundles, the blocks queried are sent on queried
+// Fails every request while unreachable is set
+type fakeLoki struct {
+	*httptest.Server
+	unreachable atomic.Bool
+	queried     chan string
+}
+
+func newFakeLoki(t *testing.T) *fakeLoki {
+	l := &fakeLoki{queried: make(chan string, 100)}
+	l.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.unreachable.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path != "/loki/api/v1/query_range" {
+			http.NotFound(w, r)
+			return
+		}
+		l.queried <- r.URL.Query().Get("query")
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "streams", "result": []}}`))
+	}))
+	t.Cleanup(l.Close)
+	return l
+}
+
+func newDetectorConfig(t *testing.T, host string) *LokiConfig {
+	outputDir := t.TempDir()
+	cacheSize, maxBackfillBlocks := 10, uint64(150)
+	return &LokiConfig{Host: &host, OutputDir: &outputDir, DedupCacheSize: &cacheSize, MaxBackfillBlocks: &maxBackfillBlocks}
+}
+
+func newBlock(number int64) *types.Block {
+	return types.NewBlockWithHeader(&types.Header{Number: big.NewInt(number), Time: uint64(time.Now().Unix())})
+}
+
+func requireLastProcessed(t *testing.T, cfg *LokiConfig, expected uint64) {
+	require.Eventually(t, func() bool {
+		number, found, err := readLastProcessed(cfg)
+		return err == nil && found && number == expected
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestRunBundleDetector_OutputDir(t *testing.T) {
+	cfg := newDetectorConfig(t, newFakeLoki(t).URL)
+
+	blockCh := make(chan *types.Block)
+	stop, err := RunBundleDetector(cfg, nil, blockCh, NewHealthState(), zap.NewNop())
+	require.NoError(t, err)
+	defer stop()
+
+	blockCh <- newBlock(5)
+	requireLastProcessed(t, cfg, 5)
+
+	// the bundles are logged under output_dir
+	matches, err := filepath.Glob(filepath.Join(*cfg.OutputDir, "*", "bundles.log"))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+}
+
+func TestRunBundleDetector_RetriesWhileLokiUnreachable(t *testing.T) {
+	loki := newFakeLoki(t)
+	cfg := newDetectorConfig(t, loki.URL)
+
+	blockCh := make(chan *types.Block)
+	state := NewHealthState()
+	stop, err := RunBundleDetector(cfg, nil, blockCh, state, zap.NewNop())
+	require.NoError(t, err)
+	defer stop()
+
+	blockCh <- newBlock(5)
+	requireLastProcessed(t, cfg, 5)
+	require.Equal(t, "{blocknum=5}", <-loki.queried)
+
+	// block 6 is retried with block 7 once loki is reachable again
+	loki.unreachable.Store(true)
+	blockCh <- newBlock(6)
+	require.Eventually(t, func() bool { return !state.Health().LokiReachable }, 5*time.Second, 10*time.Millisecond)
+	loki.unreachable.Store(false)
+	blockCh <- newBlock(7)
+
+	requireLastProcessed(t, cfg, 7)
+	require.Equal(t, "{blocknum=6}", <-loki.queried)
+	require.Equal(t, "{blocknum=7}", <-loki.queried)
+}
+
+func TestRunBundleDetector_SkippedBlocksNotPersisted(t *testing.T) {
+	loki := newFakeLoki(t)
+	cfg := newDetectorConfig(t, loki.URL)
+
+	blockCh := make(chan *types.Block)
+	stop, err := RunBundleDetector(cfg, nil, blockCh, NewHealthState(), zap.NewNop())
+	require.NoError(t, err)
+
+	blockCh <- newBlock(5)
+	requireLastProcessed(t, cfg, 5)
+
+	// block 6 never arrived, the next run must backfill it
+	blockCh <- newBlock(7)
+	// returns once block 7 was processed
+	stop()
+	require.Equal(t, "{blocknum=5}", <-loki.queried)
+	require.Equal(t, "{blocknum=7}", <-loki.queried)
+	number, _, err := readLastProcessed(cfg)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), number)
+}
+
+func TestRunBundleDetector_MissingOutputDir(t *testing.T) {
+	host, cacheSize := "localhost:3100", 10
+	_, err := RunBundleDetector(&LokiConfig{Host: &host, DedupCacheSize: &cacheSize}, nil, make(chan *types.Block), NewHealthState(), zap.NewNop())
+	require.EqualError(t, err, "Please configure loki.output_dir!")
+}
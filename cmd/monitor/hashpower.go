@@ -2,20 +2,13 @@ package main
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/emirpasic/gods/sets/hashset"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/ethereum/go-ethereum/common"
 	"go.uber.org/zap"
 )
 
-var (
-	mevTotal = promauto.NewCounter(prometheus.CounterOpts{
-		Name: "polygon_mev_total",
-		Help: "Latest polygon blocknum polled periodically every 10s",
-	})
-)
-
 type HashpowerConfig struct {
 	Whitelist []string `toml:"whitelist"`
 }
@@ -27,13 +20,16 @@ func GetDefaultHashpowerConfig() *HashpowerConfig {
 }
 
 // Update the block counter every time we encounter a block produced by a validator running mev polygon
-func RunMevBlockDetector(cfg *HashpowerConfig, authorCh chan string, logger *zap.Logger) (func(), error) {
+func RunMevBlockDetector(cfg *HashpowerConfig, authorCh chan string, metrics *monitorMetrics, logger *zap.Logger) (func(), error) {
 	if cfg.Whitelist == nil {
 		return nil, errors.New("Please configure hashpower.whitelist")
 	}
 	whitelist := hashset.New()
 	for _, val := range cfg.Whitelist {
-		whitelist.Add(val)
+		if !common.IsHexAddress(val) {
+			return nil, fmt.Errorf("invalid address %q in hashpower.whitelist", val)
+		}
+		whitelist.Add(canonicalAddress(val))
 	}
 
 	stopCh := make(chan struct{})
@@ -42,15 +38,23 @@ func RunMevBlockDetector(cfg *HashpowerConfig, authorCh chan string, logger *zap
 	}
 
 	go func() {
-		select {
-		case author := <-authorCh:
-			if whitelist.Contains(author) {
-				mevTotal.Inc()
+		for {
+			select {
+			case author := <-authorCh:
+				if whitelist.Contains(canonicalAddress(author)) {
+					metrics.mevTotal.Inc()
+				}
+			case <-stopCh:
+				return
 			}
-		case <-stopCh:
-			return
 		}
 	}()
 
 	return stop, nil
 }
+
+// canonicalAddress returns the EIP-55 checksummed form of the address,
+// so that addresses compare equal whatever their case.
+func canonicalAddress(address string) string {
+	return common.HexToAddress(address).Hex()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRunMevBlockDetector(t *testing.T) {
+	authorCh := make(chan string)
+	metrics := newMonitorMetrics("", prometheus.NewRegistry())
+	stop, err := RunMevBlockDetector(&HashpowerConfig{
+		Whitelist: []string{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"},
+	}, authorCh, metrics, zap.NewNop())
+	require.NoError(t, err)
+
+	authorCh <- "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	authorCh <- "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"
+	// the detector handles the stop after the authors sent before
+	stop()
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.mevTotal))
+}
+
+func TestRunMevBlockDetector_InvalidWhitelist(t *testing.T) {
+	_, err := RunMevBlockDetector(&HashpowerConfig{
+		Whitelist: []string{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "not-an-address"},
+	}, make(chan string), newMonitorMetrics("", prometheus.NewRegistry()), zap.NewNop())
+	require.EqualError(t, err, `invalid address "not-an-address" in hashpower.whitelist`)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// Health is a snapshot of the state of every monitor subsystem
+// Served as JSON on the /status endpoint
+type Health struct {
+	// Whether the websocket subscription to the local polygon node is up
+	WebsocketConnected bool `json:"websocket_connected"`
+
+	// Seconds elapsed since the latest block received over the websocket was produced
+	// null until the first block is received
+	LastBlockAgeSeconds *float64 `json:"last_block_age_seconds"`
+
+	// Time of the latest successful block number poll from alchemy
+	// null until the first successful poll
+	AlchemyLastSuccess *time.Time `json:"alchemy_last_success"`
+
+	// Whether the latest request to loki succeeded
+	LokiReachable bool `json:"loki_reachable"`
+}
+
+// HealthState is shared by the runners to publish the state of their subsystem
+// Safe for concurrent use
+type HealthState struct {
+	mtx sync.Mutex
+
+	websocketConnected bool
+	lastBlockTime      time.Time
+	alchemyLastSuccess time.Time
+	lokiReachable      bool
+
+	// Clock used to compute the block age, replaced in tests
+	now func() time.Time
+
+	// Flipped once the websocket subscription and the blocknum publisher
+	// first succeeded, the monitor is ready once both are set
+	websocketSubscribed atomic.Bool
+	blocknumPublished   atomic.Bool
+}
+
+func NewHealthState() *HealthState {
+	return &HealthState{
+		now: time.Now,
+	}
+}
+
+func (s *HealthState) SetWebsocketConnected(connected bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.websocketConnected = connected
+}
+
+// Records the production time of the latest block
+func (s *HealthState) SetLastBlockTime(t time.Time) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.lastBlockTime = t
+}
+
+func (s *HealthState) SetAlchemyLastSuccess(t time.Time) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.alchemyLastSuccess = t
+}
+
+// Records that the websocket subscription succeeded
+func (s *HealthState) MarkWebsocketSubscribed() {
+	s.websocketSubscribed.Store(true)
+}
+
+// Records that the block number was published
+func (s *HealthState) MarkBlocknumPublished() {
+	s.blocknumPublished.Store(true)
+}
+
+// Whether the websocket subscription and the blocknum publisher have both
+// succeeded at least once
+func (s *HealthState) Ready() bool {
+	return s.websocketSubscribed.Load() && s.blocknumPublished.Load()
+}
+
+func (s *HealthState) SetLokiReachable(reachable bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.lokiReachable = reachable
+}
+
+// Returns a snapshot of the state of all the subsystems
+func (s *HealthState) Health() Health {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	health := Health{
+		WebsocketConnected: s.websocketConnected,
+		LokiReachable:      s.lokiReachable,
+	}
+	if !s.lastBlockTime.IsZero() {
+		age := s.now().Sub(s.lastBlockTime).Seconds()
+		health.LastBlockAgeSeconds = &age
+	}
+	if !s.alchemyLastSuccess.IsZero() {
+		lastSuccess := s.alchemyLastSuccess
+		health.AlchemyLastSuccess = &lastSuccess
+	}
+	return health
+}
+
+// Serves the health report as JSON
+func HealthHandler(state *HealthState) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(state.Health()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// Liveness probe, succeeds as long as the server is serving
+func HealthzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+}
+
+// Readiness probe, succeeds only once the monitor is ready
+func ReadyzHandler(state *HealthState) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !state.Ready() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ready"))
+	})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthHandler(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	state := NewHealthState()
+	state.now = func() time.Time { return now }
+
+	get := func() string {
+		rec := httptest.NewRecorder()
+		HealthHandler(state).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		return rec.Body.String()
+	}
+
+	// nothing published yet
+	require.JSONEq(t, `{
+		"websocket_connected": false,
+		"last_block_age_seconds": null,
+		"alchemy_last_success": null,
+		"loki_reachable": false
+	}`, get())
+
+	state.SetWebsocketConnected(true)
+	state.SetLastBlockTime(now.Add(-4 * time.Second))
+	state.SetAlchemyLastSuccess(now.Add(-time.Minute))
+	state.SetLokiReachable(true)
+	require.JSONEq(t, `{
+		"websocket_connected": true,
+		"last_block_age_seconds": 4,
+		"alchemy_last_success": "2022-03-01T11:59:00Z",
+		"loki_reachable": true
+	}`, get())
+
+	state.SetWebsocketConnected(false)
+	state.SetLokiReachable(false)
+	require.JSONEq(t, `{
+		"websocket_connected": false,
+		"last_block_age_seconds": 4,
+		"alchemy_last_success": "2022-03-01T11:59:00Z",
+		"loki_reachable": false
+	}`, get())
+}
+
+func TestProbeHandlers(t *testing.T) {
+	state := NewHealthState()
+
+	get := func(h http.Handler, path string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	// nothing succeeded yet
+	require.Equal(t, http.StatusOK, get(HealthzHandler(), "/healthz"))
+	require.Equal(t, http.StatusServiceUnavailable, get(ReadyzHandler(state), "/readyz"))
+
+	// both subsystems must have succeeded
+	state.MarkWebsocketSubscribed()
+	require.Equal(t, http.StatusServiceUnavailable, get(ReadyzHandler(state), "/readyz"))
+
+	state.MarkBlocknumPublished()
+	require.Equal(t, http.StatusOK, get(HealthzHandler(), "/healthz"))
+	require.Equal(t, http.StatusOK, get(ReadyzHandler(state), "/readyz"))
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	logFormatJSON    = "json"
+	logFormatConsole = "console"
+)
+
+type LogConfig struct {
+	// Minimum level of the logged messages: debug, info, warn, error, ...
+	Level *string `toml:"level,omitempty"`
+
+	// Encoding of the logs: json or console
+	Format *string `toml:"format,omitempty"`
+}
+
+func GetDefaultLogConfig() *LogConfig {
+	level := "info"
+	format := logFormatJSON
+	return &LogConfig{
+		Level:  &level,
+		Format: &format,
+	}
+}
+
+// Builds the zap configuration matching cfg
+// json logs use the production defaults and console logs the development ones
+func newZapConfig(cfg *LogConfig) (zap.Config, error) {
+	var zapCfg zap.Config
+	switch *cfg.Format {
+	case logFormatJSON:
+		zapCfg = zap.NewProductionConfig()
+	case logFormatConsole:
+		zapCfg = zap.NewDevelopmentConfig()
+	default:
+		return zap.Config{}, fmt.Errorf("unknown log format %q, expected %s or %s", *cfg.Format, logFormatJSON, logFormatConsole)
+	}
+
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(*cfg.Level)); err != nil {
+		return zap.Config{}, err
+	}
+	zapCfg.Level = zap.NewAtomicLevelAt(level)
+	return zapCfg, nil
+}
+
+func newLogger(cfg *LogConfig) (*zap.Logger, error) {
+	zapCfg, err := newZapConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return zapCfg.Build()
+}
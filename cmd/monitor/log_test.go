@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	toml "github.com/pelletier/go-toml"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewZapConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		config   string
+		level    zapcore.Level
+		encoding string
+		err      bool
+	}{
+		{name: "defaults", level: zapcore.InfoLevel, encoding: "json"},
+		{name: "console", config: "[log]\nlevel = \"debug\"\nformat = \"console\"\n", level: zapcore.DebugLevel, encoding: "console"},
+		{name: "json", config: "[log]\nlevel = \"warn\"\nformat = \"json\"\n", level: zapcore.WarnLevel, encoding: "json"},
+		{name: "only level", config: "[log]\nlevel = \"error\"\n", level: zapcore.ErrorLevel, encoding: "json"},
+		{name: "unknown format", config: "[log]\nformat = \"xml\"\n", err: true},
+		{name: "unknown level", config: "[log]\nlevel = \"loud\"\n", err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := GetDefaultConfig()
+			require.NoError(t, toml.NewDecoder(strings.NewReader(tc.config)).Strict(true).Decode(cfg))
+
+			zapCfg, err := newZapConfig(cfg.Log)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.level, zapCfg.Level.Level())
+			require.Equal(t, tc.encoding, zapCfg.Encoding)
+		})
+	}
+}
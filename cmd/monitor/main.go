@@ -8,6 +8,8 @@ import (
 	"syscall"
 
 	toml "github.com/pelletier/go-toml"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/urfave/cli/v2"
 	"go.uber.org/zap"
 )
@@ -19,12 +21,12 @@ var (
 		Aliases: []string{"c"},
 		Usage:   "Load TOML based configuration from `FILE`",
 	}
-
-	// switch to true when debugging
-	devMode = true
 )
 
 type Config struct {
+	// Configures the level and format of the logs
+	Log *LogConfig `toml:"log,omitempty"`
+
 	// Configures the /metrics endpoint that exposes prometheus metrics
 	Prometheus *PromConfig `toml:"prometheus,omitempty"`
 
@@ -43,6 +45,7 @@ type Config struct {
 
 func GetDefaultConfig() *Config {
 	return &Config{
+		Log:        GetDefaultLogConfig(),
 		Prometheus: GetDefaultPromConfig(),
 		Node:       GetDefaultNodeConfig(),
 		Alchemy:    GetDefaultAlchemyConfig(),
@@ -52,7 +55,11 @@ func GetDefaultConfig() *Config {
 }
 
 func main() {
-	logger := newLogger()
+	// Logs until the configured logger is built
+	logger, logErr := newLogger(GetDefaultLogConfig())
+	if logErr != nil {
+		panic(logErr)
+	}
 	// Lifecycle of the logger must extend over all the goroutines using this logger
 	defer logger.Sync()
 
@@ -80,12 +87,31 @@ func monitor(ctx *cli.Context, logger *zap.Logger) error {
 		return loadErr
 	}
 
+	// Switch to the logger configured in the config file
+	logger, logErr := newLogger(cfg.Log)
+	if logErr != nil {
+		return logErr
+	}
+	defer logger.Sync()
+
+	// State of the subsystems published by the runners
+	state := NewHealthState()
+
+	// Metrics defined by the monitor, prefixed with the configured namespace
+	var namespace string
+	if cfg.Prometheus.Namespace != nil {
+		namespace = *cfg.Prometheus.Namespace
+	}
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	metrics := newMonitorMetrics(namespace, reg)
+
 	// Export the metrics endpoint for prometheus
-	promErrorCh, stopProm := RunPromMetrics(cfg.Prometheus, logger)
+	promErrorCh, stopProm := RunPromMetrics(cfg.Prometheus, reg, state, logger)
 	defer stopProm()
 
 	// Run websocket client to retrieve new blocks
-	wsAuthorCh, wsBlockCh, wsErrorCh, stopWS, wsErr := RunWebsocketClient(cfg.Node, logger)
+	wsAuthorCh, wsBlockCh, wsErrorCh, stopWS, wsErr := RunWebsocketClient(cfg.Node, state, logger)
 	if wsErr != nil {
 		return wsErr
 	}
@@ -93,22 +119,35 @@ func monitor(ctx *cli.Context, logger *zap.Logger) error {
 
 	// Periodically publish the latest polygon blockchain height
 	// The data is retrieved using the alchemy API
-	stopBlocknum, blocknumErr := RunBlocknumPublisher(cfg.Alchemy, logger)
+	stopBlocknum, blocknumErr := RunBlocknumPublisher(cfg.Alchemy, state, metrics, logger)
 	if blocknumErr != nil {
 		return blocknumErr
 	}
 	defer stopBlocknum()
 
 	// Publish count of mev blocks produced metric
-	stopBlockDetector, whitelistErr := RunMevBlockDetector(cfg.Hashpower, wsAuthorCh, logger)
+	stopBlockDetector, whitelistErr := RunMevBlockDetector(cfg.Hashpower, wsAuthorCh, metrics, logger)
 	if whitelistErr != nil {
 		return whitelistErr
 	}
 	defer stopBlockDetector()
 
 	// Check bundle inclusion
-	stopBundleDetector, bundleErr := RunBundleDetector(cfg.Loki, wsBlockCh, logger)
+	// The blocks missed while the monitor was down are retrieved from the local node
+	// The connection is closed by the bundle detector once the backfill is done
+	var blockSource BlockSource
+	if BackfillEnabled(cfg.Loki) {
+		var sourceErr error
+		blockSource, sourceErr = NewNodeBlockSource(cfg.Node)
+		if sourceErr != nil {
+			return sourceErr
+		}
+	}
+	stopBundleDetector, bundleErr := RunBundleDetector(cfg.Loki, blockSource, wsBlockCh, state, logger)
 	if bundleErr != nil {
+		if blockSource != nil {
+			blockSource.Close()
+		}
 		return bundleErr
 	}
 	defer stopBundleDetector()
@@ -127,25 +166,6 @@ func monitor(ctx *cli.Context, logger *zap.Logger) error {
 	}
 }
 
-func newLogger() *zap.Logger {
-	var loggerCfg zap.Config
-	if devMode {
-		loggerCfg = zap.NewDevelopmentConfig()
-		// Uncomment below to output logs to a file
-		// loggerCfg.OutputPaths = []string{
-		// 	"logs/debug.log",
-		// }
-	} else {
-		loggerCfg = zap.NewProductionConfig()
-	}
-	logger, logErr := loggerCfg.Build()
-	if logErr != nil {
-		panic(logErr)
-	}
-
-	return logger
-}
-
 // Extracts configuration required for monitoring
 // options specified in the config file take preference over default options
 func loadConfig(ctx *cli.Context, logger *zap.Logger) (*Config, error) {
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics defined by the monitor
+// Registered on an explicit registry so that the monitor can be instantiated more than once
+// Their names are prefixed with prometheus.namespace
+type monitorMetrics struct {
+	// Using gauge instead of counter since we do not explicitly increment the "height"
+	latestBlock prometheus.Gauge
+	mevTotal    prometheus.Counter
+}
+
+func newMonitorMetrics(namespace string, reg prometheus.Registerer) *monitorMetrics {
+	return &monitorMetrics{
+		latestBlock: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "polygon_blocknum",
+			Help:      "Latest polygon blocknum polled periodically every 10s",
+		}),
+		mevTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "polygon_mev_total",
+			Help:      "Total number of polygon blocks produced by validators running mev polygon, per hashpower.whitelist",
+		}),
+	}
+}
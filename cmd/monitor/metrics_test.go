@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMonitorMetrics_Namespace(t *testing.T) {
+	for _, tc := range []struct {
+		namespace string
+		expected  []string
+	}{
+		{namespace: "", expected: []string{"polygon_blocknum", "polygon_mev_total"}},
+		{namespace: "validator1", expected: []string{"validator1_polygon_blocknum", "validator1_polygon_mev_total"}},
+	} {
+		reg := prometheus.NewRegistry()
+		newMonitorMetrics(tc.namespace, reg)
+
+		families, err := reg.Gather()
+		require.NoError(t, err)
+		var names []string
+		for _, family := range families {
+			names = append(names, family.GetName())
+		}
+		require.Equal(t, tc.expected, names)
+	}
+}
+
+func TestNewMonitorMetrics_SeparateRegistries(t *testing.T) {
+	first := newMonitorMetrics("", prometheus.NewRegistry())
+	second := newMonitorMetrics("", prometheus.NewRegistry())
+
+	first.mevTotal.Inc()
+	require.Equal(t, 1.0, testutil.ToFloat64(first.mevTotal))
+	require.Equal(t, 0.0, testutil.ToFloat64(second.mevTotal))
+}
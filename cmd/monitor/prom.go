@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 )
@@ -15,6 +16,8 @@ const (
 
 type PromConfig struct {
 	Host *string `toml:"host,omitempty"`
+	// Prefix of the metrics defined by the monitor
+	Namespace *string `toml:"namespace,omitempty"`
 }
 
 func GetDefaultPromConfig() *PromConfig {
@@ -25,14 +28,16 @@ func GetDefaultPromConfig() *PromConfig {
 }
 
 // Export the prometheus end point on the configured cfg.Host
+// The metrics registered on reg are served on /metrics
+// The health report of the subsystems is served on /status
+// Liveness and readiness probes are served on /healthz and /readyz
 // Publishes an error on the error channel if the server crashed with an error
 // Also returns a stopping routine to be used to shutdown the server
 //   the server is explicitly shutdown in the scenarios where there are issues with other modules
-func RunPromMetrics(cfg *PromConfig, logger *zap.Logger) (chan error, func()) {
+func RunPromMetrics(cfg *PromConfig, reg *prometheus.Registry, state *HealthState, logger *zap.Logger) (chan error, func()) {
 	errorCh := make(chan error)
 
-	http.Handle("/metrics", promhttp.Handler())
-	server := &http.Server{Addr: *cfg.Host}
+	server := &http.Server{Addr: *cfg.Host, Handler: newPromMux(reg, state)}
 
 	stop := func() {
 		ctx, cancel := context.WithTimeout(context.Background(), promShutdownTimeout)
@@ -53,3 +58,14 @@ func RunPromMetrics(cfg *PromConfig, logger *zap.Logger) (chan error, func()) {
 
 	return errorCh, stop
 }
+
+// Routes the endpoints of a prometheus server
+// Each server gets its own mux rather than the default one, so that several can run in the same process
+func newPromMux(reg *prometheus.Registry, state *HealthState) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	mux.Handle("/status", HealthHandler(state))
+	mux.Handle("/healthz", HealthzHandler())
+	mux.Handle("/readyz", ReadyzHandler(state))
+	return mux
+}
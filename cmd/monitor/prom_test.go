@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPromMux_SeparateRegistries(t *testing.T) {
+	scrape := func(mux *http.ServeMux) string {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+
+	firstReg, secondReg := prometheus.NewRegistry(), prometheus.NewRegistry()
+	first := newMonitorMetrics("first", firstReg)
+	newMonitorMetrics("second", secondReg)
+	first.mevTotal.Inc()
+
+	// each mux serves the metrics of its own registry
+	firstMetrics := scrape(newPromMux(firstReg, NewHealthState()))
+	secondMetrics := scrape(newPromMux(secondReg, NewHealthState()))
+	require.True(t, strings.Contains(firstMetrics, "first_polygon_mev_total 1"), firstMetrics)
+	require.False(t, strings.Contains(firstMetrics, "second_polygon"), firstMetrics)
+	require.True(t, strings.Contains(secondMetrics, "second_polygon_mev_total 0"), secondMetrics)
+}
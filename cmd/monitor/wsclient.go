@@ -37,7 +37,8 @@ func GetDefaultNodeConfig() *NodeConfig {
 // - a channel to get notified of any subscription errors
 // - a stop function to stop the goroutine (in the event of external errors)
 // - an error in launching the service itself
-func RunWebsocketClient(cfg *NodeConfig, logger *zap.Logger) (
+// The connection status and the time of the latest block are published to state
+func RunWebsocketClient(cfg *NodeConfig, state *HealthState, logger *zap.Logger) (
 	chan string,
 	chan *types.Block,
 	chan error,
@@ -63,6 +64,8 @@ func RunWebsocketClient(cfg *NodeConfig, logger *zap.Logger) (
 	if subErr != nil {
 		return nil, nil, nil, nil, subErr
 	}
+	state.SetWebsocketConnected(true)
+	state.MarkWebsocketSubscribed()
 
 	stopCh := make(chan struct{})
 	authorCh := make(chan string)
@@ -75,10 +78,13 @@ func RunWebsocketClient(cfg *NodeConfig, logger *zap.Logger) (
 
 	go func() {
 		defer newHeadsSub.Unsubscribe()
+		defer state.SetWebsocketConnected(false)
 
 		for {
 			select {
 			case header := <-newHeadsCh:
+				state.SetLastBlockTime(time.Unix(int64(header.Time), 0))
+
 				// Retrieve the author
 				number := header.Number.Int64()
 				author, authorErr := getAuthor(client, number)
@@ -286,6 +286,10 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 		return &logproto.PushResponse{}, validationErr
 	}
 
+	if err := d.validator.ValidatePushSize(validationContext, validatedSamplesSize, validatedSamplesCount); err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
 	if !d.ingestionRateLimiter.AllowN(now, userID, validatedSamplesSize) {
 		// Return a 429 to indicate to the client they are being rate limited
@@ -49,11 +49,12 @@ func TestDistributor(t *testing.T) {
 	ingestionRateLimit := 0.000096 // 100 Bytes/s limit
 
 	for i, tc := range []struct {
-		lines            int
-		maxLineSize      uint64
-		mangleLabels     bool
-		expectedResponse *logproto.PushResponse
-		expectedError    error
+		lines               int
+		maxLineSize         uint64
+		maxPushRequestBytes uint64
+		mangleLabels        bool
+		expectedResponse    *logproto.PushResponse
+		expectedError       error
 	}{
 		{
 			lines:            10,
@@ -75,6 +76,11 @@ func TestDistributor(t *testing.T) {
 			expectedResponse: success,
 			expectedError:    httpgrpc.Errorf(http.StatusBadRequest, validation.InvalidLabelsErrorMsg, "{ab\"", "1:4: parse error: unterminated quoted string"),
 		},
+		{
+			lines:               100,
+			maxPushRequestBytes: 500,
+			expectedError:       httpgrpc.Errorf(http.StatusRequestEntityTooLarge, validation.PushTooLargeErrorMsg, 500, "test", 100, 1000),
+		},
 	} {
 		t.Run(fmt.Sprintf("[%d](samples=%v)", i, tc.lines), func(t *testing.T) {
 			limits := &validation.Limits{}
@@ -83,6 +89,7 @@ func TestDistributor(t *testing.T) {
 			limits.IngestionRateMB = ingestionRateLimit
 			limits.IngestionBurstSizeMB = ingestionRateLimit
 			limits.MaxLineSize = fe.ByteSize(tc.maxLineSize)
+			limits.MaxPushRequestBytes = fe.ByteSize(tc.maxPushRequestBytes)
 
 			d := prepare(t, limits, nil, nil)
 			defer services.StopAndAwaitTerminated(context.Background(), d) //nolint:errcheck
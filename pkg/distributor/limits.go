@@ -6,12 +6,14 @@ import "time"
 type Limits interface {
 	MaxLineSize(userID string) int
 	MaxLineSizeTruncate(userID string) bool
+	MaxPushRequestBytes(userID string) int
 	EnforceMetricName(userID string) bool
 	MaxLabelNamesPerSeries(userID string) int
 	MaxLabelNameLength(userID string) int
 	MaxLabelValueLength(userID string) int
 
 	CreationGracePeriod(userID string) time.Duration
+	MaxClockSkew(userID string) time.Duration
 	RejectOldSamples(userID string) bool
 	RejectOldSamplesMaxAge(userID string) time.Duration
 }
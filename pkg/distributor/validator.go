@@ -19,13 +19,33 @@ const (
 
 type Validator struct {
 	Limits
+	userIDResolver UserIDResolver
+}
+
+// UserIDResolver resolves the tenant used for validation from the tenant the request was received for.
+type UserIDResolver interface {
+	ResolveUserID(userID string) string
+}
+
+// UserIDResolverFunc is a function implementing UserIDResolver.
+type UserIDResolverFunc func(userID string) string
+
+func (f UserIDResolverFunc) ResolveUserID(userID string) string {
+	return f(userID)
 }
 
 func NewValidator(l Limits) (*Validator, error) {
+	return NewValidatorWithUserIDResolver(l, nil)
+}
+
+// NewValidatorWithUserIDResolver makes a Validator which looks up limits and reports metrics
+// for the tenant returned by r instead of the tenant the request was received for.
+// A nil resolver uses the request tenant as is.
+func NewValidatorWithUserIDResolver(l Limits, r UserIDResolver) (*Validator, error) {
 	if l == nil {
 		return nil, errors.New("nil Limits")
 	}
-	return &Validator{l}, nil
+	return &Validator{Limits: l, userIDResolver: r}, nil
 }
 
 type validationContext struct {
@@ -35,6 +55,7 @@ type validationContext struct {
 
 	maxLineSize         int
 	maxLineSizeTruncate bool
+	maxPushRequestBytes int
 
 	maxLabelNamesPerSeries int
 	maxLabelNameLength     int
@@ -44,13 +65,19 @@ type validationContext struct {
 }
 
 func (v Validator) getValidationContextForTime(now time.Time, userID string) validationContext {
+	if v.userIDResolver != nil {
+		userID = v.userIDResolver.ResolveUserID(userID)
+	}
+	// the clock skew is tolerated both ways
+	clockSkew := v.MaxClockSkew(userID)
 	return validationContext{
 		userID:                 userID,
 		rejectOldSample:        v.RejectOldSamples(userID),
-		rejectOldSampleMaxAge:  now.Add(-v.RejectOldSamplesMaxAge(userID)).UnixNano(),
-		creationGracePeriod:    now.Add(v.CreationGracePeriod(userID)).UnixNano(),
+		rejectOldSampleMaxAge:  now.Add(-v.RejectOldSamplesMaxAge(userID) - clockSkew).UnixNano(),
+		creationGracePeriod:    now.Add(v.CreationGracePeriod(userID) + clockSkew).UnixNano(),
 		maxLineSize:            v.MaxLineSize(userID),
 		maxLineSizeTruncate:    v.MaxLineSizeTruncate(userID),
+		maxPushRequestBytes:    v.MaxPushRequestBytes(userID),
 		maxLabelNamesPerSeries: v.MaxLabelNamesPerSeries(userID),
 		maxLabelNameLength:     v.MaxLabelNameLength(userID),
 		maxLabelValueLength:    v.MaxLabelValueLength(userID),
@@ -90,6 +117,16 @@ func (v Validator) ValidateEntry(ctx validationContext, labels string, entry log
 	return nil
 }
 
+// ValidatePushSize returns an error if the lines of a push request, across all its streams, are too large in total
+func (v Validator) ValidatePushSize(ctx validationContext, totalBytes, totalLines int) error {
+	if maxSize := ctx.maxPushRequestBytes; maxSize != 0 && totalBytes > maxSize {
+		validation.DiscardedSamples.WithLabelValues(validation.PushTooLarge, ctx.userID).Add(float64(totalLines))
+		validation.DiscardedBytes.WithLabelValues(validation.PushTooLarge, ctx.userID).Add(float64(totalBytes))
+		return httpgrpc.Errorf(http.StatusRequestEntityTooLarge, validation.PushTooLargeErrorMsg, maxSize, ctx.userID, totalLines, totalBytes)
+	}
+	return nil
+}
+
 // Validate labels returns an error if the labels are invalid
 func (v Validator) ValidateLabels(ctx validationContext, ls labels.Labels, stream logproto.Stream) error {
 	if len(ls) == 0 {
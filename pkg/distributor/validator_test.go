@@ -2,13 +2,16 @@ package distributor
 
 import (
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/httpgrpc"
 
 	"github.com/pao214/loki/pkg/logproto"
@@ -101,6 +104,54 @@ func TestValidator_ValidateEntry(t *testing.T) {
 	}
 }
 
+func TestValidator_ClockSkew(t *testing.T) {
+	l := &validation.Limits{}
+	flagext.DefaultValues(l)
+	o, err := validation.NewOverrides(*l, perTenantLimits{
+		"skewed": &validation.Limits{
+			RejectOldSamples:       true,
+			RejectOldSamplesMaxAge: model.Duration(time.Hour),
+			CreationGracePeriod:    model.Duration(20 * time.Minute),
+			MaxClockSkew:           model.Duration(5 * time.Minute),
+		},
+		"strict": &validation.Limits{
+			RejectOldSamples:       true,
+			RejectOldSamplesMaxAge: model.Duration(time.Hour),
+			CreationGracePeriod:    model.Duration(10 * time.Minute),
+		},
+	})
+	require.NoError(t, err)
+	v, err := NewValidator(o)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name   string
+		userID string
+		ts     time.Time
+		reason string
+	}{
+		{name: "skewed oldest accepted", userID: "skewed", ts: testTime.Add(-65 * time.Minute)},
+		{name: "skewed too old", userID: "skewed", ts: testTime.Add(-65*time.Minute - time.Nanosecond), reason: validation.GreaterThanMaxSampleAge},
+		{name: "skewed newest accepted", userID: "skewed", ts: testTime.Add(25 * time.Minute)},
+		{name: "skewed too new", userID: "skewed", ts: testTime.Add(25*time.Minute + time.Nanosecond), reason: validation.TooFarInFuture},
+		{name: "strict oldest accepted", userID: "strict", ts: testTime.Add(-time.Hour)},
+		{name: "strict too old", userID: "strict", ts: testTime.Add(-time.Hour - time.Nanosecond), reason: validation.GreaterThanMaxSampleAge},
+		{name: "strict newest accepted", userID: "strict", ts: testTime.Add(10 * time.Minute)},
+		{name: "strict too new", userID: "strict", ts: testTime.Add(10*time.Minute + time.Nanosecond), reason: validation.TooFarInFuture},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			before := testutil.ToFloat64(validation.DiscardedSamples.WithLabelValues(tc.reason, tc.userID))
+			err := v.ValidateEntry(v.getValidationContextForTime(testTime, tc.userID), testStreamLabels, logproto.Entry{Timestamp: tc.ts, Line: "test"})
+			if tc.reason == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Equal(t, before+1, testutil.ToFloat64(validation.DiscardedSamples.WithLabelValues(tc.reason, tc.userID)))
+		})
+	}
+}
+
 func TestValidator_ValidateLabels(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -202,6 +253,108 @@ func TestValidator_ValidateLabels(t *testing.T) {
 	}
 }
 
+type perTenantLimits map[string]*validation.Limits
+
+func (p perTenantLimits) TenantLimits(userID string) *validation.Limits {
+	return p[userID]
+}
+
+func (p perTenantLimits) AllByUserID() map[string]*validation.Limits {
+	return p
+}
+
+func TestValidator_UserIDResolver(t *testing.T) {
+	l := &validation.Limits{}
+	flagext.DefaultValues(l)
+	o, err := validation.NewOverrides(*l, perTenantLimits{
+		"tenant-a": &validation.Limits{MaxLineSize: 10},
+	})
+	require.NoError(t, err)
+
+	v, err := NewValidatorWithUserIDResolver(o, UserIDResolverFunc(func(userID string) string {
+		return strings.TrimPrefix(userID, "team-")
+	}))
+	require.NoError(t, err)
+
+	ctx := v.getValidationContextForTime(testTime, "team-tenant-a")
+	require.Equal(t, "tenant-a", ctx.userID)
+	require.Equal(t, 10, ctx.maxLineSize)
+
+	err = v.ValidateEntry(ctx, testStreamLabels, logproto.Entry{Timestamp: testTime, Line: "12345678901"})
+	require.Equal(t, httpgrpc.Errorf(http.StatusBadRequest, validation.LineTooLongErrorMsg, 10, testStreamLabels, 11), err)
+
+	// without a resolver the request tenant is used as is.
+	v, err = NewValidator(o)
+	require.NoError(t, err)
+	ctx = v.getValidationContextForTime(testTime, "team-tenant-a")
+	require.Equal(t, "team-tenant-a", ctx.userID)
+	require.Equal(t, l.MaxLineSize.Val(), ctx.maxLineSize)
+}
+
+func TestValidator_RequireDiscard(t *testing.T) {
+	const tenant = "require-discard"
+
+	l := &validation.Limits{}
+	flagext.DefaultValues(l)
+	o, err := validation.NewOverrides(*l, fakeLimits{&validation.Limits{MaxLineSize: 10}})
+	require.NoError(t, err)
+	v, err := NewValidator(o)
+	require.NoError(t, err)
+
+	lineTooLong := discardedSamples(validation.LineTooLong, tenant)
+	tooFarInFuture := discardedSamples(validation.TooFarInFuture, tenant)
+	RequireDiscard(t, validation.LineTooLong, tenant, lineTooLong, 0)
+
+	err = v.ValidateEntry(v.getValidationContextForTime(testTime, tenant), testStreamLabels, logproto.Entry{Timestamp: testTime, Line: "12345678901"})
+	require.Error(t, err)
+
+	RequireDiscard(t, validation.LineTooLong, tenant, lineTooLong, 1)
+	RequireDiscard(t, validation.TooFarInFuture, tenant, tooFarInFuture, 0)
+}
+
+func TestValidator_ValidatePushSize(t *testing.T) {
+	const tenant = "push-size"
+
+	l := &validation.Limits{}
+	flagext.DefaultValues(l)
+	o, err := validation.NewOverrides(*l, fakeLimits{&validation.Limits{MaxPushRequestBytes: 100}})
+	require.NoError(t, err)
+	v, err := NewValidator(o)
+	require.NoError(t, err)
+	ctx := v.getValidationContextForTime(testTime, tenant)
+	pushTooLarge := discardedSamples(validation.PushTooLarge, tenant)
+	pushTooLargeBytes := testutil.ToFloat64(validation.DiscardedBytes.WithLabelValues(validation.PushTooLarge, tenant))
+
+	// at the limit
+	require.NoError(t, v.ValidatePushSize(ctx, 100, 10))
+	RequireDiscard(t, validation.PushTooLarge, tenant, pushTooLarge, 0)
+
+	// over the limit
+	err = v.ValidatePushSize(ctx, 101, 10)
+	require.Equal(t, httpgrpc.Errorf(http.StatusRequestEntityTooLarge, validation.PushTooLargeErrorMsg, 100, tenant, 10, 101), err)
+	RequireDiscard(t, validation.PushTooLarge, tenant, pushTooLarge, 10)
+	require.Equal(t, float64(101), testutil.ToFloat64(validation.DiscardedBytes.WithLabelValues(validation.PushTooLarge, tenant))-pushTooLargeBytes)
+
+	// unlimited by default
+	o, err = validation.NewOverrides(*l, nil)
+	require.NoError(t, err)
+	v, err = NewValidator(o)
+	require.NoError(t, err)
+	require.NoError(t, v.ValidatePushSize(v.getValidationContextForTime(testTime, tenant), 1<<30, 10))
+}
+
+// RequireDiscard asserts the number of samples discarded for the given reason and tenant since baseline,
+// as returned by discardedSamples: the counters are global and keep what previous tests discarded.
+func RequireDiscard(t *testing.T, reason, tenant string, baseline float64, count int) {
+	t.Helper()
+	require.Equal(t, float64(count), discardedSamples(reason, tenant)-baseline, "discarded samples for reason %q and tenant %q", reason, tenant)
+}
+
+// discardedSamples returns the number of samples discarded so far for the given reason and tenant.
+func discardedSamples(reason, tenant string) float64 {
+	return testutil.ToFloat64(validation.DiscardedSamples.WithLabelValues(reason, tenant))
+}
+
 func mustParseLabels(s string) labels.Labels {
 	ls, err := syntax.ParseLabels(s)
 	if err != nil {
@@ -508,6 +508,9 @@ func (w *WALCheckpointWriter) Close(abort bool) error {
 		// We can try again next time.
 		level.Error(util_log.Logger).Log("msg", "error deleting old WAL segments", "err", err, "lastSegment", w.lastSegment)
 	}
+	if err := updatePendingSegments(w.segmentWAL.Dir(), w.metrics); err != nil {
+		level.Warn(util_log.Logger).Log("msg", "failed to compute pending WAL segments", "err", err)
+	}
 
 	if w.lastSegment >= 0 {
 		if err := w.deleteCheckpoints(w.lastSegment); err != nil {
@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -669,3 +671,36 @@ func TestIngesterWALReplaysUnorderedToOrdered(t *testing.T) {
 		})
 	}
 }
+
+func TestWALPendingSegments(t *testing.T) {
+	metrics := newIngesterMetrics(prometheus.NewRegistry(), Config{})
+	w, err := newWAL(WALConfig{Enabled: true, Dir: t.TempDir()}, nil, metrics, nil)
+	require.NoError(t, err)
+	ww := w.(*walWrapper)
+	defer func() { require.NoError(t, ww.wal.Close()) }()
+
+	require.NoError(t, ww.Log(&WALRecord{
+		UserID: "fake",
+		RefEntries: []RefEntries{{
+			Counter: 1,
+			Ref:     1,
+			Entries: []logproto.Entry{{Timestamp: time.Unix(1, 0), Line: "line"}},
+		}},
+	}))
+	// Log accounts the bytes right away
+	logged := testutil.ToFloat64(metrics.walBytesPending)
+	require.Greater(t, logged, 0.0)
+
+	require.NoError(t, updatePendingSegments(ww.wal.Dir(), metrics))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.walSegmentsPending))
+	require.GreaterOrEqual(t, testutil.ToFloat64(metrics.walBytesPending), logged)
+
+	// a checkpoint starts a new segment and removes the ones it covers
+	writer := ww.checkpointWriter()
+	_, err = writer.Advance()
+	require.NoError(t, err)
+	require.NoError(t, writer.Close(false))
+
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.walSegmentsPending))
+	require.Equal(t, 0.0, testutil.ToFloat64(metrics.walBytesPending))
+}
@@ -79,6 +79,8 @@ type Config struct {
 	MaxChunkAge         time.Duration     `yaml:"max_chunk_age"`
 	AutoForgetUnhealthy bool              `yaml:"autoforget_unhealthy"`
 
+	AutoForgetMetricPerIngester bool `yaml:"autoforget_metric_per_ingester"`
+
 	// Synchronization settings. Used to make sure that ingesters cut their chunks at the same moments.
 	SyncPeriod         time.Duration `yaml:"sync_period"`
 	SyncMinUtilization float64       `yaml:"sync_min_utilization"`
@@ -122,6 +124,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.MaxChunkAge, "ingester.max-chunk-age", 2*time.Hour, "Maximum chunk age before flushing.")
 	f.DurationVar(&cfg.QueryStoreMaxLookBackPeriod, "ingester.query-store-max-look-back-period", 0, "How far back should an ingester be allowed to query the store for data, for use only with boltdb-shipper index and filesystem object store. -1 for infinite.")
 	f.BoolVar(&cfg.AutoForgetUnhealthy, "ingester.autoforget-unhealthy", false, "Enable to remove unhealthy ingesters from the ring after `ring.kvstore.heartbeat_timeout`")
+	f.BoolVar(&cfg.AutoForgetMetricPerIngester, "ingester.autoforget-metric-per-ingester", false, "Record the id of every ingester removed by autoforget in loki_ingester_autoforget_unhealthy_ingesters_by_id_total. Each forgotten ingester adds a series, keep disabled when ingester ids aren't stable.")
 	f.IntVar(&cfg.IndexShards, "ingester.index-shards", index.DefaultIndexShards, "Shard factor used in the ingesters for the in process reverse index. This MUST be evenly divisible by ALL schema shard factors or Loki will not start.")
 	f.IntVar(&cfg.MaxDroppedStreams, "ingester.tailer.max-dropped-streams", 10, "Maximum number of dropped streams to keep in memory during tailing")
 }
@@ -229,7 +232,7 @@ func New(cfg Config, clientConfig client.Config, store ChunkStore, limits *valid
 	if cfg.WAL.Enabled {
 		walStats.Set("enabled")
 	}
-	metrics := newIngesterMetrics(registerer)
+	metrics := newIngesterMetrics(registerer, cfg)
 
 	i := &Ingester{
 		cfg:                   cfg,
@@ -357,7 +360,7 @@ func (i *Ingester) setupAutoForget() {
 			for _, id := range forgetList {
 				level.Info(util_log.Logger).Log("msg", fmt.Sprintf("autoforget removed ingester %v from the ring because it was not healthy after %v", id, i.cfg.LifecyclerConfig.RingConfig.HeartbeatTimeout))
 			}
-			i.metrics.autoForgetUnhealthyIngestersTotal.Add(float64(len(forgetList)))
+			i.metrics.ingestersForgotten(forgetList)
 		}
 	}()
 }
@@ -294,6 +294,9 @@ func (i *instance) removeStream(s *stream) {
 		i.streamsRemovedTotal.Inc()
 		memoryStreams.WithLabelValues(i.instanceID).Dec()
 		streamsCountStats.Add(-1)
+		if i.streams.Len() == 0 {
+			i.limiter.RemoveTenant(i.instanceID)
+		}
 	}
 }
 
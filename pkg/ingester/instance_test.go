@@ -14,6 +14,8 @@ import (
 	"github.com/pao214/loki/pkg/querier/astmapper"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
@@ -36,7 +38,7 @@ func defaultConfig() *Config {
 	return &cfg
 }
 
-var NilMetrics = newIngesterMetrics(nil)
+var NilMetrics = newIngesterMetrics(nil, Config{})
 
 func TestLabelsCollisions(t *testing.T) {
 	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
@@ -65,6 +67,34 @@ func TestLabelsCollisions(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestRemoveStream_DropsTenantMetrics(t *testing.T) {
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	metrics := newIngesterMetrics(prometheus.NewRegistry(), Config{})
+	limiter := NewLimiter(limits, metrics, &ringCountMock{count: 1}, 1)
+
+	inst := newInstance(defaultConfig(), "test", limiter, loki_runtime.DefaultTenantConfigs(), noopWAL{}, NilMetrics, &OnceSwitch{}, nil)
+	err = inst.Push(context.Background(), &logproto.PushRequest{Streams: []logproto.Stream{
+		{Labels: `{app="a"}`, Entries: entries(1, time.Now())},
+		{Labels: `{app="b"}`, Entries: entries(1, time.Now())},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.tenantLimiterEnabled))
+
+	var streams []*stream
+	require.NoError(t, inst.forAllStreams(context.Background(), func(s *stream) error {
+		streams = append(streams, s)
+		return nil
+	}))
+	require.Len(t, streams, 2)
+
+	// the gauge is kept until the last stream of the tenant is removed
+	inst.removeStream(streams[0])
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.tenantLimiterEnabled))
+	inst.removeStream(streams[1])
+	require.Equal(t, 0, testutil.CollectAndCount(metrics.tenantLimiterEnabled))
+}
+
 func TestConcurrentPushes(t *testing.T) {
 	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
 	require.NoError(t, err)
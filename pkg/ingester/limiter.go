@@ -134,12 +134,26 @@ type RateLimiterStrategy interface {
 	RateLimit(tenant string) validation.RateLimit
 }
 
+// RateLimit returns the per stream rate limit of tenant, recording whether it is currently applied.
 func (l *Limiter) RateLimit(tenant string) validation.RateLimit {
-	if l.disabled {
-		return validation.Unlimited
+	rl := validation.Unlimited
+	if !l.disabled {
+		rl = l.limits.PerStreamRateLimit(tenant)
+	}
+
+	enabled := 0.0
+	if rl.Limit != rate.Inf {
+		enabled = 1
 	}
+	l.metrics.tenantLimiterEnabled.WithLabelValues(tenant).Set(enabled)
+
+	return rl
+}
 
-	return l.limits.PerStreamRateLimit(tenant)
+// RemoveTenant drops the per tenant metrics of tenant, once it has no streams left.
+// A stream created in the meantime sets them again when rechecking its rate limit.
+func (l *Limiter) RemoveTenant(tenant string) {
+	l.metrics.tenantLimiterEnabled.DeleteLabelValues(tenant)
 }
 
 type StreamRateLimiter struct {
@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/time/rate"
@@ -211,3 +213,27 @@ func TestGoLimiter(t *testing.T) {
 		})
 	}
 }
+
+func TestLimiter_TenantRateLimiterEnabled(t *testing.T) {
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	metrics := newIngesterMetrics(prometheus.NewRegistry(), Config{})
+	limiter := NewLimiter(limits, metrics, &ringCountMock{count: 1}, 1)
+
+	// tenant-a's streams are created during the WAL replay, tenant-b's afterwards
+	limiter.DisableForWALReplay()
+	require.Equal(t, validation.Unlimited, limiter.RateLimit("tenant-a"))
+	limiter.Enable()
+	require.Equal(t, limits.PerStreamRateLimit("tenant-b"), limiter.RateLimit("tenant-b"))
+
+	require.Equal(t, 0.0, testutil.ToFloat64(metrics.tenantLimiterEnabled.WithLabelValues("tenant-a")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.tenantLimiterEnabled.WithLabelValues("tenant-b")))
+
+	// tenant-a's gauge follows once its streams recheck their limit
+	limiter.RateLimit("tenant-a")
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.tenantLimiterEnabled.WithLabelValues("tenant-a")))
+
+	// and is dropped once the tenant has no streams left
+	limiter.RemoveTenant("tenant-a")
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.tenantLimiterEnabled))
+}
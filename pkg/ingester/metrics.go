@@ -12,7 +12,7 @@ type ingesterMetrics struct {
 	checkpointDeleteTotal      prometheus.Counter
 	checkpointCreationFail     prometheus.Counter
 	checkpointCreationTotal    prometheus.Counter
-	checkpointDuration         prometheus.Summary
+	checkpointDuration         prometheus.Observer
 	checkpointLoggedBytesTotal prometheus.Counter
 
 	walDiskFullFailures     prometheus.Counter
@@ -23,6 +23,8 @@ type ingesterMetrics struct {
 	walCorruptionsTotal     *prometheus.CounterVec
 	walLoggedBytesTotal     prometheus.Counter
 	walRecordsLogged        prometheus.Counter
+	walSegmentsPending      prometheus.Gauge
+	walBytesPending         prometheus.Gauge
 
 	recoveredStreamsTotal prometheus.Counter
 	recoveredChunksTotal  prometheus.Counter
@@ -32,9 +34,16 @@ type ingesterMetrics struct {
 	recoveryBytesInUse    prometheus.Gauge
 	recoveryIsFlushing    prometheus.Gauge
 
-	limiterEnabled prometheus.Gauge
+	limiterEnabled       prometheus.Gauge
+	tenantLimiterEnabled *prometheus.GaugeVec
 
-	autoForgetUnhealthyIngestersTotal prometheus.Counter
+	// walReplayDiscardsPerTenant tells whether WAL replay discards are attributed to their tenant.
+	walReplayDiscardsPerTenant bool
+
+	autoForgetUnhealthyIngestersTotal     prometheus.Counter
+	autoForgetUnhealthyIngestersByIDTotal *prometheus.CounterVec
+	// autoForgetPerIngester tells whether forgotten ingesters are recorded by id.
+	autoForgetPerIngester bool
 }
 
 // setRecoveryBytesInUse bounds the bytes reports to >= 0.
@@ -46,6 +55,27 @@ func (m *ingesterMetrics) setRecoveryBytesInUse(v int64) {
 	m.recoveryBytesInUse.Set(float64(v))
 }
 
+// ingestersForgotten records the ingesters removed from the ring by autoforget.
+func (m *ingesterMetrics) ingestersForgotten(ids []string) {
+	m.autoForgetUnhealthyIngestersTotal.Add(float64(len(ids)))
+	if !m.autoForgetPerIngester {
+		return
+	}
+	for _, id := range ids {
+		m.autoForgetUnhealthyIngestersByIDTotal.WithLabelValues(id).Inc()
+	}
+}
+
+// walReplayDiscarded records entries of tenant discarded during WAL replay for reason.
+// The tenant label is left empty unless discards are tracked per tenant.
+func (m *ingesterMetrics) walReplayDiscarded(tenant, reason string, entries, bytes int) {
+	if !m.walReplayDiscardsPerTenant {
+		tenant = ""
+	}
+	m.walReplaySamplesDropped.WithLabelValues(reason, tenant).Add(float64(entries))
+	m.walReplayBytesDropped.WithLabelValues(reason, tenant).Add(float64(bytes))
+}
+
 const (
 	walTypeCheckpoint = "checkpoint"
 	walTypeSegment    = "segment"
@@ -53,8 +83,14 @@ const (
 	duplicateReason = "duplicate"
 )
 
-func newIngesterMetrics(r prometheus.Registerer) *ingesterMetrics {
-	return &ingesterMetrics{
+// newIngesterMetrics registers the ingester metrics. The checkpoint duration is a
+// histogram when cfg.WAL.CheckpointDurationHistogram is set, so it can be aggregated
+// across ingesters, and a summary otherwise.
+func newIngesterMetrics(r prometheus.Registerer, cfg Config) *ingesterMetrics {
+	m := &ingesterMetrics{
+		walReplayDiscardsPerTenant: cfg.WAL.ReplayDiscardsPerTenant,
+		autoForgetPerIngester:      cfg.AutoForgetMetricPerIngester,
+
 		walDiskFullFailures: promauto.With(r).NewCounter(prometheus.CounterOpts{
 			Name: "loki_ingester_wal_disk_full_failures_total",
 			Help: "Total number of wal write failures due to full disk.",
@@ -70,11 +106,11 @@ func newIngesterMetrics(r prometheus.Registerer) *ingesterMetrics {
 		walReplaySamplesDropped: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
 			Name: "loki_ingester_wal_discarded_samples_total",
 			Help: "WAL segment entries discarded during replay",
-		}, []string{validation.ReasonLabel}),
+		}, []string{validation.ReasonLabel, "tenant"}),
 		walReplayBytesDropped: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
 			Name: "loki_ingester_wal_discarded_bytes_total",
 			Help: "WAL segment bytes discarded during replay",
-		}, []string{validation.ReasonLabel}),
+		}, []string{validation.ReasonLabel, "tenant"}),
 		walCorruptionsTotal: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
 			Name: "loki_ingester_wal_corruptions_total",
 			Help: "Total number of WAL corruptions encountered.",
@@ -95,10 +131,13 @@ func newIngesterMetrics(r prometheus.Registerer) *ingesterMetrics {
 			Name: "loki_ingester_checkpoint_creations_total",
 			Help: "Total number of checkpoint creations attempted.",
 		}),
-		checkpointDuration: promauto.With(r).NewSummary(prometheus.SummaryOpts{
-			Name:       "loki_ingester_checkpoint_duration_seconds",
-			Help:       "Time taken to create a checkpoint.",
-			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		walSegmentsPending: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Name: "loki_ingester_wal_segments_pending",
+			Help: "Number of WAL segments on disk which haven't been removed by a checkpoint yet.",
+		}),
+		walBytesPending: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Name: "loki_ingester_wal_bytes_pending",
+			Help: "Size in bytes of the WAL segments on disk which haven't been removed by a checkpoint yet.",
 		}),
 		walRecordsLogged: promauto.With(r).NewCounter(prometheus.CounterOpts{
 			Name: "loki_ingester_wal_records_logged_total",
@@ -144,9 +183,34 @@ func newIngesterMetrics(r prometheus.Registerer) *ingesterMetrics {
 			Name: "loki_ingester_limiter_enabled",
 			Help: "Whether the ingester's limiter is enabled",
 		}),
+		tenantLimiterEnabled: promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "loki_ingester_tenant_rate_limiter_enabled",
+			Help: "Whether per stream rate limiting is currently applied to the tenant's streams",
+		}, []string{"tenant"}),
 		autoForgetUnhealthyIngestersTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
 			Name: "loki_ingester_autoforget_unhealthy_ingesters_total",
 			Help: "Total number of ingesters automatically forgotten",
 		}),
+		autoForgetUnhealthyIngestersByIDTotal: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_ingester_autoforget_unhealthy_ingesters_by_id_total",
+			Help: "Total number of times each ingester was automatically forgotten. Only recorded when enabled.",
+		}, []string{"ingester"}),
 	}
+
+	if cfg.WAL.CheckpointDurationHistogram {
+		m.checkpointDuration = promauto.With(r).NewHistogram(prometheus.HistogramOpts{
+			Name: "loki_ingester_checkpoint_duration_seconds",
+			Help: "Time taken to create a checkpoint.",
+			// 1s -> ~34m
+			Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+		})
+	} else {
+		m.checkpointDuration = promauto.With(r).NewSummary(prometheus.SummaryOpts{
+			Name:       "loki_ingester_checkpoint_duration_seconds",
+			Help:       "Time taken to create a checkpoint.",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		})
+	}
+
+	return m
 }
@@ -0,0 +1,53 @@
+package ingester
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointDurationMetricType(t *testing.T) {
+	for _, tc := range []struct {
+		histogram bool
+		expected  dto.MetricType
+	}{
+		{histogram: false, expected: dto.MetricType_SUMMARY},
+		{histogram: true, expected: dto.MetricType_HISTOGRAM},
+	} {
+		reg := prometheus.NewRegistry()
+		m := newIngesterMetrics(reg, Config{WAL: WALConfig{CheckpointDurationHistogram: tc.histogram}})
+		m.checkpointDuration.Observe(3)
+
+		families, err := reg.Gather()
+		require.NoError(t, err)
+
+		var found bool
+		for _, f := range families {
+			if f.GetName() == "loki_ingester_checkpoint_duration_seconds" {
+				found = true
+				require.Equal(t, tc.expected, f.GetType())
+			}
+		}
+		require.True(t, found)
+	}
+}
+
+func TestIngestersForgotten(t *testing.T) {
+	for _, perIngester := range []bool{true, false} {
+		m := newIngesterMetrics(prometheus.NewRegistry(), Config{AutoForgetMetricPerIngester: perIngester})
+		m.ingestersForgotten([]string{"ingester-1", "ingester-2"})
+		m.ingestersForgotten([]string{"ingester-1"})
+
+		require.Equal(t, 3.0, testutil.ToFloat64(m.autoForgetUnhealthyIngestersTotal))
+		if perIngester {
+			require.Equal(t, 2, testutil.CollectAndCount(m.autoForgetUnhealthyIngestersByIDTotal))
+			require.Equal(t, 2.0, testutil.ToFloat64(m.autoForgetUnhealthyIngestersByIDTotal.WithLabelValues("ingester-1")))
+			require.Equal(t, 1.0, testutil.ToFloat64(m.autoForgetUnhealthyIngestersByIDTotal.WithLabelValues("ingester-2")))
+		} else {
+			require.Equal(t, 0, testutil.CollectAndCount(m.autoForgetUnhealthyIngestersByIDTotal))
+		}
+	}
+}
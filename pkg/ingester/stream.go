@@ -194,8 +194,7 @@ func (s *stream) Push(
 			byteCt += len(e.Line)
 		}
 
-		s.metrics.walReplaySamplesDropped.WithLabelValues(duplicateReason).Add(float64(len(entries)))
-		s.metrics.walReplayBytesDropped.WithLabelValues(duplicateReason).Add(float64(byteCt))
+		s.metrics.walReplayDiscarded(s.tenant, duplicateReason, len(entries), byteCt)
 		return 0, ErrEntriesExist
 	}
 
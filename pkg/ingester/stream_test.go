@@ -9,6 +9,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
@@ -339,6 +341,41 @@ func TestPushRateLimit(t *testing.T) {
 	require.Contains(t, err.Error(), (&validation.ErrStreamRateLimit{RateLimit: l.PerStreamRateLimit, Labels: s.labelsString, Bytes: flagext.ByteSize(len(entries[1].Line))}).Error())
 }
 
+func TestReplayDiscardsPerTenant(t *testing.T) {
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	limiter := NewLimiter(limits, NilMetrics, &ringCountMock{count: 1}, 1)
+
+	for _, perTenant := range []bool{true, false} {
+		metrics := newIngesterMetrics(prometheus.NewRegistry(), Config{WAL: WALConfig{ReplayDiscardsPerTenant: perTenant}})
+
+		for _, tenant := range []string{"tenant-a", "tenant-b"} {
+			s := newStream(defaultConfig(), limiter, tenant, model.Fingerprint(0), labels.Labels{{Name: "foo", Value: "bar"}}, true, metrics)
+
+			_, err := s.Push(context.Background(), []logproto.Entry{
+				{Timestamp: time.Unix(1, 0), Line: "test"},
+			}, recordPool.GetRecord(), 1, true)
+			require.NoError(t, err)
+
+			// replaying the same counter again is discarded
+			_, err = s.Push(context.Background(), []logproto.Entry{
+				{Timestamp: time.Unix(1, 0), Line: "test"},
+			}, recordPool.GetRecord(), 1, true)
+			require.Equal(t, ErrEntriesExist, err)
+		}
+
+		if perTenant {
+			for _, tenant := range []string{"tenant-a", "tenant-b"} {
+				require.Equal(t, 1.0, testutil.ToFloat64(metrics.walReplaySamplesDropped.WithLabelValues(duplicateReason, tenant)))
+				require.Equal(t, 4.0, testutil.ToFloat64(metrics.walReplayBytesDropped.WithLabelValues(duplicateReason, tenant)))
+			}
+		} else {
+			require.Equal(t, 2.0, testutil.ToFloat64(metrics.walReplaySamplesDropped.WithLabelValues(duplicateReason, "")))
+			require.Equal(t, 8.0, testutil.ToFloat64(metrics.walReplayBytesDropped.WithLabelValues(duplicateReason, "")))
+		}
+	}
+}
+
 func TestReplayAppendIgnoresValidityWindow(t *testing.T) {
 	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
 	require.NoError(t, err)
@@ -2,6 +2,7 @@ package ingester
 
 import (
 	"flag"
+	"os"
 	"sync"
 	"time"
 
@@ -21,14 +22,19 @@ var (
 )
 
 const walSegmentSize = wal.DefaultSegmentSize * 4
+
+// pendingSegmentsRefreshInterval is how often the pending WAL segments metrics are recomputed from disk.
+const pendingSegmentsRefreshInterval = 15 * time.Second
 const defaultCeiling = 4 << 30 // 4GB
 
 type WALConfig struct {
-	Enabled             bool             `yaml:"enabled"`
-	Dir                 string           `yaml:"dir"`
-	CheckpointDuration  time.Duration    `yaml:"checkpoint_duration"`
-	FlushOnShutdown     bool             `yaml:"flush_on_shutdown"`
-	ReplayMemoryCeiling flagext.ByteSize `yaml:"replay_memory_ceiling"`
+	Enabled                     bool             `yaml:"enabled"`
+	Dir                         string           `yaml:"dir"`
+	CheckpointDuration          time.Duration    `yaml:"checkpoint_duration"`
+	CheckpointDurationHistogram bool             `yaml:"checkpoint_duration_histogram"`
+	ReplayDiscardsPerTenant     bool             `yaml:"replay_discards_per_tenant"`
+	FlushOnShutdown             bool             `yaml:"flush_on_shutdown"`
+	ReplayMemoryCeiling         flagext.ByteSize `yaml:"replay_memory_ceiling"`
 }
 
 func (cfg *WALConfig) Validate() error {
@@ -43,6 +49,8 @@ func (cfg *WALConfig) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&cfg.Dir, "ingester.wal-dir", "wal", "Directory to store the WAL and/or recover from WAL.")
 	f.BoolVar(&cfg.Enabled, "ingester.wal-enabled", true, "Enable writing of ingested data into WAL.")
 	f.DurationVar(&cfg.CheckpointDuration, "ingester.checkpoint-duration", 5*time.Minute, "Interval at which checkpoints should be created.")
+	f.BoolVar(&cfg.CheckpointDurationHistogram, "ingester.checkpoint-duration-histogram", false, "Expose loki_ingester_checkpoint_duration_seconds as a histogram, which can be aggregated across ingesters, instead of a summary.")
+	f.BoolVar(&cfg.ReplayDiscardsPerTenant, "ingester.wal-replay-discards-per-tenant", true, "Attribute the entries discarded during WAL replay to their tenant in loki_ingester_wal_discarded_samples_total and loki_ingester_wal_discarded_bytes_total. Disable to reduce the cardinality of these metrics.")
 	f.BoolVar(&cfg.FlushOnShutdown, "ingester.flush-on-shutdown", false, "When WAL is enabled, should chunks be flushed to long-term storage on shutdown.")
 
 	// Need to set default here
@@ -123,6 +131,7 @@ func (w *walWrapper) Log(record *WALRecord) error {
 			}
 			w.metrics.walRecordsLogged.Inc()
 			w.metrics.walLoggedBytesTotal.Add(float64(len(buf)))
+			w.metrics.walBytesPending.Add(float64(len(buf)))
 			buf = buf[:0]
 		}
 		if len(record.RefEntries) > 0 {
@@ -132,6 +141,7 @@ func (w *walWrapper) Log(record *WALRecord) error {
 			}
 			w.metrics.walRecordsLogged.Inc()
 			w.metrics.walLoggedBytesTotal.Add(float64(len(buf)))
+			w.metrics.walBytesPending.Add(float64(len(buf)))
 		}
 		return nil
 	}
@@ -156,6 +166,9 @@ func (w *walWrapper) run() {
 	level.Info(util_log.Logger).Log("msg", "started", "component", "wal")
 	defer w.wait.Done()
 
+	w.wait.Add(1)
+	go w.refreshPendingSegments()
+
 	checkpointer := NewCheckpointer(
 		w.cfg.CheckpointDuration,
 		w.seriesIter,
@@ -167,6 +180,55 @@ func (w *walWrapper) run() {
 
 }
 
+// refreshPendingSegments periodically recomputes the pending WAL segments metrics, which Log
+// only approximates in between as it can't tell when a new segment is started.
+func (w *walWrapper) refreshPendingSegments() {
+	defer w.wait.Done()
+
+	ticker := time.NewTicker(pendingSegmentsRefreshInterval)
+	defer ticker.Stop()
+	for {
+		if err := updatePendingSegments(w.wal.Dir(), w.metrics); err != nil {
+			level.Warn(util_log.Logger).Log("msg", "failed to compute pending WAL segments", "err", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// updatePendingSegments sets the pending WAL segments metrics from the segments found in dir.
+func updatePendingSegments(dir string, metrics *ingesterMetrics) error {
+	first, last, err := wal.Segments(dir)
+	if err != nil {
+		return err
+	}
+
+	var (
+		segments int
+		size     int64
+	)
+	for i := first; last >= 0 && i <= last; i++ {
+		fi, err := os.Stat(wal.SegmentName(dir, i))
+		if os.IsNotExist(err) {
+			// removed by a concurrent truncation
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		segments++
+		size += fi.Size()
+	}
+
+	metrics.walSegmentsPending.Set(float64(segments))
+	metrics.walBytesPending.Set(float64(size))
+	return nil
+}
+
 type resettingPool struct {
 	rPool *sync.Pool // records
 	ePool *sync.Pool // entries
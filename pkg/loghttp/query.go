@@ -33,8 +33,9 @@ const (
 
 // QueryResponse represents the http json response to a Loki range and instant query
 type QueryResponse struct {
-	Status string            `json:"status"`
-	Data   QueryResponseData `json:"data"`
+	Status   string            `json:"status"`
+	Data     QueryResponseData `json:"data"`
+	Warnings []string          `json:"warnings,omitempty"`
 }
 
 func (q *QueryResponse) UnmarshalJSON(data []byte) error {
@@ -48,6 +49,12 @@ func (q *QueryResponse) UnmarshalJSON(data []byte) error {
 				return err
 			}
 			q.Data = responseData
+		case "warnings":
+			var warnings []string
+			if err := json.Unmarshal(value, &warnings); err != nil {
+				return err
+			}
+			q.Warnings = warnings
 		}
 		return nil
 	})
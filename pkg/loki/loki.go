@@ -176,6 +176,9 @@ func (c *Config) Validate() error {
 	if err := c.LimitsConfig.Validate(); err != nil {
 		return errors.Wrap(err, "invalid limits config")
 	}
+	if err := c.Frontend.Validate(); err != nil {
+		return errors.Wrap(err, "invalid frontend config")
+	}
 	if err := c.Worker.Validate(util_log.Logger); err != nil {
 		return errors.Wrap(err, "invalid frontend-worker config")
 	}
@@ -268,7 +271,7 @@ func New(cfg Config) (*Loki, error) {
 	if err := loki.setupModuleManager(); err != nil {
 		return nil, err
 	}
-	storage.RegisterCustomIndexClients(&loki.Cfg.StorageConfig, loki.clientMetrics, prometheus.DefaultRegisterer)
+	storage.RegisterCustomIndexClients(&loki.Cfg.StorageConfig, loki.Cfg.SchemaConfig, loki.clientMetrics, prometheus.DefaultRegisterer)
 
 	return loki, nil
 }
@@ -33,6 +33,7 @@ import (
 	"github.com/pao214/loki/pkg/ingester"
 	"github.com/pao214/loki/pkg/logproto"
 	"github.com/pao214/loki/pkg/logql"
+	"github.com/pao214/loki/pkg/lokifrontend"
 	"github.com/pao214/loki/pkg/lokifrontend/frontend"
 	"github.com/pao214/loki/pkg/lokifrontend/frontend/transport"
 	"github.com/pao214/loki/pkg/lokifrontend/frontend/v1/frontendv1pb"
@@ -529,7 +530,11 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 
 	frontendHandler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, util_log.Logger, prometheus.DefaultRegisterer)
 	if t.Cfg.Frontend.CompressResponses {
-		frontendHandler = gziphandler.GzipHandler(frontendHandler)
+		gzipHandler, err := gziphandler.NewGzipLevelHandler(t.Cfg.Frontend.CompressionLevel)
+		if err != nil {
+			return nil, err
+		}
+		frontendHandler = gzipHandler(queryrange.CompressResponsesHTTPMiddleware(t.Cfg.Frontend.CompressionLevel).Wrap(frontendHandler))
 	}
 
 	frontendHandler = middleware.Merge(
@@ -553,6 +558,11 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 		if err != nil {
 			return nil, err
 		}
+		if t.Cfg.Frontend.ProbeTailProxyURL {
+			if err := lokifrontend.ProbeTailProxy(context.Background(), t.Cfg.Frontend.TailProxyURL); err != nil {
+				level.Warn(util_log.Logger).Log("msg", "tail proxy URL is unreachable, tail requests will fail", "url", t.Cfg.Frontend.TailProxyURL, "err", err)
+			}
+		}
 		tp := httputil.NewSingleHostReverseProxy(tailURL)
 
 		director := tp.Director
@@ -764,7 +774,7 @@ func (t *Loki) initIndexGateway() (services.Service, error) {
 		return nil, err
 	}
 
-	shipperIndexClient, err := shipper.NewShipper(t.Cfg.StorageConfig.BoltDBShipperConfig, objectClient, t.overrides, prometheus.DefaultRegisterer)
+	shipperIndexClient, err := shipper.NewShipper(t.Cfg.StorageConfig.BoltDBShipperConfig, t.Cfg.SchemaConfig.SchemaConfig, objectClient, t.overrides, prometheus.DefaultRegisterer)
 	if err != nil {
 		return nil, err
 	}
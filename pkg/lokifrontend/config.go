@@ -1,7 +1,13 @@
 package lokifrontend
 
 import (
+	"compress/gzip"
+	"context"
 	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/pao214/loki/pkg/lokifrontend/frontend/transport"
 	v1 "github.com/pao214/loki/pkg/lokifrontend/frontend/v1"
@@ -14,9 +20,11 @@ type Config struct {
 	FrontendV2 v2.Config               `yaml:",inline"`
 
 	CompressResponses bool   `yaml:"compress_responses"`
+	CompressionLevel  int    `yaml:"compression_level"`
 	DownstreamURL     string `yaml:"downstream_url"`
 
-	TailProxyURL string `yaml:"tail_proxy_url"`
+	TailProxyURL      string `yaml:"tail_proxy_url"`
+	ProbeTailProxyURL bool   `yaml:"probe_tail_proxy_url"`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet.
@@ -26,7 +34,43 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.FrontendV2.RegisterFlags(f)
 
 	f.BoolVar(&cfg.CompressResponses, "querier.compress-http-responses", false, "Compress HTTP responses.")
-	f.StringVar(&cfg.DownstreamURL, "frontend.downstream-url", "", "URL of downstream Prometheus.")
+	f.IntVar(&cfg.CompressionLevel, "querier.compression-level", gzip.DefaultCompression, "Gzip compression level of the HTTP responses, from 1 (best speed) to 9 (best compression). Defaults to the gzip default compression level.")
+	f.StringVar(&cfg.DownstreamURL, "frontend.downstream-url", "", "URL of downstream Prometheus. A comma-separated list of URLs load-balances the requests across them.")
 
 	f.StringVar(&cfg.TailProxyURL, "frontend.tail-proxy-url", "", "URL of querier for tail proxy.")
+	f.BoolVar(&cfg.ProbeTailProxyURL, "frontend.probe-tail-proxy-url", false, "Check that the tail proxy URL is reachable on startup and log a warning if it is not.")
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	if cfg.CompressionLevel != gzip.DefaultCompression && (cfg.CompressionLevel < gzip.BestSpeed || cfg.CompressionLevel > gzip.BestCompression) {
+		return fmt.Errorf("invalid compression level %d, must be between %d and %d", cfg.CompressionLevel, gzip.BestSpeed, gzip.BestCompression)
+	}
+	if cfg.TailProxyURL != "" {
+		u, err := url.Parse(cfg.TailProxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid tail proxy URL: %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid tail proxy URL %q: scheme and host are required", cfg.TailProxyURL)
+		}
+	}
+	return nil
+}
+
+// ProbeTailProxy checks that the tail proxy URL is reachable.
+// Any HTTP response, whatever its status code, means the URL is reachable.
+func ProbeTailProxy(ctx context.Context, tailProxyURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tailProxyURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
 }
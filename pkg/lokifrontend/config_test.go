@@ -0,0 +1,56 @@
+package lokifrontend
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		args        []string
+		expectedErr bool
+	}{
+		{name: "default", args: nil},
+		{name: "best speed", args: []string{"-querier.compression-level=1"}},
+		{name: "best compression", args: []string{"-querier.compression-level=9"}},
+		{name: "no compression", args: []string{"-querier.compression-level=0"}, expectedErr: true},
+		{name: "too high", args: []string{"-querier.compression-level=10"}, expectedErr: true},
+		{name: "negative", args: []string{"-querier.compression-level=-2"}, expectedErr: true},
+		{name: "valid tail proxy url", args: []string{"-frontend.tail-proxy-url=http://querier:3100"}},
+		{name: "malformed tail proxy url", args: []string{"-frontend.tail-proxy-url=://querier"}, expectedErr: true},
+		{name: "tail proxy url without scheme", args: []string{"-frontend.tail-proxy-url=querier:3100"}, expectedErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg Config
+			fs := flag.NewFlagSet("test", flag.PanicOnError)
+			cfg.RegisterFlags(fs)
+			require.NoError(t, fs.Parse(tc.args))
+
+			err := cfg.Validate()
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestProbeTailProxy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	cfg := Config{CompressionLevel: -1, TailProxyURL: server.URL}
+	require.NoError(t, cfg.Validate())
+	require.NoError(t, ProbeTailProxy(context.Background(), cfg.TailProxyURL))
+
+	server.Close()
+	require.Error(t, ProbeTailProxy(context.Background(), cfg.TailProxyURL))
+}
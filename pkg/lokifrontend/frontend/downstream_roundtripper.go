@@ -1,29 +1,57 @@
 package frontend
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 	"path"
+	"strings"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"go.uber.org/atomic"
 )
 
-// RoundTripper that forwards requests to downstream URL.
+// unhealthyDownstreamBackoff is how long a downstream is skipped after a connection error.
+// Responses, whatever their status code, and requests cancelled by the caller don't affect the downstream health.
+const unhealthyDownstreamBackoff = 10 * time.Second
+
+// RoundTripper that forwards requests to downstream URLs, round-robin across the healthy ones.
 type downstreamRoundTripper struct {
-	downstreamURL *url.URL
-	transport     http.RoundTripper
+	downstreams []*downstream
+	next        atomic.Uint64
+	transport   http.RoundTripper
+	now         func() time.Time
+}
+
+type downstream struct {
+	url            *url.URL
+	unhealthyUntil atomic.Int64
 }
 
+// NewDownstreamRoundTripper makes a RoundTripper forwarding requests to the given comma-separated list of downstream URLs.
 func NewDownstreamRoundTripper(downstreamURL string, transport http.RoundTripper) (http.RoundTripper, error) {
-	u, err := url.Parse(downstreamURL)
-	if err != nil {
-		return nil, err
+	var downstreams []*downstream
+	for _, rawURL := range strings.Split(downstreamURL, ",") {
+		rawURL = strings.TrimSpace(rawURL)
+		if rawURL == "" {
+			continue
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		downstreams = append(downstreams, &downstream{url: u})
+	}
+	if len(downstreams) == 0 {
+		return nil, errors.New("no downstream URL configured")
 	}
 
-	return &downstreamRoundTripper{downstreamURL: u, transport: transport}, nil
+	return &downstreamRoundTripper{downstreams: downstreams, transport: transport, now: time.Now}, nil
 }
 
-func (d downstreamRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+func (d *downstreamRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
 	tracer, span := opentracing.GlobalTracer(), opentracing.SpanFromContext(r.Context())
 	if tracer != nil && span != nil {
 		carrier := opentracing.HTTPHeadersCarrier(r.Header)
@@ -33,9 +61,32 @@ func (d downstreamRoundTripper) RoundTrip(r *http.Request) (*http.Response, erro
 		}
 	}
 
-	r.URL.Scheme = d.downstreamURL.Scheme
-	r.URL.Host = d.downstreamURL.Host
-	r.URL.Path = path.Join(d.downstreamURL.Path, r.URL.Path)
+	ds := d.pick()
+	r.URL.Scheme = ds.url.Scheme
+	r.URL.Host = ds.url.Host
+	r.URL.Path = path.Join(ds.url.Path, r.URL.Path)
 	r.Host = ""
-	return d.transport.RoundTrip(r)
+	resp, err := d.transport.RoundTrip(r)
+	if err != nil && !isCanceled(r, err) {
+		ds.unhealthyUntil.Store(d.now().Add(unhealthyDownstreamBackoff).UnixNano())
+	}
+	return resp, err
+}
+
+// isCanceled returns whether the request failed because it was canceled or timed out.
+func isCanceled(r *http.Request, err error) bool {
+	return r.Context().Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// pick returns the next healthy downstream, or the next one in rotation if none is healthy.
+func (d *downstreamRoundTripper) pick() *downstream {
+	start := d.next.Inc() - 1
+	now := d.now().UnixNano()
+	for i := uint64(0); i < uint64(len(d.downstreams)); i++ {
+		ds := d.downstreams[(start+i)%uint64(len(d.downstreams))]
+		if ds.unhealthyUntil.Load() <= now {
+			return ds
+		}
+	}
+	return d.downstreams[start%uint64(len(d.downstreams))]
 }
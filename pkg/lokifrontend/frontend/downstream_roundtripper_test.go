@@ -0,0 +1,123 @@
+package frontend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingRoundTripper struct {
+	hosts     []string
+	failHosts map[string]bool
+	status    int
+}
+
+func (r *recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.hosts = append(r.hosts, req.URL.Host)
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	if r.failHosts[req.URL.Host] {
+		return nil, errors.New("connection refused")
+	}
+	if r.status != 0 {
+		return &http.Response{StatusCode: r.status}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestDownstreamRoundTripper_RoundRobin(t *testing.T) {
+	next := &recordingRoundTripper{}
+	rt, err := NewDownstreamRoundTripper("http://querier-1:3100, http://querier-2:3100", next)
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		_, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "/loki/api/v1/query", nil))
+		require.NoError(t, err)
+	}
+	require.Equal(t, []string{"querier-1:3100", "querier-2:3100", "querier-1:3100", "querier-2:3100"}, next.hosts)
+}
+
+func TestDownstreamRoundTripper_SkipsUnhealthy(t *testing.T) {
+	next := &recordingRoundTripper{failHosts: map[string]bool{"querier-1:3100": true}}
+	rt, err := NewDownstreamRoundTripper("http://querier-1:3100,http://querier-2:3100", next)
+	require.NoError(t, err)
+
+	now := time.Unix(0, 0)
+	rt.(*downstreamRoundTripper).now = func() time.Time { return now }
+
+	_, err = rt.RoundTrip(httptest.NewRequest(http.MethodGet, "/loki/api/v1/query", nil))
+	require.Error(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = rt.RoundTrip(httptest.NewRequest(http.MethodGet, "/loki/api/v1/query", nil))
+		require.NoError(t, err)
+	}
+	require.Equal(t, []string{"querier-1:3100", "querier-2:3100", "querier-2:3100"}, next.hosts)
+
+	// the failing downstream is retried once the backoff expired.
+	now = now.Add(unhealthyDownstreamBackoff)
+	next.hosts = nil
+	next.failHosts = nil
+	for i := 0; i < 2; i++ {
+		_, err = rt.RoundTrip(httptest.NewRequest(http.MethodGet, "/loki/api/v1/query", nil))
+		require.NoError(t, err)
+	}
+	require.ElementsMatch(t, []string{"querier-1:3100", "querier-2:3100"}, next.hosts)
+}
+
+func TestDownstreamRoundTripper_HealthyOnCancellationAndClientErrors(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Unix(0, 0))
+	defer cancel()
+
+	for _, tc := range []struct {
+		name   string
+		ctx    context.Context
+		status int
+	}{
+		{name: "canceled", ctx: canceled},
+		{name: "deadline exceeded", ctx: expired},
+		{name: "client error", ctx: context.Background(), status: http.StatusBadRequest},
+		{name: "too many requests", ctx: context.Background(), status: http.StatusTooManyRequests},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			next := &recordingRoundTripper{status: tc.status}
+			rt, err := NewDownstreamRoundTripper("http://querier-1:3100,http://querier-2:3100", next)
+			require.NoError(t, err)
+
+			_, _ = rt.RoundTrip(httptest.NewRequest(http.MethodGet, "/loki/api/v1/query", nil).WithContext(tc.ctx))
+			for _, ds := range rt.(*downstreamRoundTripper).downstreams {
+				require.Zero(t, ds.unhealthyUntil.Load(), ds.url.Host)
+			}
+
+			// the downstream which served the request stays in rotation.
+			next.status = 0
+			for i := 0; i < 2; i++ {
+				_, err = rt.RoundTrip(httptest.NewRequest(http.MethodGet, "/loki/api/v1/query", nil))
+				require.NoError(t, err)
+			}
+			require.Equal(t, []string{"querier-1:3100", "querier-2:3100", "querier-1:3100"}, next.hosts)
+		})
+	}
+}
+
+func TestDownstreamRoundTripper_SingleURL(t *testing.T) {
+	next := &recordingRoundTripper{}
+	rt, err := NewDownstreamRoundTripper("http://prometheus:9090/prefix", next)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/query", nil)
+	_, err = rt.RoundTrip(req)
+	require.NoError(t, err)
+	require.Equal(t, "prometheus:9090", req.URL.Host)
+	require.Equal(t, "/prefix/api/v1/query", req.URL.Path)
+
+	_, err = NewDownstreamRoundTripper(" , ", next)
+	require.Error(t, err)
+}
@@ -25,6 +25,7 @@ import (
 )
 
 // LogResultCacheMetrics is the metrics wrapper used in log result cache.
+// The hit ratio is left to queries over the hit and miss counters, so that it can be computed over any time range.
 type LogResultCacheMetrics struct {
 	CacheHit  prometheus.Counter
 	CacheMiss prometheus.Counter
@@ -36,10 +37,12 @@ func NewLogResultCacheMetrics(registerer prometheus.Registerer) *LogResultCacheM
 		CacheHit: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
 			Namespace: "loki",
 			Name:      "query_frontend_log_result_cache_hit_total",
+			Help:      "Total number of log result cache lookups hitting the cache.",
 		}),
 		CacheMiss: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
 			Namespace: "loki",
 			Name:      "query_frontend_log_result_cache_miss_total",
+			Help:      "Total number of log result cache lookups missing the cache.",
 		}),
 	}
 }
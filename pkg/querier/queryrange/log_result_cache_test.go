@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/user"
@@ -450,3 +451,43 @@ func nonEmptyResponse(lokiReq *LokiRequest, i int) *LokiResponse {
 		},
 	}
 }
+
+func Test_LogResultCacheMetrics(t *testing.T) {
+	var (
+		ctx     = user.InjectOrgID(context.Background(), "foo")
+		metrics = NewLogResultCacheMetrics(nil)
+		lrc     = NewLogResultCache(
+			log.NewNopLogger(),
+			fakeLimits{
+				splits: map[string]time.Duration{"foo": time.Minute},
+			},
+			cache.NewMockCache(),
+			nil,
+			metrics,
+		)
+	)
+
+	req := &LokiRequest{
+		StartTs: time.Unix(0, time.Minute.Nanoseconds()),
+		EndTs:   time.Unix(0, 2*time.Minute.Nanoseconds()),
+	}
+
+	fake := newFakeResponse([]mockResponse{
+		{
+			RequestResponse: queryrangebase.RequestResponse{
+				Request:  req,
+				Response: emptyResponse(req),
+			},
+		},
+	})
+
+	h := lrc.Wrap(fake)
+	for i := 0; i < 3; i++ {
+		_, err := h.Do(ctx, req)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.CacheHit))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.CacheMiss))
+	fake.AssertExpectations(t)
+}
@@ -15,12 +15,12 @@ type Metrics struct {
 	*LogResultCacheMetrics
 }
 
-func NewMetrics(registerer prometheus.Registerer) *Metrics {
+func NewMetrics(cfg Config, registerer prometheus.Registerer) *Metrics {
 	return &Metrics{
 		InstrumentMiddlewareMetrics: queryrangebase.NewInstrumentMiddlewareMetrics(registerer),
 		RetryMiddlewareMetrics:      queryrangebase.NewRetryMiddlewareMetrics(registerer),
 		ShardingMetrics:             logql.NewShardingMetrics(registerer),
-		SplitByMetrics:              NewSplitByMetrics(registerer),
+		SplitByMetrics:              NewSplitByMetrics(registerer, cfg.PerTenantSplitMetrics),
 		LogResultCacheMetrics:       NewLogResultCacheMetrics(registerer),
 	}
 }
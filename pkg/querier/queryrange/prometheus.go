@@ -2,25 +2,79 @@ package queryrange
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
 
 	jsoniter "github.com/json-iterator/go"
 	"github.com/opentracing/opentracing-go"
 	otlog "github.com/opentracing/opentracing-go/log"
 	"github.com/prometheus/common/model"
+	"github.com/weaveworks/common/middleware"
 
 	"github.com/pao214/loki/pkg/loghttp"
 	"github.com/pao214/loki/pkg/logqlmodel/stats"
 	"github.com/pao214/loki/pkg/querier/queryrange/queryrangebase"
 )
 
+const gzipCtxKey ctxKeyType = "gzip"
+
+// QueryExecTimeHeader is the response header holding the execution time of the query in seconds,
+// so that clients and proxies can read the cost of a query without parsing the body.
+const QueryExecTimeHeader = "X-Query-Exec-Time"
+
 var (
 	jsonStd   = jsoniter.ConfigCompatibleWithStandardLibrary
 	extractor = queryrangebase.PrometheusResponseExtractor{}
 )
 
+// CompressResponsesHTTPMiddleware is an http middleware gzip compressing encoded Prometheus responses
+// with the given compression level for requests accepting the gzip encoding.
+func CompressResponsesHTTPMiddleware(level int) middleware.Interface {
+	return middleware.Func(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if acceptsGzip(r.Header.Get("Accept-Encoding")) {
+				r = r.WithContext(context.WithValue(r.Context(), gzipCtxKey, level))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+}
+
+// acceptsGzip returns whether the Accept-Encoding header value allows gzip, either explicitly
+// or through a wildcard, with a non-zero quality value.
+func acceptsGzip(acceptEncoding string) bool {
+	wildcard := false
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		name, params := coding, ""
+		if i := strings.Index(coding, ";"); i >= 0 {
+			name, params = coding[:i], coding[i+1:]
+		}
+		accepted := true
+		for _, param := range strings.Split(params, ";") {
+			key, value := param, ""
+			if i := strings.Index(param, "="); i >= 0 {
+				key, value = param[:i], param[i+1:]
+			}
+			if strings.EqualFold(strings.TrimSpace(key), "q") {
+				q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				accepted = err == nil && q > 0
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "gzip":
+			// an explicit gzip coding takes precedence over the wildcard
+			return accepted
+		case "*":
+			wildcard = accepted
+		}
+	}
+	return wildcard
+}
+
 // PrometheusExtractor implements Extractor interface
 type PrometheusExtractor struct{}
 
@@ -47,41 +101,73 @@ func (p *LokiPromResponse) encode(ctx context.Context) (*http.Response, error) {
 		b   []byte
 		err error
 	)
-	if p.Response.Data.ResultType == loghttp.ResultTypeVector {
+	switch p.Response.Data.ResultType {
+	case loghttp.ResultTypeVector:
 		b, err = p.marshalVector()
-	} else {
+	case loghttp.ResultTypeScalar:
+		b, err = p.marshalScalar()
+	default:
 		b, err = p.marshalMatrix()
 	}
 	if err != nil {
 		return nil, err
 	}
 
+	header := http.Header{
+		"Content-Type": []string{"application/json"},
+	}
+	header.Set(QueryExecTimeHeader, strconv.FormatFloat(p.Statistics.Summary.ExecTime, 'f', -1, 64))
+	if level, ok := ctx.Value(gzipCtxKey).(int); ok {
+		if b, err = gzipBytes(b, level); err != nil {
+			return nil, err
+		}
+		header.Set("Content-Encoding", "gzip")
+	}
+
 	if sp != nil {
 		sp.LogFields(otlog.Int("bytes", len(b)))
 	}
 
 	resp := http.Response{
-		Header: http.Header{
-			"Content-Type": []string{"application/json"},
-		},
+		Header:     header,
 		Body:       ioutil.NopCloser(bytes.NewBuffer(b)),
 		StatusCode: http.StatusOK,
 	}
 	return &resp, nil
 }
 
+func gzipBytes(b []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	gw, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := gw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 func (p *LokiPromResponse) marshalVector() ([]byte, error) {
-	vec := make(loghttp.Vector, len(p.Response.Data.Result))
-	for i, v := range p.Response.Data.Result {
+	// always allocated, so that an empty or nil result is encoded as [] rather than null.
+	vec := make(loghttp.Vector, 0, len(p.Response.Data.Result))
+	for _, v := range p.Response.Data.Result {
+		// a malformed row without samples has no value to report.
+		if len(v.Samples) == 0 {
+			continue
+		}
 		lbs := make(model.LabelSet, len(v.Labels))
 		for _, v := range v.Labels {
 			lbs[model.LabelName(v.Name)] = model.LabelValue(v.Value)
 		}
-		vec[i] = model.Sample{
+		vec = append(vec, model.Sample{
 			Metric:    model.Metric(lbs),
 			Timestamp: model.Time(v.Samples[0].TimestampMs),
 			Value:     model.SampleValue(v.Samples[0].Value),
-		}
+		})
 	}
 	return jsonStd.Marshal(struct {
 		Status string `json:"status"`
@@ -90,8 +176,9 @@ func (p *LokiPromResponse) marshalVector() ([]byte, error) {
 			Result     loghttp.Vector `json:"result"`
 			Statistics stats.Result   `json:"stats,omitempty"`
 		} `json:"data,omitempty"`
-		ErrorType string `json:"errorType,omitempty"`
-		Error     string `json:"error,omitempty"`
+		ErrorType string   `json:"errorType,omitempty"`
+		Error     string   `json:"error,omitempty"`
+		Warnings  []string `json:"warnings,omitempty"`
 	}{
 		Error: p.Response.Error,
 		Data: struct {
@@ -105,6 +192,43 @@ func (p *LokiPromResponse) marshalVector() ([]byte, error) {
 		},
 		ErrorType: p.Response.ErrorType,
 		Status:    p.Response.Status,
+		Warnings:  p.Response.Warnings,
+	})
+}
+
+func (p *LokiPromResponse) marshalScalar() ([]byte, error) {
+	var scalar loghttp.Scalar
+	if len(p.Response.Data.Result) > 0 && len(p.Response.Data.Result[0].Samples) > 0 {
+		sample := p.Response.Data.Result[0].Samples[0]
+		scalar = loghttp.Scalar{
+			Timestamp: model.Time(sample.TimestampMs),
+			Value:     model.SampleValue(sample.Value),
+		}
+	}
+	return jsonStd.Marshal(struct {
+		Status string `json:"status"`
+		Data   struct {
+			ResultType string         `json:"resultType"`
+			Result     loghttp.Scalar `json:"result"`
+			Statistics stats.Result   `json:"stats,omitempty"`
+		} `json:"data,omitempty"`
+		ErrorType string   `json:"errorType,omitempty"`
+		Error     string   `json:"error,omitempty"`
+		Warnings  []string `json:"warnings,omitempty"`
+	}{
+		Error: p.Response.Error,
+		Data: struct {
+			ResultType string         `json:"resultType"`
+			Result     loghttp.Scalar `json:"result"`
+			Statistics stats.Result   `json:"stats,omitempty"`
+		}{
+			ResultType: loghttp.ResultTypeScalar,
+			Result:     scalar,
+			Statistics: p.Statistics,
+		},
+		ErrorType: p.Response.ErrorType,
+		Status:    p.Response.Status,
+		Warnings:  p.Response.Warnings,
 	})
 }
 
@@ -116,8 +240,9 @@ func (p *LokiPromResponse) marshalMatrix() ([]byte, error) {
 			queryrangebase.PrometheusData
 			Statistics stats.Result `json:"stats,omitempty"`
 		} `json:"data,omitempty"`
-		ErrorType string `json:"errorType,omitempty"`
-		Error     string `json:"error,omitempty"`
+		ErrorType string   `json:"errorType,omitempty"`
+		Error     string   `json:"error,omitempty"`
+		Warnings  []string `json:"warnings,omitempty"`
 	}{
 		Error: p.Response.Error,
 		Data: struct {
@@ -129,5 +254,6 @@ func (p *LokiPromResponse) marshalMatrix() ([]byte, error) {
 		},
 		ErrorType: p.Response.ErrorType,
 		Status:    p.Response.Status,
+		Warnings:  p.Response.Warnings,
 	})
 }
@@ -1,14 +1,18 @@
 package queryrange
 
 import (
+	"compress/gzip"
 	"context"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/pao214/loki/pkg/loghttp"
 	"github.com/pao214/loki/pkg/logproto"
+	"github.com/pao214/loki/pkg/logqlmodel/stats"
 	"github.com/pao214/loki/pkg/querier/queryrange/queryrangebase"
 )
 
@@ -158,6 +162,32 @@ func Test_encodePromResponse(t *testing.T) {
 				}
 			}`,
 		},
+		{
+			"scalar",
+			&LokiPromResponse{
+				Response: &queryrangebase.PrometheusResponse{
+					Status: string(queryrangebase.StatusSuccess),
+					Data: queryrangebase.PrometheusData{
+						ResultType: loghttp.ResultTypeScalar,
+						Result: []queryrangebase.SampleStream{
+							{
+								Samples: []logproto.LegacySample{
+									{Value: 42, TimestampMs: 1000},
+								},
+							},
+						},
+					},
+				},
+			},
+			`{
+				"status": "success",
+				"data": {
+					"resultType": "scalar",
+					"result": [1, "42"],
+					` + emptyStats + `
+				}
+			}`,
+		},
 	} {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
@@ -170,3 +200,238 @@ func Test_encodePromResponse(t *testing.T) {
 		})
 	}
 }
+
+func Test_encodePromResponse_ScalarRoundTrip(t *testing.T) {
+	resp := &LokiPromResponse{
+		Response: &queryrangebase.PrometheusResponse{
+			Status: string(queryrangebase.StatusSuccess),
+			Data: queryrangebase.PrometheusData{
+				ResultType: loghttp.ResultTypeScalar,
+				Result: []queryrangebase.SampleStream{
+					{
+						Samples: []logproto.LegacySample{
+							{Value: 42, TimestampMs: 1000},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	r, err := resp.encode(context.Background())
+	require.NoError(t, err)
+
+	decoded, err := LokiCodec.DecodeResponse(context.Background(), r, &LokiInstantRequest{Query: "vector(42)", Path: "/loki/api/v1/query"})
+	require.NoError(t, err)
+
+	got := decoded.(*LokiPromResponse)
+	require.Equal(t, resp.Response.Data, got.Response.Data)
+	require.Equal(t, resp.Response.Status, got.Response.Status)
+}
+
+func Test_encodePromResponse_Gzip(t *testing.T) {
+	resp := &LokiPromResponse{
+		Response: &queryrangebase.PrometheusResponse{
+			Status: string(queryrangebase.StatusSuccess),
+			Data: queryrangebase.PrometheusData{
+				ResultType: loghttp.ResultTypeVector,
+				Result: []queryrangebase.SampleStream{
+					{
+						Labels: []logproto.LabelAdapter{
+							{Name: "foo", Value: "bar"},
+						},
+						Samples: []logproto.LegacySample{
+							{Value: 1, TimestampMs: 1000},
+						},
+					},
+				},
+			},
+		},
+	}
+	expected := `{
+		"status": "success",
+		"data": {
+			"resultType": "vector",
+			"result": [
+				{
+					"metric": {"foo": "bar"},
+					"value": [1, "1"]
+				}
+			],
+			` + emptyStats + `
+		}
+	}`
+
+	for _, tc := range []struct {
+		name           string
+		acceptEncoding string
+		gzipped        bool
+	}{
+		{name: "gzip accepted", acceptEncoding: "gzip, deflate", gzipped: true},
+		{name: "gzip not accepted", acceptEncoding: "", gzipped: false},
+		{name: "gzip with quality", acceptEncoding: "deflate, gzip;q=0.5", gzipped: true},
+		{name: "gzip refused", acceptEncoding: "gzip;q=0, deflate", gzipped: false},
+		{name: "gzip refused with spaces", acceptEncoding: "gzip ; q=0.000", gzipped: false},
+		{name: "wildcard", acceptEncoding: "*", gzipped: true},
+		{name: "wildcard with gzip refused", acceptEncoding: "gzip;q=0, *;q=1", gzipped: false},
+		{name: "wildcard refused", acceptEncoding: "deflate, *;q=0", gzipped: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/loki/api/v1/query", nil)
+			req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+
+			var ctx context.Context
+			CompressResponsesHTTPMiddleware(gzip.BestSpeed).Wrap(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				ctx = r.Context()
+			})).ServeHTTP(httptest.NewRecorder(), req)
+
+			r, err := resp.encode(ctx)
+			require.NoError(t, err)
+
+			body := r.Body
+			if tc.gzipped {
+				require.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+				body, err = gzip.NewReader(r.Body)
+				require.NoError(t, err)
+			} else {
+				require.Empty(t, r.Header.Get("Content-Encoding"))
+			}
+			b, err := io.ReadAll(body)
+			require.NoError(t, err)
+			require.JSONEq(t, expected, string(b))
+		})
+	}
+}
+
+func Test_encodePromResponse_Warnings(t *testing.T) {
+	for _, resultType := range []string{loghttp.ResultTypeVector, loghttp.ResultTypeMatrix} {
+		resultType := resultType
+		t.Run(resultType, func(t *testing.T) {
+			resp := &LokiPromResponse{
+				Response: &queryrangebase.PrometheusResponse{
+					Status: string(queryrangebase.StatusSuccess),
+					Data: queryrangebase.PrometheusData{
+						ResultType: resultType,
+						Result: []queryrangebase.SampleStream{
+							{
+								Labels: []logproto.LabelAdapter{
+									{Name: "foo", Value: "bar"},
+								},
+								Samples: []logproto.LegacySample{
+									{Value: 1, TimestampMs: 1000},
+								},
+							},
+						},
+					},
+					Warnings: []string{"partial result", "shard timed out"},
+				},
+			}
+
+			r, err := resp.encode(context.Background())
+			require.NoError(t, err)
+
+			decoded, err := LokiCodec.DecodeResponse(context.Background(), r, &LokiRequest{Query: `sum(rate({foo="bar"}[1m]))`, Path: "/loki/api/v1/query_range"})
+			require.NoError(t, err)
+			require.Equal(t, resp.Response.Warnings, decoded.(*LokiPromResponse).Response.Warnings)
+		})
+	}
+}
+
+func Test_encodePromResponse_ExecTimeHeader(t *testing.T) {
+	resp := &LokiPromResponse{
+		Response: &queryrangebase.PrometheusResponse{
+			Status: string(queryrangebase.StatusSuccess),
+			Data: queryrangebase.PrometheusData{
+				ResultType: loghttp.ResultTypeVector,
+			},
+		},
+		Statistics: stats.Result{
+			Summary: stats.Summary{ExecTime: 1.25},
+		},
+	}
+
+	r, err := resp.encode(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "1.25", r.Header.Get(QueryExecTimeHeader))
+	require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+}
+
+func Test_encodePromResponse_EmptyVector(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		result []queryrangebase.SampleStream
+	}{
+		{name: "nil result", result: nil},
+		{name: "empty result", result: []queryrangebase.SampleStream{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &LokiPromResponse{
+				Response: &queryrangebase.PrometheusResponse{
+					Status: string(queryrangebase.StatusSuccess),
+					Data: queryrangebase.PrometheusData{
+						ResultType: loghttp.ResultTypeVector,
+						Result:     tc.result,
+					},
+				},
+			}
+
+			r, err := resp.encode(context.Background())
+			require.NoError(t, err)
+			b, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.Contains(t, string(b), `"result":[]`)
+			require.JSONEq(t, `{
+				"status": "success",
+				"data": {
+					"resultType": "vector",
+					"result": [],
+					`+emptyStats+`
+				}
+			}`, string(b))
+		})
+	}
+}
+
+func Test_encodePromResponse_VectorWithoutSamples(t *testing.T) {
+	resp := &LokiPromResponse{
+		Response: &queryrangebase.PrometheusResponse{
+			Status: string(queryrangebase.StatusSuccess),
+			Data: queryrangebase.PrometheusData{
+				ResultType: loghttp.ResultTypeVector,
+				Result: []queryrangebase.SampleStream{
+					{
+						Labels: []logproto.LabelAdapter{
+							{Name: "foo", Value: "empty"},
+						},
+					},
+					{
+						Labels: []logproto.LabelAdapter{
+							{Name: "foo", Value: "bar"},
+						},
+						Samples: []logproto.LegacySample{
+							{Value: 1, TimestampMs: 1000},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	r, err := resp.encode(context.Background())
+	require.NoError(t, err)
+	b, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"status": "success",
+		"data": {
+			"resultType": "vector",
+			"result": [
+				{
+					"metric": {"foo": "bar"},
+					"value": [1, "1"]
+				}
+			],
+			`+emptyStats+`
+		}
+	}`, string(b))
+}
@@ -239,6 +239,7 @@ type PrometheusResponse struct {
 	ErrorType string                      `protobuf:"bytes,3,opt,name=ErrorType,proto3" json:"errorType,omitempty"`
 	Error     string                      `protobuf:"bytes,4,opt,name=Error,proto3" json:"error,omitempty"`
 	Headers   []*PrometheusResponseHeader `protobuf:"bytes,5,rep,name=Headers,proto3" json:"-"`
+	Warnings  []string                    `protobuf:"bytes,6,rep,name=Warnings,proto3" json:"warnings,omitempty"`
 }
 
 func (m *PrometheusResponse) Reset()      { *m = PrometheusResponse{} }
@@ -308,6 +309,13 @@ func (m *PrometheusResponse) GetHeaders() []*PrometheusResponseHeader {
 	return nil
 }
 
+func (m *PrometheusResponse) GetWarnings() []string {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
+
 type PrometheusData struct {
 	ResultType string         `protobuf:"bytes,1,opt,name=ResultType,proto3" json:"resultType"`
 	Result     []SampleStream `protobuf:"bytes,2,rep,name=Result,proto3" json:"result"`
@@ -582,62 +590,63 @@ func init() {
 }
 
 var fileDescriptor_4cc6a0c1d6b614c4 = []byte{
-	// 869 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0x3f, 0x6f, 0xdb, 0x46,
-	0x14, 0x17, 0x4d, 0x89, 0x92, 0xce, 0x81, 0x92, 0x5e, 0x82, 0x84, 0x72, 0x5b, 0x52, 0xe0, 0x52,
-	0x15, 0x48, 0x28, 0xd4, 0x45, 0xbb, 0xa5, 0xa8, 0x19, 0x07, 0x48, 0x02, 0xa3, 0x0d, 0xce, 0x41,
-	0x87, 0x2e, 0xc5, 0x49, 0x7c, 0xa1, 0x09, 0xf3, 0x5f, 0xee, 0x8e, 0x41, 0xb5, 0x75, 0xea, 0xdc,
-	0xb1, 0x1f, 0xa1, 0x43, 0x97, 0x7e, 0x0b, 0x8f, 0x1e, 0x83, 0x0e, 0x6c, 0x2d, 0x2f, 0x05, 0xa7,
-	0x7c, 0x84, 0x82, 0x77, 0xa4, 0x44, 0xcb, 0x6d, 0x3a, 0x74, 0xb1, 0xdf, 0x9f, 0xdf, 0xfb, 0x73,
-	0xbf, 0xf7, 0xf8, 0x84, 0x3e, 0xcf, 0x4e, 0x83, 0xd9, 0xab, 0x1c, 0x58, 0x08, 0x4c, 0xfe, 0x5f,
-	0x32, 0x9a, 0x04, 0xd0, 0x12, 0xe7, 0x94, 0xb7, 0x55, 0x37, 0x63, 0xa9, 0x48, 0xf1, 0xe8, 0x2a,
-	0x60, 0xef, 0x41, 0x10, 0x8a, 0x93, 0x7c, 0xee, 0x2e, 0xd2, 0x78, 0x16, 0xa4, 0x41, 0x3a, 0x93,
-	0xb0, 0x79, 0xfe, 0x52, 0x6a, 0x52, 0x91, 0x92, 0x0a, 0xdf, 0xb3, 0x82, 0x34, 0x0d, 0x22, 0xd8,
-	0xa0, 0xfc, 0x9c, 0x51, 0x11, 0xa6, 0x49, 0xed, 0x1f, 0x6f, 0xfb, 0x69, 0xb2, 0xac, 0x5d, 0xef,
-	0x57, 0x1d, 0x47, 0x69, 0xa0, 0x72, 0x36, 0x82, 0x72, 0x3a, 0xc7, 0xe8, 0xde, 0x73, 0x96, 0xc6,
-	0x20, 0x4e, 0x20, 0xe7, 0x04, 0x5e, 0xe5, 0xc0, 0xc5, 0x13, 0xa0, 0x3e, 0x30, 0x3c, 0x46, 0xdd,
-	0xaf, 0x68, 0x0c, 0xa6, 0x36, 0xd1, 0xa6, 0x43, 0xaf, 0x57, 0x16, 0xb6, 0xf6, 0x80, 0x48, 0x13,
-	0xfe, 0x10, 0x19, 0xdf, 0xd0, 0x28, 0x07, 0x6e, 0xee, 0x4c, 0xf4, 0x8d, 0xb3, 0x36, 0x3a, 0xe7,
-	0x3b, 0xe8, 0xbd, 0x6b, 0x59, 0x31, 0x46, 0xdd, 0x8c, 0x8a, 0x13, 0x95, 0x8f, 0x48, 0x19, 0xdf,
-	0x41, 0x3d, 0x2e, 0x28, 0x13, 0xe6, 0xce, 0x44, 0x9b, 0xea, 0x44, 0x29, 0xf8, 0x16, 0xd2, 0x21,
-	0xf1, 0x4d, 0x5d, 0xda, 0x2a, 0xb1, 0x8a, 0xe5, 0x02, 0x32, 0xb3, 0x2b, 0x4d, 0x52, 0xc6, 0x0f,
-	0x51, 0x5f, 0x84, 0x31, 0xa4, 0xb9, 0x30, 0x7b, 0x13, 0x6d, 0xba, 0xbb, 0x3f, 0x76, 0x15, 0x09,
-	0x6e, 0x43, 0x82, 0x7b, 0x58, 0x93, 0xe4, 0x0d, 0xce, 0x0a, 0xbb, 0xf3, 0xf3, 0x1f, 0xb6, 0x46,
-	0x9a, 0x98, 0xaa, 0xb4, 0x1c, 0x89, 0x69, 0xc8, 0x7e, 0x94, 0x82, 0x8f, 0xd0, 0x68, 0x41, 0x17,
-	0x27, 0x61, 0x12, 0x7c, 0x9d, 0x55, 0x91, 0xdc, 0xec, 0xcb, 0xdc, 0x96, 0x7b, 0x75, 0x7e, 0xee,
-	0xa3, 0x2b, 0x28, 0xaf, 0x5b, 0x15, 0x20, 0x5b, 0xb1, 0xf8, 0x09, 0xea, 0x2b, 0x32, 0xb9, 0x39,
-	0x98, 0xe8, 0xd3, 0xdd, 0xfd, 0x8f, 0xb6, 0xd3, 0xfc, 0x0b, 0xf9, 0x0d, 0xa3, 0x4d, 0xb8, 0xf3,
-	0x02, 0x99, 0x6d, 0x28, 0xcf, 0xd2, 0x84, 0xc3, 0xff, 0x1e, 0xd4, 0x6f, 0x3b, 0x08, 0x5f, 0x4f,
-	0x8b, 0x1d, 0x64, 0x1c, 0x0b, 0x2a, 0x72, 0x5e, 0xa7, 0x44, 0x65, 0x61, 0x1b, 0x5c, 0x5a, 0x48,
-	0xed, 0xc1, 0xcf, 0x50, 0xf7, 0x90, 0x0a, 0x2a, 0x07, 0xf7, 0x0f, 0xf4, 0x6c, 0xb2, 0x56, 0x28,
-	0xef, 0x6e, 0x45, 0x4f, 0x59, 0xd8, 0x23, 0x9f, 0x0a, 0x7a, 0x3f, 0x8d, 0x43, 0x01, 0x71, 0x26,
-	0x96, 0x44, 0xe6, 0xc0, 0x9f, 0xa1, 0xe1, 0x63, 0xc6, 0x52, 0xf6, 0x62, 0x99, 0x81, 0x9c, 0xfa,
-	0xd0, 0xbb, 0x57, 0x16, 0xf6, 0x6d, 0x68, 0x8c, 0xad, 0x88, 0x0d, 0x12, 0x7f, 0x8c, 0x7a, 0x52,
-	0x91, 0x5b, 0x31, 0xf4, 0x6e, 0x97, 0x85, 0x7d, 0x53, 0x86, 0xb4, 0xe0, 0x0a, 0x81, 0x9f, 0x6e,
-	0x06, 0xd1, 0x93, 0x83, 0x98, 0xbe, 0x6b, 0x10, 0x6d, 0x76, 0xaf, 0x4d, 0xe2, 0x47, 0x0d, 0x8d,
-	0xae, 0xbe, 0x0e, 0xbb, 0x08, 0x11, 0xe0, 0x79, 0x24, 0xe4, 0x03, 0x14, 0x67, 0xa3, 0xb2, 0xb0,
-	0x11, 0x5b, 0x5b, 0x49, 0x0b, 0x81, 0x0f, 0x91, 0xa1, 0x34, 0x39, 0x95, 0xdd, 0xfd, 0x0f, 0xb6,
-	0x9b, 0x39, 0xa6, 0x71, 0x16, 0xc1, 0xb1, 0x60, 0x40, 0x63, 0x6f, 0x54, 0x73, 0x67, 0xa8, 0x6c,
-	0xa4, 0x8e, 0x75, 0xce, 0x34, 0x74, 0xa3, 0x0d, 0xc4, 0xaf, 0x91, 0x11, 0xd1, 0x39, 0x44, 0xd5,
-	0xd8, 0x74, 0xf9, 0x3d, 0xac, 0x3f, 0xf6, 0x23, 0x08, 0xe8, 0x62, 0x79, 0x54, 0x79, 0x9f, 0xd3,
-	0x90, 0x79, 0x8f, 0xaa, 0x9c, 0xbf, 0x17, 0xf6, 0x27, 0xed, 0x2b, 0xc4, 0xe8, 0x4b, 0x9a, 0xd0,
-	0x59, 0x94, 0x9e, 0x86, 0xb3, 0xf6, 0xcd, 0x70, 0x65, 0xdc, 0x81, 0x4f, 0x33, 0x01, 0xac, 0x6a,
-	0x24, 0x06, 0xc1, 0xc2, 0x05, 0xa9, 0xab, 0xe1, 0x2f, 0x51, 0x9f, 0xcb, 0x3e, 0x78, 0xfd, 0x9e,
-	0xbb, 0xdb, 0x85, 0x55, 0x9b, 0x9b, 0x97, 0xbc, 0x96, 0xeb, 0x47, 0x9a, 0x30, 0x27, 0x41, 0xa3,
-	0xea, 0x7b, 0x02, 0x7f, 0xbd, 0x82, 0x63, 0xa4, 0x9f, 0xc2, 0xb2, 0xe6, 0xb2, 0x5f, 0x16, 0x76,
-	0xa5, 0x92, 0xea, 0x0f, 0x3e, 0x40, 0x7d, 0xf8, 0x5e, 0x40, 0x22, 0x36, 0xe5, 0xb6, 0xe8, 0x7b,
-	0x2c, 0xdd, 0xde, 0xcd, 0xba, 0x5c, 0x03, 0x27, 0x8d, 0xe0, 0xfc, 0xaa, 0x21, 0x43, 0x81, 0xb0,
-	0xdd, 0x5c, 0xa0, 0xaa, 0x94, 0xee, 0x0d, 0xcb, 0xc2, 0x56, 0x86, 0xe6, 0x18, 0x8d, 0xd5, 0x31,
-	0x92, 0x07, 0x4a, 0x75, 0x02, 0x89, 0xaf, 0xae, 0xd2, 0x04, 0x0d, 0x04, 0xa3, 0x0b, 0xf8, 0x2e,
-	0xf4, 0xeb, 0x1d, 0x6c, 0x96, 0x45, 0x9a, 0x9f, 0xfa, 0xf8, 0x0b, 0x34, 0x60, 0xf5, 0x93, 0xea,
-	0x23, 0x75, 0xe7, 0xda, 0x91, 0x3a, 0x48, 0x96, 0xde, 0x8d, 0xb2, 0xb0, 0xd7, 0x48, 0xb2, 0x96,
-	0x9e, 0x75, 0x07, 0xfa, 0xad, 0xae, 0x73, 0x5f, 0xd1, 0xd3, 0x3a, 0x2c, 0x7b, 0x68, 0xe0, 0x87,
-	0x9c, 0xce, 0x23, 0xf0, 0x65, 0xe3, 0x03, 0xb2, 0xd6, 0x3d, 0x7e, 0x7e, 0x61, 0x75, 0xde, 0x5c,
-	0x58, 0x9d, 0xb7, 0x17, 0x96, 0xf6, 0xc3, 0xca, 0xd2, 0x7e, 0x59, 0x59, 0xda, 0xd9, 0xca, 0xd2,
-	0xce, 0x57, 0x96, 0xf6, 0xe7, 0xca, 0xd2, 0xfe, 0x5a, 0x59, 0x9d, 0xb7, 0x2b, 0x4b, 0xfb, 0xe9,
-	0xd2, 0xea, 0x9c, 0x5f, 0x5a, 0x9d, 0x37, 0x97, 0x56, 0xe7, 0xdb, 0x87, 0xef, 0xda, 0x84, 0xff,
-	0xfc, 0xbd, 0x9b, 0x1b, 0xf2, 0x39, 0x9f, 0xfe, 0x1d, 0x00, 0x00, 0xff, 0xff, 0xb3, 0x10, 0x2b,
-	0x2c, 0x1f, 0x07, 0x00, 0x00,
+	// 889 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0xcd, 0x6f, 0xdc, 0x44,
+	0x14, 0x5f, 0xef, 0x87, 0x77, 0xf7, 0xa5, 0xda, 0x96, 0x69, 0x95, 0x7a, 0x03, 0xd8, 0x2b, 0x5f,
+	0x58, 0xa4, 0xd6, 0x2b, 0x82, 0xe0, 0x56, 0x44, 0xdc, 0x54, 0x6a, 0xab, 0x08, 0xaa, 0x49, 0x05,
+	0x12, 0x17, 0x34, 0xbb, 0x9e, 0x3a, 0x56, 0xfc, 0xd5, 0x99, 0x71, 0x61, 0x6f, 0x9c, 0x38, 0x73,
+	0xe4, 0x4f, 0xe0, 0xc0, 0x1f, 0x92, 0x63, 0x8e, 0x15, 0x07, 0x43, 0x36, 0x42, 0x42, 0x3e, 0xf5,
+	0x4f, 0x40, 0x9e, 0xb1, 0x77, 0x9d, 0x0d, 0x94, 0x03, 0x97, 0xe4, 0x7d, 0xfc, 0xde, 0xc7, 0xfc,
+	0xde, 0xf3, 0x5b, 0xf8, 0x34, 0x3d, 0xf5, 0x67, 0x2f, 0x33, 0xca, 0x02, 0xca, 0xe4, 0xff, 0x25,
+	0x23, 0xb1, 0x4f, 0x1b, 0xe2, 0x9c, 0xf0, 0xa6, 0xea, 0xa4, 0x2c, 0x11, 0x09, 0x1a, 0x5d, 0x05,
+	0xec, 0xdd, 0xf7, 0x03, 0x71, 0x92, 0xcd, 0x9d, 0x45, 0x12, 0xcd, 0xfc, 0xc4, 0x4f, 0x66, 0x12,
+	0x36, 0xcf, 0x5e, 0x48, 0x4d, 0x2a, 0x52, 0x52, 0xe1, 0x7b, 0xa6, 0x9f, 0x24, 0x7e, 0x48, 0x37,
+	0x28, 0x2f, 0x63, 0x44, 0x04, 0x49, 0x5c, 0xf9, 0xc7, 0xdb, 0x7e, 0x12, 0x2f, 0x2b, 0xd7, 0xbb,
+	0x65, 0xc7, 0x61, 0xe2, 0xab, 0x9c, 0xb5, 0xa0, 0x9c, 0xf6, 0x31, 0xdc, 0x7d, 0xc6, 0x92, 0x88,
+	0x8a, 0x13, 0x9a, 0x71, 0x4c, 0x5f, 0x66, 0x94, 0x8b, 0xc7, 0x94, 0x78, 0x94, 0xa1, 0x31, 0x74,
+	0xbf, 0x20, 0x11, 0x35, 0xb4, 0x89, 0x36, 0x1d, 0xba, 0xbd, 0x22, 0xb7, 0xb4, 0xfb, 0x58, 0x9a,
+	0xd0, 0xfb, 0xa0, 0x7f, 0x45, 0xc2, 0x8c, 0x72, 0xa3, 0x3d, 0xe9, 0x6c, 0x9c, 0x95, 0xd1, 0x3e,
+	0x6f, 0xc3, 0x3b, 0xd7, 0xb2, 0x22, 0x04, 0xdd, 0x94, 0x88, 0x13, 0x95, 0x0f, 0x4b, 0x19, 0xdd,
+	0x81, 0x1e, 0x17, 0x84, 0x09, 0xa3, 0x3d, 0xd1, 0xa6, 0x1d, 0xac, 0x14, 0x74, 0x0b, 0x3a, 0x34,
+	0xf6, 0x8c, 0x8e, 0xb4, 0x95, 0x62, 0x19, 0xcb, 0x05, 0x4d, 0x8d, 0xae, 0x34, 0x49, 0x19, 0x3d,
+	0x80, 0xbe, 0x08, 0x22, 0x9a, 0x64, 0xc2, 0xe8, 0x4d, 0xb4, 0xe9, 0xce, 0xfe, 0xd8, 0x51, 0x24,
+	0x38, 0x35, 0x09, 0xce, 0x61, 0x45, 0x92, 0x3b, 0x38, 0xcb, 0xad, 0xd6, 0xcf, 0xbf, 0x5b, 0x1a,
+	0xae, 0x63, 0xca, 0xd2, 0x72, 0x24, 0x86, 0x2e, 0xfb, 0x51, 0x0a, 0x3a, 0x82, 0xd1, 0x82, 0x2c,
+	0x4e, 0x82, 0xd8, 0xff, 0x32, 0x2d, 0x23, 0xb9, 0xd1, 0x97, 0xb9, 0x4d, 0xe7, 0xea, 0xfc, 0x9c,
+	0x87, 0x57, 0x50, 0x6e, 0xb7, 0x2c, 0x80, 0xb7, 0x62, 0xd1, 0x63, 0xe8, 0x2b, 0x32, 0xb9, 0x31,
+	0x98, 0x74, 0xa6, 0x3b, 0xfb, 0x1f, 0x6c, 0xa7, 0xf9, 0x17, 0xf2, 0x6b, 0x46, 0xeb, 0x70, 0xfb,
+	0x39, 0x18, 0x4d, 0x28, 0x4f, 0x93, 0x98, 0xd3, 0xff, 0x3d, 0xa8, 0x3f, 0xdb, 0x80, 0xae, 0xa7,
+	0x45, 0x36, 0xe8, 0xc7, 0x82, 0x88, 0x8c, 0x57, 0x29, 0xa1, 0xc8, 0x2d, 0x9d, 0x4b, 0x0b, 0xae,
+	0x3c, 0xe8, 0x29, 0x74, 0x0f, 0x89, 0x20, 0x46, 0xfb, 0x9f, 0xe9, 0xd9, 0x64, 0x2d, 0x51, 0xee,
+	0x6e, 0x49, 0x4f, 0x91, 0x5b, 0x23, 0x8f, 0x08, 0x72, 0x2f, 0x89, 0x02, 0x41, 0xa3, 0x54, 0x2c,
+	0xb1, 0xcc, 0x81, 0x3e, 0x81, 0xe1, 0x23, 0xc6, 0x12, 0xf6, 0x7c, 0x99, 0x52, 0x39, 0xf5, 0xa1,
+	0x7b, 0xb7, 0xc8, 0xad, 0xdb, 0xb4, 0x36, 0x36, 0x22, 0x36, 0x48, 0xf4, 0x21, 0xf4, 0xa4, 0x22,
+	0xb7, 0x62, 0xe8, 0xde, 0x2e, 0x72, 0xeb, 0xa6, 0x0c, 0x69, 0xc0, 0x15, 0x02, 0x3d, 0xd9, 0x0c,
+	0xa2, 0x27, 0x07, 0x31, 0x7d, 0xdb, 0x20, 0x9a, 0xec, 0x6e, 0x4f, 0x02, 0xed, 0xc3, 0xe0, 0x6b,
+	0xc2, 0xe2, 0x20, 0xf6, 0xb9, 0xa1, 0x4b, 0x52, 0x77, 0x8b, 0xdc, 0x42, 0xdf, 0x55, 0xb6, 0x46,
+	0xed, 0x35, 0xce, 0xfe, 0x51, 0x83, 0xd1, 0x55, 0x46, 0x90, 0x03, 0x80, 0x29, 0xcf, 0x42, 0x21,
+	0x1f, 0xad, 0x78, 0x1e, 0x15, 0xb9, 0x05, 0x6c, 0x6d, 0xc5, 0x0d, 0x04, 0x3a, 0x04, 0x5d, 0x69,
+	0x72, 0x92, 0x3b, 0xfb, 0xef, 0x6d, 0x3f, 0xe0, 0x98, 0x44, 0x69, 0x48, 0x8f, 0x05, 0xa3, 0x24,
+	0x72, 0x47, 0x15, 0xdf, 0xba, 0xca, 0x86, 0xab, 0x58, 0xfb, 0x4c, 0x83, 0x1b, 0x4d, 0x20, 0x7a,
+	0x05, 0x7a, 0x48, 0xe6, 0x34, 0x2c, 0x47, 0xdd, 0x91, 0xdf, 0xd0, 0xfa, 0x40, 0x1c, 0x51, 0x9f,
+	0x2c, 0x96, 0x47, 0xa5, 0xf7, 0x19, 0x09, 0x98, 0xfb, 0xb0, 0xcc, 0xf9, 0x5b, 0x6e, 0x7d, 0xd4,
+	0xbc, 0x5c, 0x8c, 0xbc, 0x20, 0x31, 0x99, 0x85, 0xc9, 0x69, 0x30, 0x6b, 0xde, 0x19, 0x47, 0xc6,
+	0x1d, 0x78, 0x24, 0x15, 0x94, 0x95, 0x8d, 0x44, 0x54, 0xb0, 0x60, 0x81, 0xab, 0x6a, 0xe8, 0x73,
+	0xe8, 0x73, 0xd9, 0x07, 0xaf, 0xde, 0xb3, 0xbb, 0x5d, 0x58, 0xb5, 0xb9, 0x79, 0xc9, 0x2b, 0xb9,
+	0xb2, 0xb8, 0x0e, 0xb3, 0x63, 0x18, 0x95, 0xdf, 0x20, 0xf5, 0xd6, 0x6b, 0x3b, 0x86, 0xce, 0x29,
+	0x5d, 0x56, 0x5c, 0xf6, 0x8b, 0xdc, 0x2a, 0x55, 0x5c, 0xfe, 0x41, 0x07, 0xd0, 0xa7, 0xdf, 0x0b,
+	0x1a, 0x8b, 0x4d, 0xb9, 0x2d, 0xfa, 0x1e, 0x49, 0xb7, 0x7b, 0xb3, 0x2a, 0x57, 0xc3, 0x71, 0x2d,
+	0xd8, 0xbf, 0x6a, 0xa0, 0x2b, 0x10, 0xb2, 0xea, 0xab, 0x55, 0x96, 0xea, 0xb8, 0xc3, 0x22, 0xb7,
+	0x94, 0xa1, 0x3e, 0x60, 0x63, 0x75, 0xc0, 0xe4, 0x51, 0x53, 0x9d, 0xd0, 0xd8, 0x53, 0x97, 0x6c,
+	0x02, 0x03, 0xc1, 0xc8, 0x82, 0x7e, 0x1b, 0x78, 0xd5, 0xde, 0xd6, 0x0b, 0x26, 0xcd, 0x4f, 0x3c,
+	0xf4, 0x19, 0x0c, 0x58, 0xf5, 0xa4, 0xea, 0xb0, 0xdd, 0xb9, 0x76, 0xd8, 0x0e, 0xe2, 0xa5, 0x7b,
+	0xa3, 0xc8, 0xad, 0x35, 0x12, 0xaf, 0xa5, 0xa7, 0xdd, 0x41, 0xe7, 0x56, 0xd7, 0xbe, 0xa7, 0xe8,
+	0x69, 0x1c, 0xa3, 0x3d, 0x18, 0x78, 0x01, 0x27, 0xf3, 0x90, 0x7a, 0xb2, 0xf1, 0x01, 0x5e, 0xeb,
+	0x2e, 0x3f, 0xbf, 0x30, 0x5b, 0xaf, 0x2f, 0xcc, 0xd6, 0x9b, 0x0b, 0x53, 0xfb, 0x61, 0x65, 0x6a,
+	0xbf, 0xac, 0x4c, 0xed, 0x6c, 0x65, 0x6a, 0xe7, 0x2b, 0x53, 0xfb, 0x63, 0x65, 0x6a, 0x7f, 0xad,
+	0xcc, 0xd6, 0x9b, 0x95, 0xa9, 0xfd, 0x74, 0x69, 0xb6, 0xce, 0x2f, 0xcd, 0xd6, 0xeb, 0x4b, 0xb3,
+	0xf5, 0xcd, 0x83, 0xb7, 0x6d, 0xc2, 0x7f, 0xfe, 0x46, 0xce, 0x75, 0xf9, 0x9c, 0x8f, 0xff, 0x1e,
+	0x00, 0xaa, 0xf4, 0x8c, 0x41, 0x53, 0x07, 0x00, 0x00,
 }
 
 func (this *PrometheusRequestHeader) Equal(that interface{}) bool {
@@ -793,6 +802,14 @@ func (this *PrometheusResponse) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if len(this.Warnings) != len(that1.Warnings) {
+		return false
+	}
+	for i := range this.Warnings {
+		if this.Warnings[i] != that1.Warnings[i] {
+			return false
+		}
+	}
 	return true
 }
 func (this *PrometheusData) Equal(that interface{}) bool {
@@ -998,7 +1015,7 @@ func (this *PrometheusResponse) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 10)
 	s = append(s, "&queryrangebase.PrometheusResponse{")
 	s = append(s, "Status: "+fmt.Sprintf("%#v", this.Status)+",\n")
 	s = append(s, "Data: "+strings.Replace(this.Data.GoString(), `&`, ``, 1)+",\n")
@@ -1007,6 +1024,7 @@ func (this *PrometheusResponse) GoString() string {
 	if this.Headers != nil {
 		s = append(s, "Headers: "+fmt.Sprintf("%#v", this.Headers)+",\n")
 	}
+	s = append(s, "Warnings: "+fmt.Sprintf("%#v", this.Warnings)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1276,6 +1294,15 @@ func (m *PrometheusResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Warnings) > 0 {
+		for iNdEx := len(m.Warnings) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Warnings[iNdEx])
+			copy(dAtA[i:], m.Warnings[iNdEx])
+			i = encodeVarintQueryrange(dAtA, i, uint64(len(m.Warnings[iNdEx])))
+			i--
+			dAtA[i] = 0x32
+		}
+	}
 	if len(m.Headers) > 0 {
 		for iNdEx := len(m.Headers) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -1659,6 +1686,12 @@ func (m *PrometheusResponse) Size() (n int) {
 			n += 1 + l + sovQueryrange(uint64(l))
 		}
 	}
+	if len(m.Warnings) > 0 {
+		for _, s := range m.Warnings {
+			l = len(s)
+			n += 1 + l + sovQueryrange(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -1821,6 +1854,7 @@ func (this *PrometheusResponse) String() string {
 		`ErrorType:` + fmt.Sprintf("%v", this.ErrorType) + `,`,
 		`Error:` + fmt.Sprintf("%v", this.Error) + `,`,
 		`Headers:` + repeatedStringForHeaders + `,`,
+		`Warnings:` + fmt.Sprintf("%v", this.Warnings) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -2604,6 +2638,38 @@ func (m *PrometheusResponse) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Warnings", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQueryrange
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthQueryrange
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthQueryrange
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Warnings = append(m.Warnings, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipQueryrange(dAtA[iNdEx:])
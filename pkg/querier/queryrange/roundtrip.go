@@ -23,11 +23,14 @@ import (
 // Config is the configuration for the queryrange tripperware
 type Config struct {
 	queryrangebase.Config `yaml:",inline"`
+
+	PerTenantSplitMetrics bool `yaml:"per_tenant_split_metrics"`
 }
 
 // RegisterFlags adds the flags required to configure this flag set.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.Config.RegisterFlags(f)
+	f.BoolVar(&cfg.PerTenantSplitMetrics, "querier.split-metrics-per-tenant", false, "Add a tenant label to the query splitting metrics. This increases the cardinality of those metrics by the number of tenants.")
 }
 
 // Stopper gracefully shutdown resources created
@@ -43,7 +46,7 @@ func NewTripperware(
 	schema chunk.SchemaConfig,
 	registerer prometheus.Registerer,
 ) (queryrangebase.Tripperware, Stopper, error) {
-	metrics := NewMetrics(registerer)
+	metrics := NewMetrics(cfg, registerer)
 
 	var (
 		c   cache.Cache
@@ -30,18 +30,34 @@ type packedResp struct {
 }
 
 type SplitByMetrics struct {
-	splits prometheus.Histogram
+	splits    *prometheus.HistogramVec
+	perTenant bool
 }
 
-func NewSplitByMetrics(r prometheus.Registerer) *SplitByMetrics {
+// NewSplitByMetrics creates the query splitting metrics. When perTenant is set,
+// they carry a tenant label.
+func NewSplitByMetrics(r prometheus.Registerer, perTenant bool) *SplitByMetrics {
+	var labels []string
+	if perTenant {
+		labels = []string{"tenant"}
+	}
 	return &SplitByMetrics{
-		splits: promauto.With(r).NewHistogram(prometheus.HistogramOpts{
+		splits: promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
 			Namespace: "loki",
 			Name:      "query_frontend_partitions",
 			Help:      "Number of time-based partitions (sub-requests) per request",
 			Buckets:   prometheus.ExponentialBuckets(1, 4, 5), // 1 -> 1024
-		}),
+		}, labels),
+		perTenant: perTenant,
+	}
+}
+
+func (m *SplitByMetrics) observeSplits(tenantIDs []string, splits int) {
+	if !m.perTenant {
+		m.splits.WithLabelValues().Observe(float64(splits))
+		return
 	}
+	m.splits.WithLabelValues(tenant.JoinTenantIDs(tenantIDs)).Observe(float64(splits))
 }
 
 type splitByInterval struct {
@@ -177,7 +193,7 @@ func (h *splitByInterval) Do(ctx context.Context, r queryrangebase.Request) (que
 	if err != nil {
 		return nil, err
 	}
-	h.metrics.splits.Observe(float64(len(intervals)))
+	h.metrics.observeSplits(tenantIDs, len(intervals))
 
 	// no interval should not be processed by the frontend.
 	if len(intervals) == 0 {
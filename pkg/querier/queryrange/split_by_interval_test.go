@@ -9,6 +9,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/user"
 
@@ -19,7 +21,7 @@ import (
 	"github.com/pao214/loki/pkg/logproto"
 )
 
-var nilMetrics = NewSplitByMetrics(nil)
+var nilMetrics = NewSplitByMetrics(nil, false)
 
 func Test_splitQuery(t *testing.T) {
 	buildLokiRequest := func(start, end time.Time) queryrangebase.Request {
@@ -968,3 +970,44 @@ func Test_DoesntDeadlock(t *testing.T) {
 	// Allow for 1% increase in goroutines
 	require.LessOrEqual(t, endingGoroutines, startingGoroutines*101/100)
 }
+
+func Test_splitByInterval_PerTenantMetrics(t *testing.T) {
+	next := queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		return &LokiResponse{
+			Status:    loghttp.QueryStatusSuccess,
+			Direction: r.(*LokiRequest).Direction,
+			Limit:     r.(*LokiRequest).Limit,
+			Version:   uint32(loghttp.VersionV1),
+			Data: LokiData{
+				ResultType: loghttp.ResultTypeStream,
+			},
+		}, nil
+	})
+
+	metrics := NewSplitByMetrics(nil, true)
+	split := SplitByIntervalMiddleware(
+		WithSplitByLimits(fakeLimits{maxQueryParallelism: 1}, time.Hour),
+		LokiCodec,
+		splitByTime,
+		metrics,
+	).Wrap(next)
+
+	for tenantID, hours := range map[string]int64{"tenant-a": 4, "tenant-b": 2} {
+		_, err := split.Do(user.InjectOrgID(context.Background(), tenantID), &LokiRequest{
+			StartTs:   time.Unix(0, 0),
+			EndTs:     time.Unix(0, (time.Duration(hours) * time.Hour).Nanoseconds()),
+			Limit:     1000,
+			Step:      1,
+			Direction: logproto.BACKWARD,
+			Path:      "/api/prom/query_range",
+		})
+		require.NoError(t, err)
+	}
+
+	for tenantID, splits := range map[string]float64{"tenant-a": 4, "tenant-b": 2} {
+		var m dto.Metric
+		require.NoError(t, metrics.splits.WithLabelValues(tenantID).(prometheus.Metric).Write(&m))
+		require.Equal(t, uint64(1), m.GetHistogram().GetSampleCount(), tenantID)
+		require.Equal(t, splits, m.GetHistogram().GetSampleSum(), tenantID)
+	}
+}
@@ -11,3 +11,33 @@ func DefaultLimitsConfig() validation.Limits {
 	flagext.DefaultValues(&limits)
 	return limits
 }
+
+// LimitsConfigWith returns the default limits modified by overrides, so that tests
+// can set the limits they care about inline.
+func LimitsConfigWith(overrides func(*validation.Limits)) validation.Limits {
+	limits := DefaultLimitsConfig()
+	overrides(&limits)
+	return limits
+}
+
+// MockOverrides is an in-memory validation.TenantLimits implementation for
+// tests that need different limits per tenant.
+type MockOverrides map[string]validation.Limits
+
+// ByUserID returns the limits configured for userID, or nil if there are none.
+func (m MockOverrides) ByUserID(userID string) *validation.Limits {
+	l, ok := m[userID]
+	if !ok {
+		return nil
+	}
+	return &l
+}
+
+// AllByUserID returns all configured tenant limits.
+func (m MockOverrides) AllByUserID() map[string]*validation.Limits {
+	res := make(map[string]*validation.Limits, len(m))
+	for userID := range m {
+		res[userID] = m.ByUserID(userID)
+	}
+	return res
+}
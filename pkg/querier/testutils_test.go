@@ -0,0 +1,44 @@
+package querier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pao214/loki/pkg/util/validation"
+)
+
+func TestMockOverrides(t *testing.T) {
+	defaults := DefaultLimitsConfig()
+
+	tenantA := DefaultLimitsConfig()
+	tenantA.MaxQueryLength = model.Duration(time.Hour)
+	tenantB := DefaultLimitsConfig()
+	tenantB.MaxQueryLength = model.Duration(2 * time.Hour)
+
+	limits, err := validation.NewOverrides(defaults, MockOverrides{
+		"tenant-a": tenantA,
+		"tenant-b": tenantB,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, time.Hour, limits.MaxQueryLength("tenant-a"))
+	require.Equal(t, 2*time.Hour, limits.MaxQueryLength("tenant-b"))
+	require.Equal(t, time.Duration(defaults.MaxQueryLength), limits.MaxQueryLength("tenant-c"))
+}
+
+func TestLimitsConfigWith(t *testing.T) {
+	defaults := DefaultLimitsConfig()
+	limits := LimitsConfigWith(func(l *validation.Limits) {
+		l.MaxQueryLength = model.Duration(time.Hour)
+	})
+
+	require.Equal(t, model.Duration(time.Hour), limits.MaxQueryLength)
+	require.NotEqual(t, defaults.MaxQueryLength, limits.MaxQueryLength)
+
+	// everything else keeps its default
+	limits.MaxQueryLength = defaults.MaxQueryLength
+	require.Equal(t, defaults, limits)
+}
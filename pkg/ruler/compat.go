@@ -28,6 +28,7 @@ import (
 	"github.com/pao214/loki/pkg/logql/syntax"
 	ruler "github.com/pao214/loki/pkg/ruler/base"
 	"github.com/pao214/loki/pkg/ruler/rulespb"
+	"github.com/pao214/loki/pkg/ruler/rulestore"
 	"github.com/pao214/loki/pkg/ruler/util"
 )
 
@@ -92,6 +93,27 @@ func engineQueryFunc(engine *logql.Engine, overrides RulesLimits, checker readyC
 	})
 }
 
+// concurrencyLimitedQueryFunc limits the number of concurrent calls to queryFunc to
+// maxConcurrent, further calls wait for a slot or for their context to be done. The
+// limit is disabled when maxConcurrent is not positive.
+func concurrencyLimitedQueryFunc(queryFunc rules.QueryFunc, maxConcurrent int) rules.QueryFunc {
+	if maxConcurrent <= 0 {
+		return queryFunc
+	}
+
+	sem := make(chan struct{}, maxConcurrent)
+	return func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		defer func() { <-sem }()
+
+		return queryFunc(ctx, qs, t)
+	}
+}
+
 // MultiTenantManagerAdapter will wrap a MultiTenantManager which validates loki rules
 func MultiTenantManagerAdapter(mgr ruler.MultiTenantManager) ruler.MultiTenantManager {
 	return &MultiTenantManager{inner: mgr}
@@ -129,10 +151,10 @@ const MetricsPrefix = "loki_ruler_wal_"
 var registry storageRegistry
 
 func MultiTenantRuleManager(cfg Config, engine *logql.Engine, overrides RulesLimits, logger log.Logger, reg prometheus.Registerer) ruler.ManagerFactory {
-	reg = prometheus.WrapRegistererWithPrefix(MetricsPrefix, reg)
-
 	registry = newWALRegistry(log.With(logger, "storage", "registry"), reg, cfg, overrides)
 
+	reg = prometheus.WrapRegistererWithPrefix(MetricsPrefix, reg)
+
 	return func(
 		ctx context.Context,
 		userID string,
@@ -143,7 +165,8 @@ func MultiTenantRuleManager(cfg Config, engine *logql.Engine, overrides RulesLim
 		registry.configureTenantStorage(userID)
 
 		logger = log.With(logger, "user", userID)
-		queryFunc := engineQueryFunc(engine, overrides, registry, userID)
+		// the query function is shared by all the groups of the tenant, so it bounds their concurrent evaluations
+		queryFunc := concurrencyLimitedQueryFunc(engineQueryFunc(engine, overrides, registry, userID), cfg.MaxConcurrentEvalsPerTenant)
 		memStore := NewMemStore(userID, queryFunc, newMemstoreMetrics(reg), 5*time.Minute, log.With(logger, "subcomponent", "MemStore"))
 
 		mgr := rules.NewManager(&rules.ManagerOptions{
@@ -238,6 +261,46 @@ func ValidateGroups(grps ...rulefmt.RuleGroup) (errs []error) {
 	return errs
 }
 
+// RuleGroupError holds the validation errors of a rule group.
+type RuleGroupError struct {
+	Namespace string
+	Group     string
+	Errs      []error
+}
+
+func (e RuleGroupError) Error() string {
+	msgs := make([]string, 0, len(e.Errs))
+	for _, err := range e.Errs {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("namespace %q, group %q: %s", e.Namespace, e.Group, strings.Join(msgs, "; "))
+}
+
+// ValidateRuleGroups loads all the rule groups of the tenant from the store and validates them,
+// including parsing every LogQL expression, without scheduling any evaluation.
+// It returns the errors of every invalid group, or an error if the groups couldn't be loaded.
+func ValidateRuleGroups(ctx context.Context, store rulestore.RuleStore, tenant string) ([]RuleGroupError, error) {
+	groups, err := store.ListRuleGroupsForUserAndNamespace(ctx, tenant, "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list rule groups of tenant %s", tenant)
+	}
+	if err := store.LoadRuleGroups(ctx, map[string]rulespb.RuleGroupList{tenant: groups}); err != nil {
+		return nil, errors.Wrapf(err, "failed to load rule groups of tenant %s", tenant)
+	}
+
+	var groupErrs []RuleGroupError
+	for _, g := range groups {
+		if errs := ValidateGroups(rulespb.FromProto(g)); len(errs) > 0 {
+			groupErrs = append(groupErrs, RuleGroupError{
+				Namespace: g.GetNamespace(),
+				Group:     g.GetName(),
+				Errs:      errs,
+			})
+		}
+	}
+	return groupErrs, nil
+}
+
 func validateRuleNode(r *rulefmt.RuleNode, groupName string) error {
 	if r.Record.Value != "" && r.Alert.Value != "" {
 		return errors.Errorf("only one of 'record' and 'alert' must be set")
@@ -6,15 +6,22 @@ import (
 	"io/ioutil"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	gokitlog "github.com/go-kit/log"
 	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/promql"
 	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"go.uber.org/atomic"
 
 	"github.com/pao214/loki/pkg/iter"
 	"github.com/pao214/loki/pkg/logql"
 	ruler "github.com/pao214/loki/pkg/ruler/base"
+	"github.com/pao214/loki/pkg/ruler/rulespb"
+	"github.com/pao214/loki/pkg/ruler/rulestore/bucketclient"
 	"github.com/pao214/loki/pkg/util/log"
 	"github.com/pao214/loki/pkg/validation"
 )
@@ -312,6 +319,57 @@ func TestNonMetricQuery(t *testing.T) {
 	require.Error(t, err, "rule result is not a vector or scalar")
 }
 
+func TestConcurrencyLimitedQueryFunc(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	release := make(chan struct{})
+	queryFunc := concurrencyLimitedQueryFunc(func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+		n := running.Inc()
+		defer running.Dec()
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CAS(m, n) {
+				break
+			}
+		}
+		<-release
+		return nil, nil
+	}, 2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := queryFunc(context.Background(), "count_over_time({job=\"nginx\"}[1m])", time.Now())
+			require.NoError(t, err)
+		}()
+	}
+
+	// the evaluations over the limit queue up
+	require.Eventually(t, func() bool { return running.Load() == 2 }, time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, int32(2), running.Load())
+
+	close(release)
+	wg.Wait()
+	require.Equal(t, int32(2), maxRunning.Load())
+
+	// a queued evaluation gives up when its context is done
+	started, block := make(chan struct{}), make(chan struct{})
+	queryFunc = concurrencyLimitedQueryFunc(func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+		close(started)
+		<-block
+		return nil, nil
+	}, 1)
+	go func() { _, _ = queryFunc(context.Background(), "", time.Now()) }()
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := queryFunc(ctx, "", time.Now())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	close(block)
+}
+
 type FakeQuerier struct{}
 
 func (q *FakeQuerier) SelectLogs(context.Context, logql.SelectLogParams) (iter.EntryIterator, error) {
@@ -327,3 +385,39 @@ type fakeChecker struct{}
 func (f fakeChecker) isReady(tenant string) bool {
 	return true
 }
+
+func TestValidateRuleGroups(t *testing.T) {
+	ctx := context.Background()
+	store := bucketclient.NewBucketRuleStore(objstore.NewInMemBucket(), nil, gokitlog.NewNopLogger())
+
+	valid := &rulespb.RuleGroupDesc{
+		Name:      "valid",
+		Namespace: "ns",
+		User:      "user",
+		Rules: []*rulespb.RuleDesc{
+			{Record: "nginx:requests:rate1m", Expr: `sum(rate({job="nginx"}[1m]))`},
+		},
+	}
+	malformed := &rulespb.RuleGroupDesc{
+		Name:      "malformed",
+		Namespace: "ns",
+		User:      "user",
+		Rules: []*rulespb.RuleDesc{
+			{Record: "nginx:requests:rate1m", Expr: `sum(rate({job="nginx"}[1m])`},
+		},
+	}
+	require.NoError(t, store.SetRuleGroup(ctx, "user", "ns", valid))
+	require.NoError(t, store.SetRuleGroup(ctx, "user", "ns", malformed))
+
+	groupErrs, err := ValidateRuleGroups(ctx, store, "user")
+	require.NoError(t, err)
+	require.Len(t, groupErrs, 1)
+	require.Equal(t, "ns", groupErrs[0].Namespace)
+	require.Equal(t, "malformed", groupErrs[0].Group)
+	require.Len(t, groupErrs[0].Errs, 1)
+	require.Contains(t, groupErrs[0].Error(), "could not parse expression")
+
+	groupErrs, err = ValidateRuleGroups(ctx, store, "unknown")
+	require.NoError(t, err)
+	require.Empty(t, groupErrs)
+}
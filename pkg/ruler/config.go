@@ -22,6 +22,8 @@ type Config struct {
 
 	WALCleaner  cleaner.Config    `yaml:"wal_cleaner,omitempty"`
 	RemoteWrite RemoteWriteConfig `yaml:"remote_write,omitempty"`
+
+	MaxConcurrentEvalsPerTenant int `yaml:"max_concurrent_evals_per_tenant"`
 }
 
 func (c *Config) RegisterFlags(f *flag.FlagSet) {
@@ -32,6 +34,7 @@ func (c *Config) RegisterFlags(f *flag.FlagSet) {
 
 	// TODO(owen-d, 3.0.0): remove deprecated experimental prefix in Cortex if they'll accept it.
 	f.BoolVar(&c.Config.EnableAPI, "ruler.enable-api", true, "Enable the ruler api")
+	f.IntVar(&c.MaxConcurrentEvalsPerTenant, "ruler.max-concurrent-evals-per-tenant", 0, "Maximum number of rule evaluations run concurrently for a single tenant, further ones wait for a slot. 0 means no limit.")
 }
 
 // Validate overrides the embedded cortex variant which expects a cortex limits struct. Instead copy the relevant bits over.
@@ -44,6 +47,10 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid ruler remote-write config: %w", err)
 	}
 
+	if c.MaxConcurrentEvalsPerTenant < 0 {
+		return errors.New("max concurrent evaluations per tenant must not be negative")
+	}
+
 	return nil
 }
 
@@ -51,6 +58,7 @@ type RemoteWriteConfig struct {
 	Client              config.RemoteWriteConfig `yaml:"client"`
 	Enabled             bool                     `yaml:"enabled"`
 	ConfigRefreshPeriod time.Duration            `yaml:"config_refresh_period"`
+	PerGroupMetrics     bool                     `yaml:"per_group_metrics"`
 }
 
 func (c *RemoteWriteConfig) Validate() error {
@@ -86,4 +94,5 @@ func (c *RemoteWriteConfig) Clone() (*RemoteWriteConfig, error) {
 func (c *RemoteWriteConfig) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&c.Enabled, "ruler.remote-write.enabled", false, "Remote-write recording rule samples to Prometheus-compatible remote-write receiver.")
 	f.DurationVar(&c.ConfigRefreshPeriod, "ruler.remote-write.config-refresh-period", 10*time.Second, "Minimum period to wait between refreshing remote-write reconfigurations. This should be greater than or equivalent to -limits.per-user-override-period.")
+	f.BoolVar(&c.PerGroupMetrics, "ruler.remote-write.per-group-metrics", false, "Break down the WAL appended samples and append failures metrics by rule group in addition to tenant. Each rule group adds series.")
 }
@@ -17,6 +17,8 @@ import (
 	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/rules"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/weaveworks/common/user"
 	"gopkg.in/yaml.v2"
@@ -46,12 +48,16 @@ type storageRegistry interface {
 	configureTenantStorage(tenant string)
 }
 
+// newWALRegistry registers its metrics with MetricsPrefix, except for the remote-write results which are named on their own.
 func newWALRegistry(logger log.Logger, reg prometheus.Registerer, config Config, overrides RulesLimits) storageRegistry {
 	if !config.RemoteWrite.Enabled {
 		return nullRegistry{}
 	}
 
-	manager := createInstanceManager(logger, reg)
+	remoteWriteResults := newRemoteWriteResults(reg)
+	reg = prometheus.WrapRegistererWithPrefix(MetricsPrefix, reg)
+
+	manager := createInstanceManager(logger, reg, remoteWriteResults)
 
 	return &walRegistry{
 		logger:    logger,
@@ -69,10 +75,11 @@ func newWALRegistry(logger log.Logger, reg prometheus.Registerer, config Config,
 	}
 }
 
-func createInstanceManager(logger log.Logger, reg prometheus.Registerer) *instance.BasicManager {
+func createInstanceManager(logger log.Logger, reg prometheus.Registerer, remoteWriteResults *remoteWriteResults) *instance.BasicManager {
 	tenantManager := &tenantWALManager{
-		reg:    reg,
-		logger: log.With(logger, "manager", "tenant-wal"),
+		reg:                reg,
+		remoteWriteResults: remoteWriteResults,
+		logger:             log.With(logger, "manager", "tenant-wal"),
 	}
 
 	return instance.NewBasicManager(instance.BasicManagerConfig{
@@ -137,7 +144,7 @@ func (r *walRegistry) Appender(ctx context.Context) storage.Appender {
 	inst := r.get(tenant)
 	if inst == nil {
 		level.Warn(r.logger).Log("user", tenant, "msg", "WAL instance not yet ready")
-		return notReadyAppender{}
+		return r.withGroupMetrics(ctx, tenant, notReadyAppender{})
 	}
 
 	// we should reconfigure the storage whenever this appender is requested, but since
@@ -150,7 +157,39 @@ func (r *walRegistry) Appender(ctx context.Context) storage.Appender {
 		r.configureTenantStorage(tenant)
 	}
 
-	return inst.Appender(ctx)
+	return r.withGroupMetrics(ctx, tenant, inst.Appender(ctx))
+}
+
+// withGroupMetrics wraps app to record the samples appended to the WAL and failed by the rule group evaluated in ctx.
+// app is returned as is outside of rule group evaluations.
+func (r *walRegistry) withGroupMetrics(ctx context.Context, tenant string, app storage.Appender) storage.Appender {
+	group, ok := ruleGroupFromContext(ctx)
+	if !ok {
+		return app
+	}
+	if !r.config.RemoteWrite.PerGroupMetrics {
+		group = ""
+	}
+
+	return &groupMetricsAppender{
+		Appender: app,
+		samples:  r.metrics.walAppendedSamples.WithLabelValues(tenant, group),
+		failures: r.metrics.walAppendFailures.WithLabelValues(tenant, group),
+	}
+}
+
+// ruleGroupFromContext returns the key of the rule group being evaluated, which the
+// rules manager sets as the query origin.
+func ruleGroupFromContext(ctx context.Context) (string, bool) {
+	origin, ok := ctx.Value(promql.QueryOrigin{}).(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	group, ok := origin["ruleGroup"].(map[string]string)
+	if !ok {
+		return "", false
+	}
+	return rules.GroupKey(group["file"], group["name"]), true
 }
 
 func (r *walRegistry) configureTenantStorage(tenant string) {
@@ -352,19 +391,56 @@ func (n discardingAppender) AppendExemplar(ref storage.SeriesRef, l labels.Label
 func (n discardingAppender) Commit() error   { return nil }
 func (n discardingAppender) Rollback() error { return nil }
 
+// groupMetricsAppender counts the samples appended and committed, or failed, by a rule group evaluation.
+type groupMetricsAppender struct {
+	storage.Appender
+
+	samples, failures prometheus.Counter
+	appended          int
+}
+
+func (a *groupMetricsAppender) Append(ref storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	ref, err := a.Appender.Append(ref, l, t, v)
+	if err != nil {
+		a.failures.Inc()
+		return ref, err
+	}
+	a.appended++
+	return ref, nil
+}
+
+func (a *groupMetricsAppender) Commit() error {
+	err := a.Appender.Commit()
+	if err != nil {
+		a.failures.Add(float64(a.appended))
+	} else {
+		a.samples.Add(float64(a.appended))
+	}
+	a.appended = 0
+	return err
+}
+
+func (a *groupMetricsAppender) Rollback() error {
+	a.appended = 0
+	return a.Appender.Rollback()
+}
+
 type readyChecker interface {
 	isReady(tenant string) bool
 }
 
 type tenantWALManager struct {
-	logger log.Logger
-	reg    prometheus.Registerer
+	logger             log.Logger
+	reg                prometheus.Registerer
+	remoteWriteResults *remoteWriteResults
 }
 
 func (t *tenantWALManager) newInstance(c instance.Config) (instance.ManagedInstance, error) {
 	reg := prometheus.WrapRegistererWith(prometheus.Labels{
 		"tenant": c.Tenant,
 	}, t.reg)
+	// the remote-write queues of the instance report their results through the counters they register
+	reg = t.remoteWriteResults.registerer(c.Tenant, reg)
 
 	// create metrics here and pass down
 	return instance.New(reg, c, wal.NewMetrics(reg), t.logger)
@@ -373,7 +449,9 @@ func (t *tenantWALManager) newInstance(c instance.Config) (instance.ManagedInsta
 type storageRegistryMetrics struct {
 	reg prometheus.Registerer
 
-	appenderReady *prometheus.GaugeVec
+	appenderReady      *prometheus.GaugeVec
+	walAppendedSamples *prometheus.CounterVec
+	walAppendFailures  *prometheus.CounterVec
 }
 
 func newStorageRegistryMetrics(reg prometheus.Registerer) *storageRegistryMetrics {
@@ -383,11 +461,21 @@ func newStorageRegistryMetrics(reg prometheus.Registerer) *storageRegistryMetric
 			Name: "appender_ready",
 			Help: "Whether a WAL appender is ready to accept samples (1) or not (0)",
 		}, []string{"tenant"}),
+		walAppendedSamples: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "appended_samples_total",
+			Help: "Total number of samples appended by rule groups to the WAL, whether or not they were remote-written since.",
+		}, []string{"tenant", "group"}),
+		walAppendFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "append_failures_total",
+			Help: "Total number of samples rule groups failed to append to the WAL.",
+		}, []string{"tenant", "group"}),
 	}
 
 	if reg != nil {
 		reg.MustRegister(
 			m.appenderReady,
+			m.walAppendedSamples,
+			m.walAppendFailures,
 		)
 	}
 
@@ -3,6 +3,8 @@ package ruler
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"strings"
@@ -10,13 +12,19 @@ import (
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	promConfig "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/rules"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
 
 	"github.com/pao214/loki/pkg/ruler/storage/instance"
 	"github.com/pao214/loki/pkg/ruler/util"
@@ -329,6 +337,142 @@ func TestStorageSetupWithRemoteWriteDisabled(t *testing.T) {
 	assert.Truef(t, ok, "instance is not of expected type")
 }
 
+func TestAppenderGroupMetrics(t *testing.T) {
+	for _, perGroup := range []bool{true, false} {
+		reg := setupRegistry(t, t.TempDir())
+		reg.config.RemoteWrite.PerGroupMetrics = perGroup
+		reg.configureTenantStorage(enabledRWTenant)
+		test.Poll(t, 2*time.Second, true, func() interface{} {
+			return reg.isReady(enabledRWTenant)
+		})
+
+		group := ""
+		if perGroup {
+			group = rules.GroupKey("rules.yaml", "group")
+		}
+		evalCtx := func(tenant string) context.Context {
+			return promql.NewOriginContext(user.InjectOrgID(context.Background(), tenant), map[string]interface{}{
+				"ruleGroup": map[string]string{"file": "rules.yaml", "name": "group"},
+			})
+		}
+
+		app := reg.Appender(evalCtx(enabledRWTenant))
+		for i := 0; i < 3; i++ {
+			_, err := app.Append(0, labels.FromStrings("__name__", "metric", "i", fmt.Sprint(i)), time.Now().UnixMilli(), 1)
+			require.NoError(t, err)
+		}
+		require.NoError(t, app.Commit())
+		require.Equal(t, 3.0, testutil.ToFloat64(reg.metrics.walAppendedSamples.WithLabelValues(enabledRWTenant, group)))
+		require.Equal(t, 0.0, testutil.ToFloat64(reg.metrics.walAppendFailures.WithLabelValues(enabledRWTenant, group)))
+
+		// the storage of this tenant isn't ready yet, so its samples fail
+		app = reg.Appender(evalCtx(additionalHeadersRWTenant))
+		_, err := app.Append(0, labels.FromStrings("__name__", "metric"), time.Now().UnixMilli(), 1)
+		require.Error(t, err)
+		require.Equal(t, 1.0, testutil.ToFloat64(reg.metrics.walAppendFailures.WithLabelValues(additionalHeadersRWTenant, group)))
+
+		// appenders requested outside of rule group evaluations aren't tracked
+		app = reg.Appender(user.InjectOrgID(context.Background(), enabledRWTenant))
+		_, ok := app.(*groupMetricsAppender)
+		require.False(t, ok)
+	}
+}
+
+func TestRemoteWriteResultMetrics(t *testing.T) {
+	var failing atomic.Bool
+	received := atomic.NewInt64(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Inc()
+		if failing.Load() {
+			// client errors aren't retried, so the samples of the request fail
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	reg := setupRegistry(t, t.TempDir())
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	reg.config.RemoteWrite.Client.URL = &promConfig.URL{URL: u}
+	reg.config.RemoteWrite.Client.QueueConfig.BatchSendDeadline = model.Duration(10 * time.Millisecond)
+
+	results := newRemoteWriteResults(nil)
+	reg.manager = createInstanceManager(log.NewNopLogger(), nil, results)
+	reg.configureTenantStorage(enabledRWTenant)
+	defer reg.stop()
+	test.Poll(t, 2*time.Second, true, func() interface{} {
+		return reg.isReady(enabledRWTenant)
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(results)
+	expected := func(samples, failures int) string {
+		return fmt.Sprintf(`
+# HELP loki_ruler_remote_write_failures_total Total number of samples the ruler failed to send to remote-write endpoints after exhausting retries.
+# TYPE loki_ruler_remote_write_failures_total counter
+loki_ruler_remote_write_failures_total{tenant="enabled"} %d
+# HELP loki_ruler_remote_write_samples_total Total number of samples sent by the ruler to remote-write endpoints, including retries.
+# TYPE loki_ruler_remote_write_samples_total counter
+loki_ruler_remote_write_samples_total{tenant="enabled"} %d
+`, failures, samples)
+	}
+	appendSamples := func(n int) {
+		app := reg.Appender(user.InjectOrgID(context.Background(), enabledRWTenant))
+		for i := 0; i < n; i++ {
+			_, err := app.Append(0, labels.FromStrings("__name__", "metric", "i", fmt.Sprint(i)), time.Now().UnixMilli(), 1)
+			require.NoError(t, err)
+		}
+		require.NoError(t, app.Commit())
+	}
+
+	appendSamples(3)
+	test.Poll(t, 10*time.Second, nil, func() interface{} {
+		return testutil.GatherAndCompare(registry, strings.NewReader(expected(3, 0)))
+	})
+
+	failing.Store(true)
+	appendSamples(2)
+	test.Poll(t, 10*time.Second, nil, func() interface{} {
+		return testutil.GatherAndCompare(registry, strings.NewReader(expected(5, 2)))
+	})
+	require.Positive(t, received.Load())
+}
+
+func TestRemoteWriteResultsKeptAcrossQueues(t *testing.T) {
+	results := newRemoteWriteResults(nil)
+	reg := results.registerer("tenant", prometheus.NewRegistry())
+
+	newQueueCounters := func() (prometheus.Counter, prometheus.Counter) {
+		samples := prometheus.NewCounter(prometheus.CounterOpts{Name: queueSamplesName, ConstLabels: prometheus.Labels{"url": "a"}})
+		failures := prometheus.NewCounter(prometheus.CounterOpts{Name: queueFailuresName, ConstLabels: prometheus.Labels{"url": "a"}})
+		reg.MustRegister(samples, failures)
+		return samples, failures
+	}
+
+	samples, failures := newQueueCounters()
+	samples.Add(3)
+	failures.Add(1)
+
+	// reloading the remote-write configuration replaces the queues and their counters
+	require.True(t, reg.Unregister(samples))
+	require.True(t, reg.Unregister(failures))
+	samples, _ = newQueueCounters()
+	samples.Add(2)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(results)
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP loki_ruler_remote_write_failures_total Total number of samples the ruler failed to send to remote-write endpoints after exhausting retries.
+# TYPE loki_ruler_remote_write_failures_total counter
+loki_ruler_remote_write_failures_total{tenant="tenant"} 1
+# HELP loki_ruler_remote_write_samples_total Total number of samples sent by the ruler to remote-write endpoints, including retries.
+# TYPE loki_ruler_remote_write_samples_total counter
+loki_ruler_remote_write_samples_total{tenant="tenant"} 5
+`)))
+}
+
 type fakeLimits struct {
 	limits map[string]*validation.Limits
 }
@@ -0,0 +1,180 @@
+package ruler
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Names of the counters the remote-write queues increment with the samples they send and fail to send.
+const (
+	queueSamplesName  = "prometheus_remote_storage_samples_total"
+	queueFailuresName = "prometheus_remote_storage_samples_failed_total"
+)
+
+// remoteWriteResults exports the samples the remote-write queues of each tenant sent, or failed to send.
+// The queues only expose their results through their own metrics, so their counters are picked up as the
+// queues register them, see remoteWriteResultsRegisterer. Samples are labelled by tenant only: once in the
+// WAL, samples are no longer attributed to the rule group which appended them.
+type remoteWriteResults struct {
+	samplesDesc, failuresDesc *prometheus.Desc
+
+	mtx     sync.Mutex
+	tenants map[string]*tenantRemoteWriteResults
+}
+
+type tenantRemoteWriteResults struct {
+	// totals holds the counts of the queues which were stopped since, by counter name.
+	totals map[string]float64
+	// queues holds the counters of the running queues, mapped to their name.
+	queues map[prometheus.Counter]string
+}
+
+func newRemoteWriteResults(reg prometheus.Registerer) *remoteWriteResults {
+	r := &remoteWriteResults{
+		samplesDesc: prometheus.NewDesc(
+			"loki_ruler_remote_write_samples_total",
+			"Total number of samples sent by the ruler to remote-write endpoints, including retries.",
+			[]string{"tenant"}, nil,
+		),
+		failuresDesc: prometheus.NewDesc(
+			"loki_ruler_remote_write_failures_total",
+			"Total number of samples the ruler failed to send to remote-write endpoints after exhausting retries.",
+			[]string{"tenant"}, nil,
+		),
+		tenants: map[string]*tenantRemoteWriteResults{},
+	}
+
+	if reg != nil {
+		reg.MustRegister(r)
+	}
+
+	return r
+}
+
+// registerer returns a registerer which forwards to reg, tracking the remote-write queue counters of tenant.
+func (r *remoteWriteResults) registerer(tenant string, reg prometheus.Registerer) prometheus.Registerer {
+	return &remoteWriteResultsRegisterer{Registerer: reg, tenant: tenant, results: r}
+}
+
+func (r *remoteWriteResults) track(tenant string, c prometheus.Collector) {
+	counter, name, ok := queueCounter(c)
+	if !ok {
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	t, ok := r.tenants[tenant]
+	if !ok {
+		t = &tenantRemoteWriteResults{
+			totals: map[string]float64{},
+			queues: map[prometheus.Counter]string{},
+		}
+		r.tenants[tenant] = t
+	}
+	t.queues[counter] = name
+}
+
+// untrack keeps the count of a stopped queue, so the exported counters don't go backwards
+// when the remote-write configuration of the tenant is reloaded.
+func (r *remoteWriteResults) untrack(tenant string, c prometheus.Collector) {
+	counter, ok := c.(prometheus.Counter)
+	if !ok {
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	t, ok := r.tenants[tenant]
+	if !ok {
+		return
+	}
+	name, ok := t.queues[counter]
+	if !ok {
+		return
+	}
+	t.totals[name] += counterValue(counter)
+	delete(t.queues, counter)
+}
+
+func (r *remoteWriteResults) Describe(ch chan<- *prometheus.Desc) {
+	ch <- r.samplesDesc
+	ch <- r.failuresDesc
+}
+
+func (r *remoteWriteResults) Collect(ch chan<- prometheus.Metric) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for tenant, t := range r.tenants {
+		samples, failures := t.totals[queueSamplesName], t.totals[queueFailuresName]
+		for counter, name := range t.queues {
+			switch name {
+			case queueSamplesName:
+				samples += counterValue(counter)
+			case queueFailuresName:
+				failures += counterValue(counter)
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(r.samplesDesc, prometheus.CounterValue, samples, tenant)
+		ch <- prometheus.MustNewConstMetric(r.failuresDesc, prometheus.CounterValue, failures, tenant)
+	}
+}
+
+// queueCounter returns c as a counter, along with its name, if it's one of the remote-write queue result counters.
+func queueCounter(c prometheus.Collector) (prometheus.Counter, string, bool) {
+	counter, ok := c.(prometheus.Counter)
+	if !ok {
+		return nil, "", false
+	}
+	// descriptors don't expose their name other than through their string representation
+	desc := counter.Desc().String()
+	for _, name := range []string{queueSamplesName, queueFailuresName} {
+		if strings.HasPrefix(desc, `Desc{fqName: "`+name+`"`) {
+			return counter, name, true
+		}
+	}
+	return nil, "", false
+}
+
+func counterValue(c prometheus.Counter) float64 {
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		return 0
+	}
+	return m.GetCounter().GetValue()
+}
+
+// remoteWriteResultsRegisterer passes the counters registered by the remote-write queues of a tenant to remoteWriteResults.
+type remoteWriteResultsRegisterer struct {
+	prometheus.Registerer
+
+	tenant  string
+	results *remoteWriteResults
+}
+
+func (r *remoteWriteResultsRegisterer) Register(c prometheus.Collector) error {
+	if err := r.Registerer.Register(c); err != nil {
+		return err
+	}
+	r.results.track(r.tenant, c)
+	return nil
+}
+
+func (r *remoteWriteResultsRegisterer) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (r *remoteWriteResultsRegisterer) Unregister(c prometheus.Collector) bool {
+	r.results.untrack(r.tenant, c)
+	return r.Registerer.Unregister(c)
+}
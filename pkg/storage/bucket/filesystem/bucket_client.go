@@ -1,11 +1,127 @@
 package filesystem
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/objstore/filesystem"
 )
 
 // NewBucketClient creates a new filesystem bucket client
 func NewBucketClient(cfg Config) (objstore.Bucket, error) {
-	return filesystem.NewBucket(cfg.Directory)
+	bkt, err := filesystem.NewBucket(cfg.Directory)
+	if err != nil {
+		return nil, err
+	}
+
+	rootDir, err := filepath.Abs(cfg.Directory)
+	if err != nil {
+		return nil, err
+	}
+	return &bucket{Bucket: bkt, rootDir: rootDir, dirMode: os.FileMode(cfg.DirMode), fileMode: os.FileMode(cfg.FileMode)}, nil
+}
+
+// tmpFileMarker is part of the name of the temporary files objects are written to before being renamed into place.
+const tmpFileMarker = ".tmp-"
+
+// bucket writes objects atomically, so a crash mid-write never leaves a truncated object behind,
+// and sets the permissions of the directories and files it creates when configured to.
+// The temporary files of in-progress or interrupted uploads are never listed.
+type bucket struct {
+	objstore.Bucket
+	rootDir  string
+	dirMode  os.FileMode
+	fileMode os.FileMode
+}
+
+// Upload writes the object to a temporary file in the destination directory and renames it into place once complete.
+// The temporary file is removed whenever the upload fails, including when ctx is done before the rename.
+func (b *bucket) Upload(ctx context.Context, name string, r io.Reader) (err error) {
+	file := filepath.Join(b.rootDir, name)
+	dir := filepath.Dir(file)
+	if b.dirMode != 0 {
+		err = b.mkdirAll(dir)
+	} else {
+		err = os.MkdirAll(dir, os.ModePerm)
+	}
+	if err != nil {
+		return err
+	}
+
+	// the temporary file is hidden and in the same directory, for the rename to be atomic
+	tmp := filepath.Join(dir, fmt.Sprintf(".%s%s%d", filepath.Base(file), tmpFileMarker, rand.Int63()))
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	if _, err = io.Copy(f, r); err != nil {
+		return errors.Wrapf(err, "copy to %s", file)
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if b.fileMode != 0 {
+		if err = os.Chmod(tmp, b.fileMode); err != nil {
+			return err
+		}
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// Iter calls f for each entry in the given directory, skipping the temporary files of uploads.
+func (b *bucket) Iter(ctx context.Context, dir string, f func(string) error, options ...objstore.IterOption) error {
+	return b.Bucket.Iter(ctx, dir, func(name string) error {
+		if isTmpFile(name) {
+			return nil
+		}
+		return f(name)
+	}, options...)
+}
+
+// isTmpFile reports whether the object name is the one of an upload's temporary file.
+func isTmpFile(name string) bool {
+	if strings.HasSuffix(name, objstore.DirDelim) {
+		return false
+	}
+	base := path.Base(name)
+	return strings.HasPrefix(base, ".") && strings.Contains(base, tmpFileMarker)
+}
+
+// mkdirAll creates dir and its missing parents with dirMode. Existing directories are left untouched.
+func (b *bucket) mkdirAll(dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if parent := filepath.Dir(dir); parent != dir {
+		if err := b.mkdirAll(parent); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Mkdir(dir, b.dirMode); err != nil && !os.IsExist(err) {
+		return err
+	}
+	// Mkdir applies the umask
+	return os.Chmod(dir, b.dirMode)
 }
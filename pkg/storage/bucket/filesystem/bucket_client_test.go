@@ -0,0 +1,132 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestBucketClient_Modes(t *testing.T) {
+	// make sure the configured modes win over the umask
+	defer syscall.Umask(syscall.Umask(0o077))
+
+	dir := t.TempDir()
+	bkt, err := NewBucketClient(Config{Directory: dir, DirMode: 0o750, FileMode: 0o640})
+	require.NoError(t, err)
+
+	require.NoError(t, bkt.Upload(context.Background(), "tenant/index/object", strings.NewReader("content")))
+
+	info, err := os.Stat(filepath.Join(dir, "tenant/index/object"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+
+	for _, d := range []string{"tenant", "tenant/index"} {
+		info, err := os.Stat(filepath.Join(dir, d))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o750), info.Mode().Perm(), d)
+	}
+
+	// the root directory isn't created by the bucket and is left as is
+	info, err = os.Stat(dir)
+	require.NoError(t, err)
+	require.NotEqual(t, os.FileMode(0o750), info.Mode().Perm())
+}
+
+type failingReader struct {
+	r io.Reader
+}
+
+func (f failingReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+func TestBucketClient_AtomicUpload(t *testing.T) {
+	dir := t.TempDir()
+	bkt, err := NewBucketClient(Config{Directory: dir})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// a failed write leaves neither a partial object nor the temporary file behind
+	err = bkt.Upload(ctx, "index/object", failingReader{strings.NewReader("partial")})
+	require.Error(t, err)
+	_, err = os.Stat(filepath.Join(dir, "index/object"))
+	require.True(t, os.IsNotExist(err))
+	entries, err := os.ReadDir(filepath.Join(dir, "index"))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	// and doesn't clobber an existing object
+	require.NoError(t, bkt.Upload(ctx, "index/object", strings.NewReader("complete")))
+	err = bkt.Upload(ctx, "index/object", failingReader{strings.NewReader("partial")})
+	require.Error(t, err)
+
+	rc, err := bkt.Get(ctx, "index/object")
+	require.NoError(t, err)
+	content, err := io.ReadAll(rc)
+	require.NoError(t, rc.Close())
+	require.NoError(t, err)
+	require.Equal(t, "complete", string(content))
+
+	entries, err = os.ReadDir(filepath.Join(dir, "index"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestBucketClient_UploadCancelled(t *testing.T) {
+	dir := t.TempDir()
+	bkt, err := NewBucketClient(Config{Directory: dir})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = bkt.Upload(ctx, "index/object", strings.NewReader("content"))
+	require.ErrorIs(t, err, context.Canceled)
+
+	entries, err := os.ReadDir(filepath.Join(dir, "index"))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestBucketClient_IterSkipsTmpFiles(t *testing.T) {
+	dir := t.TempDir()
+	bkt, err := NewBucketClient(Config{Directory: dir})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, bkt.Upload(ctx, "index/object", strings.NewReader("content")))
+	// left behind by an upload interrupted by a crash
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index", ".other"+tmpFileMarker+"42"), []byte("partial"), 0o666))
+
+	var names []string
+	require.NoError(t, bkt.Iter(ctx, "index/", func(name string) error {
+		names = append(names, name)
+		return nil
+	}))
+	require.Equal(t, []string{"index/object"}, names)
+}
+
+func TestMode(t *testing.T) {
+	var cfg Config
+	require.NoError(t, yaml.UnmarshalStrict([]byte("dir: /data\ndir_mode: 0750\nfile_mode: \"640\"\n"), &cfg))
+	require.Equal(t, Mode(0o750), cfg.DirMode)
+	require.Equal(t, Mode(0o640), cfg.FileMode)
+	require.Equal(t, "0750", cfg.DirMode.String())
+
+	var m Mode
+	require.Error(t, m.Set("0999"))
+	require.Error(t, m.Set("10777"))
+	require.NoError(t, m.Set("0600"))
+	require.Equal(t, Mode(0o600), m)
+}
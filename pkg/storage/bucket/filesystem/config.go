@@ -1,10 +1,17 @@
 package filesystem
 
-import "flag"
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
 
 // Config stores the configuration for storing and accessing objects in the local filesystem.
 type Config struct {
 	Directory string `yaml:"dir"`
+	DirMode   Mode   `yaml:"dir_mode"`
+	FileMode  Mode   `yaml:"file_mode"`
 }
 
 // RegisterFlags registers the flags for filesystem storage
@@ -15,4 +22,47 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 // RegisterFlagsWithPrefix registers the flags for filesystem storage with the provided prefix
 func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.StringVar(&cfg.Directory, prefix+"filesystem.dir", "", "Local filesystem storage directory.")
+	f.Var(&cfg.DirMode, prefix+"filesystem.dir-mode", "Permissions, in octal, of the directories created in the local filesystem storage directory, regardless of the umask. 0 to use the process umask.")
+	f.Var(&cfg.FileMode, prefix+"filesystem.file-mode", "Permissions, in octal, of the objects written to the local filesystem storage directory, regardless of the umask. 0 to use the process umask.")
+}
+
+// Mode is a file mode set in octal, e.g. 0640.
+type Mode os.FileMode
+
+// String implements flag.Value
+func (m Mode) String() string {
+	return fmt.Sprintf("%#o", uint32(m))
+}
+
+// Set implements flag.Value
+func (m *Mode) Set(s string) error {
+	v, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return fmt.Errorf("invalid file mode %q, expected an octal number", s)
+	}
+	if os.FileMode(v)&^os.ModePerm != 0 {
+		return fmt.Errorf("invalid file mode %q, only permission bits are allowed", s)
+	}
+	*m = Mode(v)
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler. Unquoted modes like 0640 are already
+// read as octal by YAML, quoted ones are parsed as octal.
+func (m *Mode) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var v uint32
+	if err := unmarshal(&v); err == nil {
+		return m.Set(strconv.FormatUint(uint64(v), 8))
+	}
+
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	return m.Set(s)
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (m Mode) MarshalYAML() (interface{}, error) {
+	return m.String(), nil
 }
@@ -60,7 +60,10 @@ type DynamoDBConfig struct {
 	Metrics                MetricsAutoScalingConfig `yaml:"metrics"`
 	ChunkGangSize          int                      `yaml:"chunk_gang_size"`
 	ChunkGetMaxParallelism int                      `yaml:"chunk_get_max_parallelism"`
-	BackoffConfig          backoff.Config           `yaml:"backoff_config"`
+	// BackoffConfig is used for reads and table operations, WriteBackoffConfig for batch writes.
+	// The settings left unset in WriteBackoffConfig default to the ones of BackoffConfig.
+	BackoffConfig      backoff.Config `yaml:"backoff_config"`
+	WriteBackoffConfig backoff.Config `yaml:"write_backoff_config"`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet
@@ -74,9 +77,27 @@ func (cfg *DynamoDBConfig) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.BackoffConfig.MinBackoff, "dynamodb.min-backoff", 100*time.Millisecond, "Minimum backoff time")
 	f.DurationVar(&cfg.BackoffConfig.MaxBackoff, "dynamodb.max-backoff", 50*time.Second, "Maximum backoff time")
 	f.IntVar(&cfg.BackoffConfig.MaxRetries, "dynamodb.max-retries", 20, "Maximum number of times to retry an operation")
+	f.DurationVar(&cfg.WriteBackoffConfig.MinBackoff, "dynamodb.write-min-backoff", 0, "Minimum backoff time of batch writes. Defaults to -dynamodb.min-backoff when 0")
+	f.DurationVar(&cfg.WriteBackoffConfig.MaxBackoff, "dynamodb.write-max-backoff", 0, "Maximum backoff time of batch writes. Defaults to -dynamodb.max-backoff when 0")
+	f.IntVar(&cfg.WriteBackoffConfig.MaxRetries, "dynamodb.write-max-retries", 0, "Maximum number of times to retry a batch write. Defaults to -dynamodb.max-retries when 0")
 	cfg.Metrics.RegisterFlags(f)
 }
 
+// writeBackoffConfig returns the backoff of batch writes, with the unset settings taken from BackoffConfig.
+func (cfg *DynamoDBConfig) writeBackoffConfig() backoff.Config {
+	c := cfg.WriteBackoffConfig
+	if c.MinBackoff == 0 {
+		c.MinBackoff = cfg.BackoffConfig.MinBackoff
+	}
+	if c.MaxBackoff == 0 {
+		c.MaxBackoff = cfg.BackoffConfig.MaxBackoff
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = cfg.BackoffConfig.MaxRetries
+	}
+	return c
+}
+
 // StorageConfig specifies config for storing data on AWS.
 type StorageConfig struct {
 	DynamoDBConfig `yaml:"dynamodb"`
@@ -177,7 +198,7 @@ func (a dynamoDBStorageClient) BatchWrite(ctx context.Context, input chunk.Write
 	outstanding := input.(dynamoDBWriteBatch)
 	unprocessed := dynamoDBWriteBatch{}
 
-	backoff := backoff.New(ctx, a.cfg.BackoffConfig)
+	backoff := backoff.New(ctx, a.cfg.writeBackoffConfig())
 
 	for outstanding.Len()+unprocessed.Len() > 0 && backoff.Ongoing() {
 		requests := dynamoDBWriteBatch{}
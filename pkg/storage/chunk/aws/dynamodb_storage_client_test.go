@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/dskit/backoff"
 	"github.com/prometheus/common/model"
 
 	"github.com/stretchr/testify/require"
@@ -51,3 +52,38 @@ func TestChunksPartialError(t *testing.T) {
 	require.Error(t, err)
 	require.Equal(t, dynamoDBMaxReadBatchSize, len(chunksWeGot))
 }
+
+func TestBatchWriteBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		maxRetries      int
+		writeMaxRetries int
+		expectErr       bool
+	}{
+		{name: "write retries exhausted", maxRetries: 1, writeMaxRetries: 3, expectErr: true},
+		{name: "write retries left", maxRetries: 1, writeMaxRetries: 6},
+		// without a write backoff, batch writes keep using -dynamodb.max-retries
+		{name: "retries exhausted", maxRetries: 3, expectErr: true},
+		{name: "retries left", maxRetries: 6},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, client, closer, err := testutils.Setup(dynamoDBFixture(0, 10, 20), tableName)
+			require.NoError(t, err)
+			defer closer.Close()
+
+			sc := client.(*dynamoDBStorageClient)
+			sc.cfg.BackoffConfig.MaxRetries = tc.maxRetries
+			sc.cfg.WriteBackoffConfig = backoff.Config{MaxRetries: tc.writeMaxRetries}
+			sc.DynamoDB.(*mockDynamoDBClient).writeProvisionedErr = 5
+
+			batch := sc.NewWriteBatch()
+			batch.Add(tableName, "hash", []byte("range"), []byte("value"))
+			err = sc.BatchWrite(context.Background(), batch)
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
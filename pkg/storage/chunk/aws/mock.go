@@ -5,6 +5,8 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/url"
 	"sort"
 	"strings"
 	"sync"
@@ -31,6 +33,9 @@ type mockDynamoDBClient struct {
 	provisionedErr int
 	errAfter       int
 	tables         map[string]*mockDynamoDBTable
+
+	// writeProvisionedErr is the number of provisioned throughput errors returned to batch writes only.
+	writeProvisionedErr int
 }
 
 type mockDynamoDBTable struct {
@@ -76,8 +81,12 @@ func (m *mockDynamoDBClient) batchWriteItemRequest(_ context.Context, input *dyn
 
 	if m.errAfter > 0 {
 		m.errAfter--
-	} else if m.provisionedErr > 0 {
-		m.provisionedErr--
+	} else if m.provisionedErr > 0 || m.writeProvisionedErr > 0 {
+		if m.writeProvisionedErr > 0 {
+			m.writeProvisionedErr--
+		} else {
+			m.provisionedErr--
+		}
 		return &dynamoDBMockRequest{
 			result: resp,
 			err:    awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "", nil),
@@ -426,3 +435,35 @@ func (m *mockS3) GetObjectWithContext(_ aws.Context, req *s3.GetObjectInput, _ .
 		Body: ioutil.NopCloser(bytes.NewReader(buf)),
 	}, nil
 }
+
+func (m *mockS3) HeadObjectWithContext(_ aws.Context, req *s3.HeadObjectInput, _ ...request.Option) (*s3.HeadObjectOutput, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	buf, ok := m.objects[*req.Key]
+	if !ok {
+		return nil, awserr.NewRequestFailure(awserr.New("NotFound", "Not Found", nil), http.StatusNotFound, "")
+	}
+
+	return &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len(buf))),
+	}, nil
+}
+
+func (m *mockS3) CopyObjectWithContext(_ aws.Context, req *s3.CopyObjectInput, _ ...request.Option) (*s3.CopyObjectOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	source, err := url.PathUnescape(*req.CopySource)
+	if err != nil {
+		return nil, err
+	}
+	// the copy source is <bucket>/<key>
+	buf, ok := m.objects[source[strings.Index(source, "/")+1:]]
+	if !ok {
+		return nil, awserr.New(s3.ErrCodeNoSuchKey, "Not found", nil)
+	}
+
+	m.objects[*req.Key] = buf
+	return &s3.CopyObjectOutput{}, nil
+}
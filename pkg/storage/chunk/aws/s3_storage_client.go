@@ -1,6 +1,7 @@
 package aws
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -8,8 +9,10 @@ import (
 	"fmt"
 	"hash/fnv"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -332,7 +335,7 @@ func (a *S3ObjectClient) Stop() {}
 
 // DeleteObject deletes the specified objectKey from the appropriate S3 bucket
 func (a *S3ObjectClient) DeleteObject(ctx context.Context, objectKey string) error {
-	return instrument.CollectedRequest(ctx, "S3.DeleteObject", s3RequestDuration, instrument.ErrorCode, func(ctx context.Context) error {
+	err := instrument.CollectedRequest(ctx, "S3.DeleteObject", s3RequestDuration, instrument.ErrorCode, func(ctx context.Context) error {
 		deleteObjectInput := &s3.DeleteObjectInput{
 			Bucket: aws.String(a.bucketFromKey(objectKey)),
 			Key:    aws.String(objectKey),
@@ -341,6 +344,7 @@ func (a *S3ObjectClient) DeleteObject(ctx context.Context, objectKey string) err
 		_, err := a.S3.DeleteObjectWithContext(ctx, deleteObjectInput)
 		return err
 	})
+	return chunk.WrapObjectError(chunk.OpDeleteObject, objectKey, err)
 }
 
 // bucketFromKey maps a key to a bucket name
@@ -367,7 +371,7 @@ func (a *S3ObjectClient) GetObject(ctx context.Context, objectKey string) (io.Re
 	err := ctx.Err()
 	for retries.Ongoing() {
 		if ctx.Err() != nil {
-			return nil, 0, errors.Wrap(ctx.Err(), "ctx related error during s3 getObject")
+			return nil, 0, chunk.WrapObjectError(chunk.OpGetObject, objectKey, errors.Wrap(ctx.Err(), "ctx related error during s3 getObject"))
 		}
 		err = instrument.CollectedRequest(ctx, "S3.GetObject", s3RequestDuration, instrument.ErrorCode, func(ctx context.Context) error {
 			var requestErr error
@@ -386,12 +390,32 @@ func (a *S3ObjectClient) GetObject(ctx context.Context, objectKey string) (io.Re
 		}
 		retries.Wait()
 	}
-	return nil, 0, errors.Wrap(err, "failed to get s3 object")
+	return nil, 0, chunk.WrapObjectError(chunk.OpGetObject, objectKey, errors.Wrap(err, "failed to get s3 object"))
+}
+
+// ObjectExists reports whether the object key exists in the configured S3 bucket,
+// using a HEAD request so the object isn't downloaded.
+func (a *S3ObjectClient) ObjectExists(ctx context.Context, objectKey string) (bool, error) {
+	err := instrument.CollectedRequest(ctx, "S3.HeadObject", s3RequestDuration, instrument.ErrorCode, func(ctx context.Context) error {
+		_, err := a.S3.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(a.bucketFromKey(objectKey)),
+			Key:    aws.String(objectKey),
+		})
+		return err
+	})
+	if err != nil {
+		// HEAD responses have no body, so S3 reports missing objects with a bare 404 instead of NoSuchKey.
+		if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusNotFound {
+			return false, nil
+		}
+		return false, chunk.WrapObjectError(chunk.OpGetAttributes, objectKey, err)
+	}
+	return true, nil
 }
 
 // PutObject into the store
 func (a *S3ObjectClient) PutObject(ctx context.Context, objectKey string, object io.ReadSeeker) error {
-	return instrument.CollectedRequest(ctx, "S3.PutObject", s3RequestDuration, instrument.ErrorCode, func(ctx context.Context) error {
+	err := instrument.CollectedRequest(ctx, "S3.PutObject", s3RequestDuration, instrument.ErrorCode, func(ctx context.Context) error {
 		putObjectInput := &s3.PutObjectInput{
 			Body:   object,
 			Bucket: aws.String(a.bucketFromKey(objectKey)),
@@ -407,6 +431,58 @@ func (a *S3ObjectClient) PutObject(ctx context.Context, objectKey string, object
 		_, err := a.S3.PutObjectWithContext(ctx, putObjectInput)
 		return err
 	})
+	return chunk.WrapObjectError(chunk.OpPutObject, objectKey, err)
+}
+
+// CopyObject copies the object at srcKey to dstKey with a server-side copy, preserving its metadata.
+// Backends not implementing server-side copies fall back to downloading and uploading the object.
+func (a *S3ObjectClient) CopyObject(ctx context.Context, srcKey, dstKey string) error {
+	err := instrument.CollectedRequest(ctx, "S3.CopyObject", s3RequestDuration, instrument.ErrorCode, func(ctx context.Context) error {
+		copyObjectInput := &s3.CopyObjectInput{
+			Bucket:            aws.String(a.bucketFromKey(dstKey)),
+			Key:               aws.String(dstKey),
+			CopySource:        aws.String(copySource(a.bucketFromKey(srcKey), srcKey)),
+			MetadataDirective: aws.String(s3.MetadataDirectiveCopy),
+		}
+
+		if a.sseConfig != nil {
+			copyObjectInput.ServerSideEncryption = aws.String(a.sseConfig.ServerSideEncryption)
+			copyObjectInput.SSEKMSKeyId = a.sseConfig.KMSKeyID
+			copyObjectInput.SSEKMSEncryptionContext = a.sseConfig.KMSEncryptionContext
+		}
+
+		_, err := a.S3.CopyObjectWithContext(ctx, copyObjectInput)
+		return err
+	})
+	if aerr, ok := errors.Cause(err).(awserr.Error); ok && aerr.Code() == "NotImplemented" {
+		return a.streamCopyObject(ctx, srcKey, dstKey)
+	}
+	return chunk.WrapObjectError(chunk.OpPutObject, dstKey, err)
+}
+
+// copySource returns the CopySource of the object at key in bucket, escaping the
+// segments of the key but not the slashes separating them.
+func copySource(bucket, key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return bucket + "/" + strings.Join(segments, "/")
+}
+
+// streamCopyObject copies the object at srcKey to dstKey through the client.
+func (a *S3ObjectClient) streamCopyObject(ctx context.Context, srcKey, dstKey string) error {
+	rc, _, err := a.GetObject(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	buf, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return chunk.WrapObjectError(chunk.OpGetObject, srcKey, err)
+	}
+	return a.PutObject(ctx, dstKey, bytes.NewReader(buf))
 }
 
 // List implements chunk.ObjectClient.
@@ -453,7 +529,7 @@ func (a *S3ObjectClient) List(ctx context.Context, prefix, delimiter string) ([]
 			return nil
 		})
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, chunk.WrapObjectError(chunk.OpList, prefix, err)
 		}
 	}
 
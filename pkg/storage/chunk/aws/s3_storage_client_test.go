@@ -12,6 +12,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/grafana/dskit/backoff"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -148,3 +152,94 @@ func Test_Hedging(t *testing.T) {
 		})
 	}
 }
+
+type failingHeadS3 struct {
+	*mockS3
+}
+
+func (m failingHeadS3) HeadObjectWithContext(_ aws.Context, _ *s3.HeadObjectInput, _ ...request.Option) (*s3.HeadObjectOutput, error) {
+	return nil, awserr.NewRequestFailure(awserr.New("Forbidden", "Forbidden", nil), http.StatusForbidden, "")
+}
+
+func TestS3ObjectClient_ObjectExists(t *testing.T) {
+	mock := newMockS3()
+	c := &S3ObjectClient{S3: mock, hedgedS3: mock}
+	require.NoError(t, c.PutObject(context.Background(), "present", bytes.NewReader([]byte("data"))))
+
+	exists, err := c.ObjectExists(context.Background(), "present")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	exists, err = c.ObjectExists(context.Background(), "absent")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	c.S3 = failingHeadS3{mock}
+	_, err = c.ObjectExists(context.Background(), "present")
+	require.Error(t, err)
+	require.False(t, c.IsObjectNotFoundErr(err))
+}
+
+// countingS3 counts the object reads and writes, optionally without support for server-side copies.
+type countingS3 struct {
+	*mockS3
+	noCopy      bool
+	gets, puts  int
+	copySources []string
+}
+
+func (m *countingS3) GetObjectWithContext(ctx aws.Context, req *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error) {
+	m.gets++
+	return m.mockS3.GetObjectWithContext(ctx, req, opts...)
+}
+
+func (m *countingS3) PutObjectWithContext(ctx aws.Context, req *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error) {
+	m.puts++
+	return m.mockS3.PutObjectWithContext(ctx, req, opts...)
+}
+
+func (m *countingS3) CopyObjectWithContext(ctx aws.Context, req *s3.CopyObjectInput, opts ...request.Option) (*s3.CopyObjectOutput, error) {
+	m.copySources = append(m.copySources, *req.CopySource)
+	if m.noCopy {
+		return nil, awserr.NewRequestFailure(awserr.New("NotImplemented", "A header you provided implies functionality that is not implemented", nil), http.StatusNotImplemented, "")
+	}
+	return m.mockS3.CopyObjectWithContext(ctx, req, opts...)
+}
+
+func TestS3ObjectClient_CopyObject(t *testing.T) {
+	for _, tc := range []struct {
+		name                       string
+		noCopy                     bool
+		expectedGets, expectedPuts int
+	}{
+		{name: "server-side copy"},
+		{name: "stream copy fallback", noCopy: true, expectedGets: 1, expectedPuts: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &countingS3{mockS3: newMockS3(), noCopy: tc.noCopy}
+			c := &S3ObjectClient{S3: mock, hedgedS3: mock, bucketNames: []string{"bucket"}}
+			mock.objects["src/chunk"] = []byte("data")
+
+			require.NoError(t, c.CopyObject(context.Background(), "src/chunk", "dst/chunk"))
+			require.Equal(t, []byte("data"), mock.objects["dst/chunk"])
+			require.Equal(t, []byte("data"), mock.objects["src/chunk"])
+			require.Equal(t, tc.expectedGets, mock.gets)
+			require.Equal(t, tc.expectedPuts, mock.puts)
+		})
+	}
+
+	mock := newMockS3()
+	c := &S3ObjectClient{S3: mock, hedgedS3: mock, bucketNames: []string{"bucket"}}
+	err := c.CopyObject(context.Background(), "missing", "dst/chunk")
+	require.True(t, c.IsObjectNotFoundErr(err))
+}
+
+func TestS3ObjectClient_CopyObjectNestedKey(t *testing.T) {
+	mock := &countingS3{mockS3: newMockS3()}
+	c := &S3ObjectClient{S3: mock, hedgedS3: mock, bucketNames: []string{"bucket"}}
+	mock.objects["index/table 1/user?1/chunk"] = []byte("data")
+
+	require.NoError(t, c.CopyObject(context.Background(), "index/table 1/user?1/chunk", "dst/chunk"))
+	require.Equal(t, []string{"bucket/index/table%201/user%3F1/chunk"}, mock.copySources)
+	require.Equal(t, []byte("data"), mock.objects["dst/chunk"])
+}
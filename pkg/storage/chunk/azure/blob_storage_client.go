@@ -213,7 +213,7 @@ func (b *BlobStorage) GetObject(ctx context.Context, objectKey string) (io.ReadC
 	if err != nil {
 		// cancel the context if there is an error.
 		cancel()
-		return nil, 0, err
+		return nil, 0, chunk.WrapObjectError(chunk.OpGetObject, objectKey, err)
 	}
 	// else return a wrapped ReadCloser which cancels the context while closing the reader.
 	return chunk_util.NewReadCloserWithContextCancelFunc(rc, cancel), size, nil
@@ -235,7 +235,7 @@ func (b *BlobStorage) getObject(ctx context.Context, objectKey string) (rc io.Re
 }
 
 func (b *BlobStorage) PutObject(ctx context.Context, objectKey string, object io.ReadSeeker) error {
-	return instrument.CollectedRequest(ctx, "azure.PutObject", instrument.NewHistogramCollector(b.metrics.requestDuration), instrument.ErrorCode, func(ctx context.Context) error {
+	err := instrument.CollectedRequest(ctx, "azure.PutObject", instrument.NewHistogramCollector(b.metrics.requestDuration), instrument.ErrorCode, func(ctx context.Context) error {
 		blockBlobURL, err := b.getBlobURL(objectKey, false)
 		if err != nil {
 			return err
@@ -248,6 +248,7 @@ func (b *BlobStorage) PutObject(ctx context.Context, objectKey string, object io
 
 		return err
 	})
+	return chunk.WrapObjectError(chunk.OpPutObject, objectKey, err)
 }
 
 func (b *BlobStorage) getBlobURL(blobID string, hedging bool) (azblob.BlockBlobURL, error) {
@@ -376,7 +377,7 @@ func (b *BlobStorage) List(ctx context.Context, prefix, delimiter string) ([]chu
 
 	for marker := (azblob.Marker{}); marker.NotDone(); {
 		if ctx.Err() != nil {
-			return nil, nil, ctx.Err()
+			return nil, nil, chunk.WrapObjectError(chunk.OpList, prefix, ctx.Err())
 		}
 
 		err := instrument.CollectedRequest(ctx, "azure.List", instrument.NewHistogramCollector(b.metrics.requestDuration), instrument.ErrorCode, func(ctx context.Context) error {
@@ -403,7 +404,7 @@ func (b *BlobStorage) List(ctx context.Context, prefix, delimiter string) ([]chu
 			return nil
 		})
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, chunk.WrapObjectError(chunk.OpList, prefix, err)
 		}
 
 	}
@@ -412,7 +413,7 @@ func (b *BlobStorage) List(ctx context.Context, prefix, delimiter string) ([]chu
 }
 
 func (b *BlobStorage) DeleteObject(ctx context.Context, blobID string) error {
-	return instrument.CollectedRequest(ctx, "azure.DeleteObject", instrument.NewHistogramCollector(b.metrics.requestDuration), instrument.ErrorCode, func(ctx context.Context) error {
+	err := instrument.CollectedRequest(ctx, "azure.DeleteObject", instrument.NewHistogramCollector(b.metrics.requestDuration), instrument.ErrorCode, func(ctx context.Context) error {
 		blockBlobURL, err := b.getBlobURL(blobID, false)
 		if err != nil {
 			return err
@@ -421,6 +422,7 @@ func (b *BlobStorage) DeleteObject(ctx context.Context, blobID string) error {
 		_, err = blockBlobURL.Delete(ctx, azblob.DeleteSnapshotsOptionInclude, azblob.BlobAccessConditions{})
 		return err
 	})
+	return chunk.WrapObjectError(chunk.OpDeleteObject, blobID, err)
 }
 
 // Validate the config.
@@ -3,29 +3,61 @@ package gcp
 import (
 	"context"
 	"flag"
+	"fmt"
 	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/storage"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/dskit/flagext"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 
 	"github.com/pao214/loki/pkg/storage/chunk"
 	"github.com/pao214/loki/pkg/storage/chunk/hedging"
 	"github.com/pao214/loki/pkg/storage/chunk/util"
+	"github.com/pao214/loki/pkg/tenant"
 )
 
+const (
+	// ListSortOrderLexical keeps the List results in the lexical order returned by GCS.
+	ListSortOrderLexical = ""
+	// ListSortOrderModifiedAsc sorts the List results by modification time, oldest first.
+	ListSortOrderModifiedAsc = "modified-asc"
+	// ListSortOrderModifiedDesc sorts the List results by modification time, newest first.
+	ListSortOrderModifiedDesc = "modified-desc"
+)
+
+// maxObjectsExistConcurrency is the number of concurrent attribute requests sent by ObjectsExist.
+const maxObjectsExistConcurrency = 16
+
+// ErrPreconditionFailed is returned by PutObjectIfGenerationMatch when the generation of the object doesn't match.
+var ErrPreconditionFailed = errors.New("object generation doesn't match")
+
+var supportedListSortOrders = []string{ListSortOrderLexical, ListSortOrderModifiedAsc, ListSortOrderModifiedDesc}
+
 type ClientFactory func(ctx context.Context, opts ...option.ClientOption) (*storage.Client, error)
 
 type GCSObjectClient struct {
 	cfg GCSConfig
 
+	defaultClient *storage.Client
+	getsClient    *storage.Client
 	defaultBucket *storage.BucketHandle
 	getsBuckets   *storage.BucketHandle
 }
 
+// defaultDialTimeout is the dial timeout of clients built from a config not setting one.
+const defaultDialTimeout = 30 * time.Second
+
 // GCSConfig is config for the GCS Chunk Client.
 type GCSConfig struct {
 	BucketName       string        `yaml:"bucket_name"`
@@ -33,6 +65,18 @@ type GCSConfig struct {
 	RequestTimeout   time.Duration `yaml:"request_timeout"`
 	EnableOpenCensus bool          `yaml:"enable_opencensus"`
 	EnableHTTP2      bool          `yaml:"enable_http2"`
+	ListSortOrder    string        `yaml:"list_sort_order"`
+	ListFallback     bool          `yaml:"list_fallback"`
+	DialTimeout      time.Duration `yaml:"dial_timeout"`
+	KeepAlive        time.Duration `yaml:"keep_alive"`
+
+	// TenantBuckets maps tenant IDs to the bucket holding their objects.
+	// Tenants without an entry use BucketName.
+	TenantBuckets map[string]string `yaml:"tenant_buckets"`
+
+	// ServiceAccountKeyFile and ServiceAccountKey override the Application Default Credentials.
+	ServiceAccountKeyFile string         `yaml:"service_account_key_file"`
+	ServiceAccountKey     flagext.Secret `yaml:"service_account_key"`
 
 	Insecure bool `yaml:"-"`
 }
@@ -49,6 +93,42 @@ func (cfg *GCSConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.DurationVar(&cfg.RequestTimeout, prefix+"gcs.request-timeout", 0, "The duration after which the requests to GCS should be timed out.")
 	f.BoolVar(&cfg.EnableOpenCensus, prefix+"gcs.enable-opencensus", true, "Enable OpenCensus (OC) instrumentation for all requests.")
 	f.BoolVar(&cfg.EnableHTTP2, prefix+"gcs.enable-http2", true, "Enable HTTP2 connections.")
+	f.StringVar(&cfg.ListSortOrder, prefix+"gcs.list-sort-order", ListSortOrderLexical, fmt.Sprintf("The order of the objects returned by List. Empty keeps the lexical order returned by GCS. Supported values are: %s.", strings.Join(supportedListSortOrders[1:], ", ")))
+	f.BoolVar(&cfg.ListFallback, prefix+"gcs.list-fallback", false, "Retry listing without the delimiter when a List with a delimiter returns nothing but objects exist under the prefix. This can double the list requests for empty prefixes.")
+	f.DurationVar(&cfg.DialTimeout, prefix+"gcs.dial-timeout", defaultDialTimeout, "Maximum time to wait for a TCP connection to GCS to be established. 0 uses the default of 30s, a negative value disables the timeout.")
+	f.DurationVar(&cfg.KeepAlive, prefix+"gcs.keep-alive", 30*time.Second, "Interval between TCP keep-alive probes on connections to GCS, used to detect dead connections. 0 uses the Go default, a negative value disables keep-alives.")
+	f.StringVar(&cfg.ServiceAccountKeyFile, prefix+"gcs.service-account-key-file", "", "Path to the service account key file to authenticate to GCS with, instead of the Application Default Credentials.")
+	f.Var(&cfg.ServiceAccountKey, prefix+"gcs.service-account-key", "Service account key JSON to authenticate to GCS with, instead of the Application Default Credentials.")
+}
+
+// Validate config and returns error on failure
+func (cfg *GCSConfig) Validate() error {
+	if cfg.ServiceAccountKeyFile != "" && cfg.ServiceAccountKey.Value != "" {
+		return errors.New("only one of service account key file and service account key can be configured")
+	}
+	if cfg.ServiceAccountKeyFile != "" {
+		if _, err := os.Stat(cfg.ServiceAccountKeyFile); err != nil {
+			return errors.Wrap(err, "invalid service account key file")
+		}
+	}
+	for _, order := range supportedListSortOrders {
+		if cfg.ListSortOrder == order {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported list sort order: %s", cfg.ListSortOrder)
+}
+
+// credentialsOptions returns the options overriding the Application Default Credentials, if configured.
+func (cfg *GCSConfig) credentialsOptions() []option.ClientOption {
+	switch {
+	case cfg.ServiceAccountKeyFile != "":
+		return []option.ClientOption{option.WithCredentialsFile(cfg.ServiceAccountKeyFile)}
+	case cfg.ServiceAccountKey.Value != "":
+		return []option.ClientOption{option.WithCredentialsJSON([]byte(cfg.ServiceAccountKey.Value))}
+	default:
+		return nil
+	}
 }
 
 // NewGCSObjectClient makes a new chunk.Client that writes chunks to GCS.
@@ -57,27 +137,35 @@ func NewGCSObjectClient(ctx context.Context, cfg GCSConfig, hedgingCfg hedging.C
 }
 
 func newGCSObjectClient(ctx context.Context, cfg GCSConfig, hedgingCfg hedging.Config, clientFactory ClientFactory) (*GCSObjectClient, error) {
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = defaultDialTimeout
+	}
+
 	// Disabling http2 and hedging is not allowed for POST/LIST/DELETE requests.
 	// This is because there's no benefit for these requests.
 	// Those requests are handled by the default bucket handle.
-	bucket, err := newBucketHandle(ctx, cfg, hedgingCfg, true, false, clientFactory)
+	client, err := newStorageClient(ctx, cfg, hedgingCfg, true, false, clientFactory)
 	if err != nil {
 		return nil, err
 	}
-	getsBucket, err := newBucketHandle(ctx, cfg, hedgingCfg, cfg.EnableHTTP2, true, clientFactory)
+	getsClient, err := newStorageClient(ctx, cfg, hedgingCfg, cfg.EnableHTTP2, true, clientFactory)
 	if err != nil {
 		return nil, err
 	}
 	return &GCSObjectClient{
 		cfg:           cfg,
-		defaultBucket: bucket,
-		getsBuckets:   getsBucket,
+		defaultClient: client,
+		getsClient:    getsClient,
+		defaultBucket: client.Bucket(cfg.BucketName),
+		getsBuckets:   getsClient.Bucket(cfg.BucketName),
 	}, nil
 }
 
-func newBucketHandle(ctx context.Context, cfg GCSConfig, hedgingCfg hedging.Config, enableHTTP2, hedging bool, clientFactory ClientFactory) (*storage.BucketHandle, error) {
+func newStorageClient(ctx context.Context, cfg GCSConfig, hedgingCfg hedging.Config, enableHTTP2, hedging bool, clientFactory ClientFactory) (*storage.Client, error) {
 	var opts []option.ClientOption
-	httpClient, err := gcsInstrumentation(ctx, storage.ScopeReadWrite, cfg.Insecure, enableHTTP2)
+	// the storage client ignores credentials options along with a custom HTTP client,
+	// so they are used to authenticate the requests of the custom transport instead.
+	httpClient, err := gcsInstrumentation(ctx, storage.ScopeReadWrite, cfg.Insecure, enableHTTP2, gcsDialer(cfg), cfg.credentialsOptions()...)
 	if err != nil {
 		return nil, err
 	}
@@ -94,36 +182,84 @@ func newBucketHandle(ctx context.Context, cfg GCSConfig, hedgingCfg hedging.Conf
 		opts = append(opts, option.WithTelemetryDisabled())
 	}
 
-	client, err := clientFactory(ctx, opts...)
-	if err != nil {
-		return nil, err
-	}
+	return clientFactory(ctx, opts...)
+}
 
-	return client.Bucket(cfg.BucketName), nil
+// gcsDialer returns the dialer used for connections to GCS.
+func gcsDialer(cfg GCSConfig) *net.Dialer {
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: cfg.KeepAlive,
+	}
+	// a negative timeout disables it, the dialer having no timeout when zero
+	if dialer.Timeout < 0 {
+		dialer.Timeout = 0
+	}
+	return dialer
 }
 
 func (s *GCSObjectClient) Stop() {
 }
 
+// bucket returns the bucket handle for the tenant in ctx, used for all requests but gets.
+func (s *GCSObjectClient) bucket(ctx context.Context) *storage.BucketHandle {
+	if name, ok := s.tenantBucket(ctx); ok {
+		return s.defaultClient.Bucket(name)
+	}
+	return s.defaultBucket
+}
+
+// getsBucket returns the bucket handle for the tenant in ctx, used for gets.
+func (s *GCSObjectClient) getsBucket(ctx context.Context) *storage.BucketHandle {
+	if name, ok := s.tenantBucket(ctx); ok {
+		return s.getsClient.Bucket(name)
+	}
+	return s.getsBuckets
+}
+
+// tenantBucket returns the bucket the tenant in ctx is mapped to, if any.
+func (s *GCSObjectClient) tenantBucket(ctx context.Context) (string, bool) {
+	if len(s.cfg.TenantBuckets) == 0 {
+		return "", false
+	}
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return "", false
+	}
+	name, ok := s.cfg.TenantBuckets[userID]
+	return name, ok
+}
+
 // GetObject returns a reader and the size for the specified object key from the configured GCS bucket.
 func (s *GCSObjectClient) GetObject(ctx context.Context, objectKey string) (io.ReadCloser, int64, error) {
+	return s.GetObjectGeneration(ctx, objectKey, 0)
+}
+
+// GetObjectGeneration is like GetObject but reads the given generation of the object.
+// A generation <= 0 reads the latest generation.
+func (s *GCSObjectClient) GetObjectGeneration(ctx context.Context, objectKey string, generation int64) (io.ReadCloser, int64, error) {
 	var cancel context.CancelFunc = func() {}
 	if s.cfg.RequestTimeout > 0 {
 		ctx, cancel = context.WithTimeout(ctx, s.cfg.RequestTimeout)
 	}
 
-	rc, size, err := s.getObject(ctx, objectKey)
+	rc, size, err := s.getObject(ctx, objectKey, generation)
 	if err != nil {
 		// cancel the context if there is an error.
 		cancel()
-		return nil, 0, err
+		return nil, 0, chunk.WrapObjectError(chunk.OpGetObject, objectKey, err)
 	}
 	// else return a wrapped ReadCloser which cancels the context while closing the reader.
 	return util.NewReadCloserWithContextCancelFunc(rc, cancel), size, nil
 }
 
-func (s *GCSObjectClient) getObject(ctx context.Context, objectKey string) (rc io.ReadCloser, size int64, err error) {
-	reader, err := s.getsBuckets.Object(objectKey).NewReader(ctx)
+func (s *GCSObjectClient) getObject(ctx context.Context, objectKey string, generation int64) (rc io.ReadCloser, size int64, err error) {
+	object := s.getsBucket(ctx).Object(objectKey)
+	if generation > 0 {
+		object = object.Generation(generation)
+	}
+
+	reader, err := object.NewReader(ctx)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -131,9 +267,94 @@ func (s *GCSObjectClient) getObject(ctx context.Context, objectKey string) (rc i
 	return reader, reader.Attrs.Size, nil
 }
 
+// ObjectsExist reports which of the given keys exist in the configured GCS bucket,
+// checking the object attributes of up to maxObjectsExistConcurrency keys at a time.
+func (s *GCSObjectClient) ObjectsExist(ctx context.Context, keys []string) (map[string]bool, error) {
+	exists := make([]bool, len(keys))
+	err := concurrency.ForEachJob(ctx, len(keys), maxObjectsExistConcurrency, func(ctx context.Context, idx int) error {
+		ok, err := s.objectExists(ctx, keys[idx])
+		if err != nil {
+			return chunk.WrapObjectError(chunk.OpGetAttributes, keys[idx], err)
+		}
+		exists[idx] = ok
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool, len(keys))
+	for i, key := range keys {
+		result[key] = exists[i]
+	}
+	return result, nil
+}
+
+func (s *GCSObjectClient) objectExists(ctx context.Context, objectKey string) (bool, error) {
+	if s.cfg.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.RequestTimeout)
+		defer cancel()
+	}
+
+	_, err := s.bucket(ctx).Object(objectKey).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ObjectAttributes holds the metadata of an object.
+type ObjectAttributes struct {
+	Size         int64
+	Updated      time.Time
+	StorageClass string
+	Etag         string
+	Generation   int64
+}
+
+// ObjectAttributes returns the metadata of the specified object key from the configured GCS bucket, without reading it.
+// A missing object returns an error satisfying IsObjectNotFoundErr.
+func (s *GCSObjectClient) ObjectAttributes(ctx context.Context, objectKey string) (ObjectAttributes, error) {
+	if s.cfg.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.RequestTimeout)
+		defer cancel()
+	}
+
+	attrs, err := s.bucket(ctx).Object(objectKey).Attrs(ctx)
+	if err != nil {
+		return ObjectAttributes{}, chunk.WrapObjectError(chunk.OpGetAttributes, objectKey, err)
+	}
+	return ObjectAttributes{
+		Size:         attrs.Size,
+		Updated:      attrs.Updated,
+		StorageClass: attrs.StorageClass,
+		Etag:         attrs.Etag,
+		Generation:   attrs.Generation,
+	}, nil
+}
+
 // PutObject puts the specified bytes into the configured GCS bucket at the provided key
 func (s *GCSObjectClient) PutObject(ctx context.Context, objectKey string, object io.ReadSeeker) error {
-	writer := s.defaultBucket.Object(objectKey).NewWriter(ctx)
+	return s.putObject(ctx, s.bucket(ctx).Object(objectKey), object)
+}
+
+// PutObjectIfGenerationMatch puts the specified bytes into the configured GCS bucket only if the
+// current generation of the object matches, so that concurrent writes don't clobber each other.
+// A generation of 0 only puts the object if it doesn't exist yet.
+// ErrPreconditionFailed is returned when the generation doesn't match.
+func (s *GCSObjectClient) PutObjectIfGenerationMatch(ctx context.Context, objectKey string, generation int64, object io.ReadSeeker) error {
+	conds := storage.Conditions{GenerationMatch: generation}
+	if generation == 0 {
+		conds = storage.Conditions{DoesNotExist: true}
+	}
+	return s.putObject(ctx, s.bucket(ctx).Object(objectKey).If(conds), object)
+}
+
+func (s *GCSObjectClient) putObject(ctx context.Context, handle *storage.ObjectHandle, object io.ReadSeeker) error {
+	objectKey := handle.ObjectName()
+	writer := handle.NewWriter(ctx)
 	// Default GCSChunkSize is 8M and for each call, 8M is allocated xD
 	// By setting it to 0, we just upload the object in a single a request
 	// which should work for our chunk sizes.
@@ -141,13 +362,46 @@ func (s *GCSObjectClient) PutObject(ctx context.Context, objectKey string, objec
 
 	if _, err := io.Copy(writer, object); err != nil {
 		_ = writer.Close()
-		return err
+		return chunk.WrapObjectError(chunk.OpPutObject, objectKey, err)
 	}
-	return writer.Close()
+
+	err := writer.Close()
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+		err = ErrPreconditionFailed
+	}
+	return chunk.WrapObjectError(chunk.OpPutObject, objectKey, err)
 }
 
 // List implements chunk.ObjectClient.
 func (s *GCSObjectClient) List(ctx context.Context, prefix, delimiter string) ([]chunk.StorageObject, []chunk.StorageCommonPrefix, error) {
+	storageObjects, commonPrefixes, err := s.list(ctx, prefix, delimiter)
+	if err != nil {
+		return nil, nil, chunk.WrapObjectError(chunk.OpList, prefix, err)
+	}
+
+	if s.cfg.ListFallback && delimiter != "" && len(storageObjects) == 0 && len(commonPrefixes) == 0 {
+		storageObjects, commonPrefixes, err = s.listWithoutDelimiter(ctx, prefix, delimiter)
+		if err != nil {
+			return nil, nil, chunk.WrapObjectError(chunk.OpList, prefix, err)
+		}
+	}
+
+	switch s.cfg.ListSortOrder {
+	case ListSortOrderModifiedAsc:
+		sort.SliceStable(storageObjects, func(i, j int) bool {
+			return storageObjects[i].ModifiedAt.Before(storageObjects[j].ModifiedAt)
+		})
+	case ListSortOrderModifiedDesc:
+		sort.SliceStable(storageObjects, func(i, j int) bool {
+			return storageObjects[i].ModifiedAt.After(storageObjects[j].ModifiedAt)
+		})
+	}
+
+	return storageObjects, commonPrefixes, nil
+}
+
+func (s *GCSObjectClient) list(ctx context.Context, prefix, delimiter string) ([]chunk.StorageObject, []chunk.StorageCommonPrefix, error) {
 	var storageObjects []chunk.StorageObject
 	var commonPrefixes []chunk.StorageCommonPrefix
 	q := &storage.Query{Prefix: prefix, Delimiter: delimiter}
@@ -163,7 +417,7 @@ func (s *GCSObjectClient) List(ctx context.Context, prefix, delimiter string) ([
 		}
 	}
 
-	iter := s.defaultBucket.Objects(ctx, q)
+	iter := s.bucket(ctx).Objects(ctx, q)
 	for {
 		if ctx.Err() != nil {
 			return nil, nil, ctx.Err()
@@ -192,11 +446,40 @@ func (s *GCSObjectClient) List(ctx context.Context, prefix, delimiter string) ([
 	return storageObjects, commonPrefixes, nil
 }
 
+// listWithoutDelimiter lists all the objects under the prefix and splits them into
+// objects and common prefixes the same way a List with the delimiter would.
+func (s *GCSObjectClient) listWithoutDelimiter(ctx context.Context, prefix, delimiter string) ([]chunk.StorageObject, []chunk.StorageCommonPrefix, error) {
+	allObjects, _, err := s.list(ctx, prefix, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var storageObjects []chunk.StorageObject
+	var commonPrefixes []chunk.StorageCommonPrefix
+	seenPrefixes := map[string]struct{}{}
+	for _, object := range allObjects {
+		idx := strings.Index(strings.TrimPrefix(object.Key, prefix), delimiter)
+		if idx < 0 {
+			storageObjects = append(storageObjects, object)
+			continue
+		}
+
+		commonPrefix := object.Key[:len(prefix)+idx+len(delimiter)]
+		if _, ok := seenPrefixes[commonPrefix]; ok {
+			continue
+		}
+		seenPrefixes[commonPrefix] = struct{}{}
+		commonPrefixes = append(commonPrefixes, chunk.StorageCommonPrefix(commonPrefix))
+	}
+
+	return storageObjects, commonPrefixes, nil
+}
+
 // DeleteObject deletes the specified object key from the configured GCS bucket.
 func (s *GCSObjectClient) DeleteObject(ctx context.Context, objectKey string) error {
-	err := s.defaultBucket.Object(objectKey).Delete(ctx)
+	err := s.bucket(ctx).Object(objectKey).Delete(ctx)
 	if err != nil {
-		return err
+		return chunk.WrapObjectError(chunk.OpDeleteObject, objectKey, err)
 	}
 
 	return nil
@@ -206,3 +489,9 @@ func (s *GCSObjectClient) DeleteObject(ctx context.Context, objectKey string) er
 func (s *GCSObjectClient) IsObjectNotFoundErr(err error) bool {
 	return errors.Is(err, storage.ErrObjectNotExist)
 }
+
+// IsPreconditionFailedErr returns true if error means that the generation of the object didn't match.
+// Relevant to PutObjectIfGenerationMatch.
+func (s *GCSObjectClient) IsPreconditionFailedErr(err error) bool {
+	return errors.Is(err, ErrPreconditionFailed)
+}
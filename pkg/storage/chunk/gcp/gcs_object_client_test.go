@@ -3,16 +3,33 @@ package gcp
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"cloud.google.com/go/storage"
+	"github.com/grafana/dskit/flagext"
 	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
 	"go.uber.org/atomic"
 	"google.golang.org/api/option"
 
+	"github.com/pao214/loki/pkg/storage/chunk"
 	"github.com/pao214/loki/pkg/storage/chunk/hedging"
 )
 
@@ -78,6 +95,318 @@ func Test_Hedging(t *testing.T) {
 	}
 }
 
+func TestGCSObjectClient_GetObjectGeneration(t *testing.T) {
+	// two writes to the same object, keyed by their generation.
+	generations := map[string]string{
+		"1": "first write",
+		"2": "second write",
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gen := r.URL.Query().Get("generation")
+		if gen == "" {
+			gen = "2"
+		}
+		data, ok := generations[gen]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("X-Goog-Generation", gen)
+		_, _ = w.Write([]byte(data))
+	}))
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	c, err := newGCSObjectClient(context.Background(), GCSConfig{
+		BucketName: "test-bucket",
+		Insecure:   true,
+	}, hedging.Config{}, func(ctx context.Context, opts ...option.ClientOption) (*storage.Client, error) {
+		opts = append(opts, option.WithEndpoint(server.URL))
+		opts = append(opts, option.WithoutAuthentication())
+		return storage.NewClient(ctx, opts...)
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		generation int64
+		expected   string
+	}{
+		{generation: 0, expected: "second write"},
+		{generation: 1, expected: "first write"},
+		{generation: 2, expected: "second write"},
+	} {
+		rc, size, err := c.GetObjectGeneration(context.Background(), "foo", tc.generation)
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		require.Equal(t, tc.expected, string(data))
+		require.Equal(t, int64(len(tc.expected)), size)
+	}
+
+	_, _, err = c.GetObjectGeneration(context.Background(), "foo", 3)
+	require.True(t, c.IsObjectNotFoundErr(err))
+}
+
+func TestGCSObjectClient_ObjectsExist(t *testing.T) {
+	present := map[string]bool{"chunks/a": true, "chunks/c": true}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// attributes are read from .../b/<bucket>/o/<object>.
+		key, err := url.PathUnescape(strings.SplitN(r.URL.EscapedPath(), "/b/test-bucket/o/", 2)[1])
+		require.NoError(t, err)
+		if key == "forbidden" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if !present[key] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"name": %q}`, key)))
+	}))
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	c, err := newGCSObjectClient(context.Background(), GCSConfig{
+		BucketName:     "test-bucket",
+		Insecure:       true,
+		RequestTimeout: time.Second,
+	}, hedging.Config{}, func(ctx context.Context, opts ...option.ClientOption) (*storage.Client, error) {
+		opts = append(opts, option.WithEndpoint(server.URL))
+		opts = append(opts, option.WithoutAuthentication())
+		return storage.NewClient(ctx, opts...)
+	})
+	require.NoError(t, err)
+
+	exists, err := c.ObjectsExist(context.Background(), []string{"chunks/a", "chunks/b", "chunks/c", "chunks/d"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{
+		"chunks/a": true,
+		"chunks/b": false,
+		"chunks/c": true,
+		"chunks/d": false,
+	}, exists)
+
+	exists, err = c.ObjectsExist(context.Background(), nil)
+	require.NoError(t, err)
+	require.Empty(t, exists)
+
+	_, err = c.ObjectsExist(context.Background(), []string{"chunks/a", "forbidden"})
+	var objectErr *chunk.ObjectError
+	require.ErrorAs(t, err, &objectErr)
+	require.Equal(t, chunk.OpGetAttributes, objectErr.Op)
+	require.Equal(t, "forbidden", objectErr.Key)
+}
+
+func TestGCSObjectClient_ListSortOrder(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"items": [
+			{"name": "a", "updated": %q},
+			{"name": "b", "updated": %q},
+			{"name": "c", "updated": %q}
+		]}`,
+			now.Add(-time.Hour).Format(time.RFC3339),
+			now.Format(time.RFC3339),
+			now.Add(-2*time.Hour).Format(time.RFC3339),
+		)
+	}))
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	for _, tc := range []struct {
+		sortOrder    string
+		expectedKeys []string
+	}{
+		{sortOrder: ListSortOrderLexical, expectedKeys: []string{"a", "b", "c"}},
+		{sortOrder: ListSortOrderModifiedAsc, expectedKeys: []string{"c", "a", "b"}},
+		{sortOrder: ListSortOrderModifiedDesc, expectedKeys: []string{"b", "a", "c"}},
+	} {
+		t.Run(tc.sortOrder, func(t *testing.T) {
+			cfg := GCSConfig{
+				BucketName:    "test-bucket",
+				Insecure:      true,
+				ListSortOrder: tc.sortOrder,
+			}
+			require.NoError(t, cfg.Validate())
+
+			c, err := newGCSObjectClient(context.Background(), cfg, hedging.Config{}, func(ctx context.Context, opts ...option.ClientOption) (*storage.Client, error) {
+				opts = append(opts, option.WithEndpoint(server.URL))
+				opts = append(opts, option.WithoutAuthentication())
+				return storage.NewClient(ctx, opts...)
+			})
+			require.NoError(t, err)
+
+			objects, _, err := c.List(context.Background(), "", "")
+			require.NoError(t, err)
+
+			keys := make([]string, 0, len(objects))
+			for _, object := range objects {
+				keys = append(keys, object.Key)
+			}
+			require.Equal(t, tc.expectedKeys, keys)
+		})
+	}
+
+	require.Error(t, (&GCSConfig{ListSortOrder: "random"}).Validate())
+}
+
+func TestGCSObjectClient_ListFallback(t *testing.T) {
+	// reproduces a delimiter listing returning nothing although objects exist under the prefix.
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("delimiter") != "" {
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"items": [
+			{"name": "index/table_1/file1"},
+			{"name": "index/table_1/file2"},
+			{"name": "index/table_2/file1"},
+			{"name": "index/file3"}
+		]}`))
+	}))
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	for _, tc := range []struct {
+		name                   string
+		listFallback           bool
+		expectedObjects        []string
+		expectedCommonPrefixes []chunk.StorageCommonPrefix
+	}{
+		{
+			name:         "fallback disabled",
+			listFallback: false,
+		},
+		{
+			name:                   "fallback enabled",
+			listFallback:           true,
+			expectedObjects:        []string{"index/file3"},
+			expectedCommonPrefixes: []chunk.StorageCommonPrefix{"index/table_1/", "index/table_2/"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := newGCSObjectClient(context.Background(), GCSConfig{
+				BucketName:   "test-bucket",
+				Insecure:     true,
+				ListFallback: tc.listFallback,
+			}, hedging.Config{}, func(ctx context.Context, opts ...option.ClientOption) (*storage.Client, error) {
+				opts = append(opts, option.WithEndpoint(server.URL))
+				opts = append(opts, option.WithoutAuthentication())
+				return storage.NewClient(ctx, opts...)
+			})
+			require.NoError(t, err)
+
+			objects, commonPrefixes, err := c.List(context.Background(), "index/", "/")
+			require.NoError(t, err)
+
+			var keys []string
+			for _, object := range objects {
+				keys = append(keys, object.Key)
+			}
+			require.Equal(t, tc.expectedObjects, keys)
+			require.Equal(t, tc.expectedCommonPrefixes, commonPrefixes)
+		})
+	}
+}
+
+func TestGCSObjectClient_TenantBuckets(t *testing.T) {
+	// objects stored by the fake server, keyed by bucket and then object name.
+	var mtx sync.Mutex
+	buckets := map[string]map[string][]byte{}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		// uploads are multipart requests to /upload/storage/v1/b/<bucket>/o, the object data being the second part.
+		if r.Method == http.MethodPost {
+			bucket := strings.Split(strings.TrimPrefix(r.URL.Path, "/upload/storage/v1/b/"), "/")[0]
+			_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			require.NoError(t, err)
+			mr := multipart.NewReader(r.Body, params["boundary"])
+			_, err = mr.NextPart()
+			require.NoError(t, err)
+			part, err := mr.NextPart()
+			require.NoError(t, err)
+			data, err := io.ReadAll(part)
+			require.NoError(t, err)
+
+			if buckets[bucket] == nil {
+				buckets[bucket] = map[string][]byte{}
+			}
+			buckets[bucket][r.URL.Query().Get("name")] = data
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+
+		// reads are sent to /<bucket>/<object>.
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+		data, ok := buckets[parts[0]][parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	c, err := newGCSObjectClient(context.Background(), GCSConfig{
+		BucketName: "default-bucket",
+		Insecure:   true,
+		TenantBuckets: map[string]string{
+			"tenant-a": "bucket-a",
+			"tenant-b": "bucket-b",
+		},
+	}, hedging.Config{}, func(ctx context.Context, opts ...option.ClientOption) (*storage.Client, error) {
+		opts = append(opts, option.WithEndpoint(server.URL))
+		opts = append(opts, option.WithoutAuthentication())
+		return storage.NewClient(ctx, opts...)
+	})
+	require.NoError(t, err)
+
+	ctxA := user.InjectOrgID(context.Background(), "tenant-a")
+	ctxB := user.InjectOrgID(context.Background(), "tenant-b")
+	ctxOther := user.InjectOrgID(context.Background(), "tenant-c")
+
+	require.NoError(t, c.PutObject(ctxA, "foo", bytes.NewReader([]byte("data-a"))))
+	require.NoError(t, c.PutObject(ctxB, "foo", bytes.NewReader([]byte("data-b"))))
+	require.NoError(t, c.PutObject(ctxOther, "bar", bytes.NewReader([]byte("data-c"))))
+	require.NoError(t, c.PutObject(context.Background(), "baz", bytes.NewReader([]byte("data-default"))))
+
+	require.Equal(t, map[string]map[string][]byte{
+		"bucket-a":       {"foo": []byte("data-a")},
+		"bucket-b":       {"foo": []byte("data-b")},
+		"default-bucket": {"bar": []byte("data-c"), "baz": []byte("data-default")},
+	}, buckets)
+
+	for _, tc := range []struct {
+		ctx      context.Context
+		key      string
+		expected string
+	}{
+		{ctx: ctxA, key: "foo", expected: "data-a"},
+		{ctx: ctxB, key: "foo", expected: "data-b"},
+		{ctx: ctxOther, key: "baz", expected: "data-default"},
+	} {
+		rc, _, err := c.GetObject(tc.ctx, tc.key)
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		require.Equal(t, tc.expected, string(data))
+	}
+
+	// tenants can't read each other's objects
+	_, _, err = c.GetObject(ctxA, "bar")
+	require.True(t, c.IsObjectNotFoundErr(err))
+}
+
 func fakeServer(t *testing.T, returnIn time.Duration, counter *atomic.Int32) *httptest.Server {
 	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		counter.Inc()
@@ -89,3 +418,215 @@ func fakeServer(t *testing.T, returnIn time.Duration, counter *atomic.Int32) *ht
 
 	return server
 }
+
+func TestGCSDialer(t *testing.T) {
+	var defaults GCSConfig
+	flagext.DefaultValues(&defaults)
+
+	dialer := gcsDialer(defaults)
+	require.Equal(t, 30*time.Second, dialer.Timeout)
+	require.Equal(t, 30*time.Second, dialer.KeepAlive)
+
+	dialer = gcsDialer(GCSConfig{
+		DialTimeout: 5 * time.Second,
+		KeepAlive:   -1,
+	})
+	require.Equal(t, 5*time.Second, dialer.Timeout)
+	require.Equal(t, time.Duration(-1), dialer.KeepAlive)
+
+	dialer = gcsDialer(GCSConfig{DialTimeout: -1})
+	require.Equal(t, time.Duration(0), dialer.Timeout)
+}
+
+func TestGCSObjectClient_DefaultDialTimeout(t *testing.T) {
+	cfg := GCSConfig{
+		BucketName:        "test-bucket",
+		ServiceAccountKey: flagext.Secret{Value: `{"type": "service_account", "private_key": "fake", "client_email": "loki@fake-project.iam.gserviceaccount.com"}`},
+	}
+
+	client, err := newGCSObjectClient(context.Background(), cfg, hedging.Config{}, storage.NewClient)
+	require.NoError(t, err)
+	require.Equal(t, defaultDialTimeout, client.cfg.DialTimeout)
+	require.Equal(t, defaultDialTimeout, gcsDialer(client.cfg).Timeout)
+}
+
+func TestGCSObjectClient_ServiceAccountKeyFile(t *testing.T) {
+	// the service account key is exchanged for an access token with the token server.
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token": "test-token", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	var (
+		mtx            sync.Mutex
+		authorizations []string
+	)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		mtx.Unlock()
+
+		// reads are sent to /<bucket>/<object>, attributes are read from .../b/<bucket>/o/<object>.
+		if strings.Contains(r.URL.Path, "/b/test-bucket/o/") {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"name": "chunk"}`))
+			return
+		}
+		_, _ = w.Write([]byte("data"))
+	}))
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "fake-project",
+		"private_key_id": "fake",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})),
+		"client_email":   "loki@fake-project.iam.gserviceaccount.com",
+		"token_uri":      tokenServer.URL,
+	})
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "key.json")
+	require.NoError(t, os.WriteFile(keyFile, key, 0600))
+
+	cfg := GCSConfig{BucketName: "test-bucket", Insecure: true, ServiceAccountKeyFile: keyFile}
+	require.NoError(t, cfg.Validate())
+
+	c, err := newGCSObjectClient(context.Background(), cfg, hedging.Config{}, func(ctx context.Context, opts ...option.ClientOption) (*storage.Client, error) {
+		opts = append(opts, option.WithEndpoint(server.URL))
+		return storage.NewClient(ctx, opts...)
+	})
+	require.NoError(t, err)
+
+	// both the gets and the default clients authenticate their requests.
+	rc, _, err := c.GetObject(context.Background(), "chunk")
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	require.Equal(t, "data", string(data))
+	exists, err := c.ObjectsExist(context.Background(), []string{"chunk"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"chunk": true}, exists)
+
+	mtx.Lock()
+	require.Equal(t, []string{"Bearer test-token", "Bearer test-token"}, authorizations)
+	mtx.Unlock()
+
+	cfg.ServiceAccountKeyFile = filepath.Join(t.TempDir(), "missing.json")
+	require.Error(t, cfg.Validate())
+
+	cfg.ServiceAccountKeyFile = keyFile
+	cfg.ServiceAccountKey = flagext.Secret{Value: `{"type": "service_account"}`}
+	require.Error(t, cfg.Validate())
+}
+
+func TestGCSObjectClient_PutObjectIfGenerationMatch(t *testing.T) {
+	// the fake server holds a single object, its generation being bumped on every write.
+	var (
+		mtx        sync.Mutex
+		generation int64
+		data       []byte
+	)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		if match := r.URL.Query().Get("ifGenerationMatch"); match != fmt.Sprint(generation) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusPreconditionFailed)
+			_, _ = w.Write([]byte(`{"error": {"code": 412, "message": "conditionNotMet"}}`))
+			return
+		}
+
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		_, err = mr.NextPart()
+		require.NoError(t, err)
+		part, err := mr.NextPart()
+		require.NoError(t, err)
+		data, err = io.ReadAll(part)
+		require.NoError(t, err)
+		generation++
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"name": "foo", "generation": "%d"}`, generation)
+	}))
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	c, err := newGCSObjectClient(context.Background(), GCSConfig{
+		BucketName: "test-bucket",
+		Insecure:   true,
+	}, hedging.Config{}, func(ctx context.Context, opts ...option.ClientOption) (*storage.Client, error) {
+		opts = append(opts, option.WithEndpoint(server.URL))
+		opts = append(opts, option.WithoutAuthentication())
+		return storage.NewClient(ctx, opts...)
+	})
+	require.NoError(t, err)
+
+	// the object doesn't exist yet
+	require.NoError(t, c.PutObjectIfGenerationMatch(context.Background(), "foo", 0, bytes.NewReader([]byte("first write"))))
+	require.NoError(t, c.PutObjectIfGenerationMatch(context.Background(), "foo", 1, bytes.NewReader([]byte("second write"))))
+
+	// a concurrent writer still expecting the first generation
+	err = c.PutObjectIfGenerationMatch(context.Background(), "foo", 1, bytes.NewReader([]byte("stale write")))
+	require.Error(t, err)
+	require.True(t, c.IsPreconditionFailedErr(err))
+	require.False(t, c.IsObjectNotFoundErr(err))
+
+	err = c.PutObjectIfGenerationMatch(context.Background(), "foo", 0, bytes.NewReader([]byte("stale write")))
+	require.True(t, c.IsPreconditionFailedErr(err))
+
+	require.Equal(t, int64(2), generation)
+	require.Equal(t, "second write", string(data))
+}
+
+func TestGCSObjectClient_ObjectAttributes(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// attributes are read from .../b/<bucket>/o/<object>.
+		if !strings.HasSuffix(r.URL.Path, "/b/test-bucket/o/chunks/a") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"name": "chunks/a",
+			"size": "1024",
+			"updated": "2022-03-01T12:00:00Z",
+			"storageClass": "NEARLINE",
+			"etag": "CKih16GjycICEAE=",
+			"generation": "3"
+		}`))
+	}))
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	c, err := newGCSObjectClient(context.Background(), GCSConfig{
+		BucketName:     "test-bucket",
+		Insecure:       true,
+		RequestTimeout: time.Second,
+	}, hedging.Config{}, func(ctx context.Context, opts ...option.ClientOption) (*storage.Client, error) {
+		opts = append(opts, option.WithEndpoint(server.URL))
+		opts = append(opts, option.WithoutAuthentication())
+		return storage.NewClient(ctx, opts...)
+	})
+	require.NoError(t, err)
+
+	attrs, err := c.ObjectAttributes(context.Background(), "chunks/a")
+	require.NoError(t, err)
+	require.Equal(t, ObjectAttributes{
+		Size:         1024,
+		Updated:      time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC),
+		StorageClass: "NEARLINE",
+		Etag:         "CKih16GjycICEAE=",
+		Generation:   3,
+	}, attrs)
+
+	_, err = c.ObjectAttributes(context.Background(), "chunks/missing")
+	require.True(t, c.IsObjectNotFoundErr(err))
+}
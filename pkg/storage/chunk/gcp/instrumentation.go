@@ -51,13 +51,12 @@ func bigtableInstrumentation() ([]grpc.UnaryClientInterceptor, []grpc.StreamClie
 		}
 }
 
-func gcsInstrumentation(ctx context.Context, scope string, insecure bool, http2 bool) (*http.Client, error) {
+// gcsInstrumentation returns an instrumented HTTP client for GCS, authenticated with the credentials
+// in opts if any, with the Application Default Credentials otherwise.
+func gcsInstrumentation(ctx context.Context, scope string, insecure bool, http2 bool, dialer *net.Dialer, opts ...option.ClientOption) (*http.Client, error) {
 	customTransport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
 		ForceAttemptHTTP2:     true,
 		MaxIdleConns:          200,
 		MaxIdleConnsPerHost:   200,
@@ -74,7 +73,7 @@ func gcsInstrumentation(ctx context.Context, scope string, insecure bool, http2
 	if insecure {
 		customTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 	}
-	transport, err := google_http.NewTransport(ctx, customTransport, option.WithScopes(scope))
+	transport, err := google_http.NewTransport(ctx, customTransport, append([]option.ClientOption{option.WithScopes(scope)}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -69,17 +69,21 @@ func (FSObjectClient) Stop() {}
 func (f *FSObjectClient) GetObject(_ context.Context, objectKey string) (io.ReadCloser, int64, error) {
 	fl, err := os.Open(filepath.Join(f.cfg.Directory, filepath.FromSlash(objectKey)))
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, chunk.WrapObjectError(chunk.OpGetObject, objectKey, err)
 	}
 	stats, err := fl.Stat()
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, chunk.WrapObjectError(chunk.OpGetObject, objectKey, err)
 	}
 	return fl, stats.Size(), nil
 }
 
 // PutObject into the store
 func (f *FSObjectClient) PutObject(_ context.Context, objectKey string, object io.ReadSeeker) error {
+	return chunk.WrapObjectError(chunk.OpPutObject, objectKey, f.putObject(objectKey, object))
+}
+
+func (f *FSObjectClient) putObject(objectKey string, object io.ReadSeeker) error {
 	fullPath := filepath.Join(f.cfg.Directory, filepath.FromSlash(objectKey))
 	err := util.EnsureDirectory(filepath.Dir(fullPath))
 	if err != nil {
@@ -109,6 +113,17 @@ func (f *FSObjectClient) PutObject(_ context.Context, objectKey string, object i
 // List implements chunk.ObjectClient.
 // FSObjectClient assumes that prefix is a directory, and only supports "" and "/" delimiters.
 func (f *FSObjectClient) List(ctx context.Context, prefix, delimiter string) ([]chunk.StorageObject, []chunk.StorageCommonPrefix, error) {
+	return f.list(ctx, prefix, delimiter, nil)
+}
+
+// ListSkippingMissing implements chunk.MissingTolerantLister.
+func (f *FSObjectClient) ListSkippingMissing(ctx context.Context, prefix, delimiter string, onMissing func(key string)) ([]chunk.StorageObject, []chunk.StorageCommonPrefix, error) {
+	return f.list(ctx, prefix, delimiter, onMissing)
+}
+
+// list lists the objects like List, the files and directories deleted during the walk are skipped
+// and passed to onMissing if it is not nil.
+func (f *FSObjectClient) list(_ context.Context, prefix, delimiter string, onMissing func(key string)) ([]chunk.StorageObject, []chunk.StorageCommonPrefix, error) {
 	if delimiter != "" && delimiter != "/" {
 		return nil, nil, fmt.Errorf("unsupported delimiter: %q", delimiter)
 	}
@@ -120,7 +135,7 @@ func (f *FSObjectClient) List(ctx context.Context, prefix, delimiter string) ([]
 		if os.IsNotExist(err) {
 			return nil, nil, nil
 		}
-		return nil, nil, err
+		return nil, nil, chunk.WrapObjectError(chunk.OpList, prefix, err)
 	}
 	if !info.IsDir() {
 		// When listing single file, return this file only.
@@ -130,8 +145,25 @@ func (f *FSObjectClient) List(ctx context.Context, prefix, delimiter string) ([]
 	var storageObjects []chunk.StorageObject
 	var commonPrefixes []chunk.StorageCommonPrefix
 
+	// skipMissing reports whether err is for a file or directory deleted during the walk which must be skipped
+	skipMissing := func(path string, err error) bool {
+		if onMissing == nil || path == folderPath || !os.IsNotExist(err) {
+			return false
+		}
+		if relPath, relErr := filepath.Rel(f.cfg.Directory, path); relErr == nil {
+			onMissing(filepath.ToSlash(relPath))
+		}
+		return true
+	}
+
 	err = filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
+			if skipMissing(path, err) {
+				if info != nil && info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
 			return err
 		}
 
@@ -155,6 +187,9 @@ func (f *FSObjectClient) List(ctx context.Context, prefix, delimiter string) ([]
 
 			empty, err := isDirEmpty(path)
 			if err != nil {
+				if skipMissing(path, err) {
+					return filepath.SkipDir
+				}
 				return err
 			}
 
@@ -168,10 +203,14 @@ func (f *FSObjectClient) List(ctx context.Context, prefix, delimiter string) ([]
 		return nil
 	})
 
-	return storageObjects, commonPrefixes, err
+	return storageObjects, commonPrefixes, chunk.WrapObjectError(chunk.OpList, prefix, err)
 }
 
 func (f *FSObjectClient) DeleteObject(ctx context.Context, objectKey string) error {
+	return chunk.WrapObjectError(chunk.OpDeleteObject, objectKey, f.deleteObject(objectKey))
+}
+
+func (f *FSObjectClient) deleteObject(objectKey string) error {
 	// inspired from https://github.com/thanos-io/thanos/blob/55cb8ca38b3539381dc6a781e637df15c694e50a/pkg/objstore/filesystem/filesystem.go#L195
 	file := filepath.Join(f.cfg.Directory, filepath.FromSlash(objectKey))
 
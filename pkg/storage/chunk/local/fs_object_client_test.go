@@ -3,6 +3,7 @@ package local
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/pao214/loki/pkg/storage/chunk"
 	"github.com/pao214/loki/pkg/storage/chunk/util"
 )
 
@@ -200,3 +202,23 @@ func TestFSObjectClient_DeleteObject(t *testing.T) {
 	require.Len(t, commonPrefixes, 0)
 	require.Len(t, files, len(foldersWithFiles["folder2/"]))*/
 }
+
+func TestFSObjectClient_ObjectError(t *testing.T) {
+	fsObjectClient, err := NewFSObjectClient(FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+
+	_, _, err = fsObjectClient.GetObject(context.Background(), "missing/object")
+	require.Error(t, err)
+	require.True(t, fsObjectClient.IsObjectNotFoundErr(err))
+	require.True(t, errors.Is(err, os.ErrNotExist))
+
+	var objErr *chunk.ObjectError
+	require.True(t, errors.As(err, &objErr))
+	require.Equal(t, chunk.OpGetObject, objErr.Op)
+	require.Equal(t, "missing/object", objErr.Key)
+
+	err = fsObjectClient.DeleteObject(context.Background(), "missing/object")
+	require.True(t, fsObjectClient.IsObjectNotFoundErr(err))
+	require.True(t, errors.As(err, &objErr))
+	require.Equal(t, chunk.OpDeleteObject, objErr.Op)
+}
@@ -0,0 +1,39 @@
+package chunk
+
+import "fmt"
+
+// Object client operations recorded in an ObjectError.
+const (
+	OpGetObject     = "GetObject"
+	OpGetAttributes = "GetAttributes"
+	OpPutObject     = "PutObject"
+	OpDeleteObject  = "DeleteObject"
+	OpList          = "List"
+)
+
+// ObjectError records the operation and the object key (or prefix, for List)
+// of a failed ObjectClient call. It unwraps to the underlying error, so
+// errors.Is/errors.As checks against a client's not found errors, as done by
+// IsObjectNotFoundErr, keep working.
+type ObjectError struct {
+	Op  string
+	Key string
+	Err error
+}
+
+func (e *ObjectError) Error() string {
+	return fmt.Sprintf("%s %q: %v", e.Op, e.Key, e.Err)
+}
+
+func (e *ObjectError) Unwrap() error { return e.Err }
+
+// Cause allows github.com/pkg/errors.Cause to see through an ObjectError.
+func (e *ObjectError) Cause() error { return e.Err }
+
+// WrapObjectError wraps err in an ObjectError. A nil err is returned as is.
+func WrapObjectError(op, key string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ObjectError{Op: op, Key: key, Err: err}
+}
@@ -0,0 +1,25 @@
+package chunk
+
+import (
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	pkg_errors "github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectError(t *testing.T) {
+	err := WrapObjectError(OpGetObject, "fake/key", pkg_errors.Wrap(storage.ErrObjectNotExist, "reading object"))
+
+	require.True(t, errors.Is(err, storage.ErrObjectNotExist))
+	require.Equal(t, storage.ErrObjectNotExist, pkg_errors.Cause(err))
+	require.Equal(t, `GetObject "fake/key": reading object: storage: object doesn't exist`, err.Error())
+
+	var objErr *ObjectError
+	require.True(t, errors.As(err, &objErr))
+	require.Equal(t, OpGetObject, objErr.Op)
+	require.Equal(t, "fake/key", objErr.Key)
+
+	require.Nil(t, WrapObjectError(OpPutObject, "fake/key", nil))
+}
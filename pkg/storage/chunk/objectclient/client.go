@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	"golang.org/x/sync/semaphore"
 
 	"github.com/pao214/loki/pkg/storage/chunk"
 	"github.com/pao214/loki/pkg/storage/chunk/util"
@@ -46,6 +47,12 @@ type Client struct {
 	keyEncoder          KeyEncoder
 	getChunkMaxParallel int
 	schema              chunk.SchemaConfig
+	fetchMetrics        *util.ChunkFetchMetrics
+
+	// getChunkMemory, when set, holds the bytes of the chunks being fetched by
+	// GetChunks, up to getChunkMemoryBudget.
+	getChunkMemory       *semaphore.Weighted
+	getChunkMemoryBudget int64
 }
 
 // NewClient wraps the provided ObjectClient with a chunk.Client implementation
@@ -62,6 +69,26 @@ func NewClientWithMaxParallel(store chunk.ObjectClient, encoder KeyEncoder, maxP
 	}
 }
 
+// NewClientWithMetrics is like NewClientWithMaxParallel, but records the size of the
+// chunks fetched by GetChunks in fetchMetrics.
+func NewClientWithMetrics(store chunk.ObjectClient, encoder KeyEncoder, maxParallel int, schema chunk.SchemaConfig, fetchMetrics *util.ChunkFetchMetrics) *Client {
+	c := NewClientWithMaxParallel(store, encoder, maxParallel, schema)
+	c.fetchMetrics = fetchMetrics
+	return c
+}
+
+// NewClientWithMemoryBudget is like NewClientWithMetrics, but additionally holds back
+// chunk fetches while the chunks being fetched take memoryBudget bytes. A chunk larger
+// than the budget is fetched alone. A non-positive budget disables the limit.
+func NewClientWithMemoryBudget(store chunk.ObjectClient, encoder KeyEncoder, maxParallel int, memoryBudget int64, schema chunk.SchemaConfig, fetchMetrics *util.ChunkFetchMetrics) *Client {
+	c := NewClientWithMetrics(store, encoder, maxParallel, schema, fetchMetrics)
+	if memoryBudget > 0 {
+		c.getChunkMemory = semaphore.NewWeighted(memoryBudget)
+		c.getChunkMemoryBudget = memoryBudget
+	}
+	return c
+}
+
 // Stop shuts down the object store and any underlying clients
 func (o *Client) Stop() {
 	o.store.Stop()
@@ -115,7 +142,7 @@ func (o *Client) GetChunks(ctx context.Context, chunks []chunk.Chunk) ([]chunk.C
 	if getChunkMaxParallel == 0 {
 		getChunkMaxParallel = defaultMaxParallel
 	}
-	return util.GetParallelChunks(ctx, getChunkMaxParallel, chunks, o.getChunk)
+	return util.GetParallelChunksWithMetrics(ctx, getChunkMaxParallel, chunks, o.getChunk, o.fetchMetrics)
 }
 
 func (o *Client) getChunk(ctx context.Context, decodeContext *chunk.DecodeContext, c chunk.Chunk) (chunk.Chunk, error) {
@@ -136,6 +163,21 @@ func (o *Client) getChunk(ctx context.Context, decodeContext *chunk.DecodeContex
 
 	defer readCloser.Close()
 
+	if o.getChunkMemory != nil {
+		// the object stores all report the size of the objects they return, a chunk
+		// of unknown size isn't accounted for.
+		reserved := size
+		if reserved > o.getChunkMemoryBudget {
+			reserved = o.getChunkMemoryBudget
+		}
+		if reserved > 0 {
+			if err := o.getChunkMemory.Acquire(ctx, reserved); err != nil {
+				return chunk.Chunk{}, err
+			}
+			defer o.getChunkMemory.Release(reserved)
+		}
+	}
+
 	// adds bytes.MinRead to avoid allocations when the size is known.
 	// This is because ReadFrom reads bytes.MinRead by bytes.MinRead.
 	buf := bytes.NewBuffer(make([]byte, 0, size+bytes.MinRead))
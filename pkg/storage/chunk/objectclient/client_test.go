@@ -1,14 +1,22 @@
 package objectclient
 
 import (
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
 	"github.com/pao214/loki/pkg/logproto"
 	"github.com/pao214/loki/pkg/storage/chunk"
+	"github.com/pao214/loki/pkg/storage/chunk/encoding"
+	"github.com/pao214/loki/pkg/storage/chunk/util"
 )
 
 func MustParseDayTime(s string) chunk.DayTime {
@@ -82,3 +90,128 @@ func TestFSEncoder(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_GetChunksMetrics(t *testing.T) {
+	schema := chunk.SchemaConfig{
+		Configs: []chunk.PeriodConfig{{From: MustParseDayTime("2020-01-01"), Schema: "v12"}},
+	}
+	reg := prometheus.NewRegistry()
+	client := NewClientWithMetrics(chunk.NewMockStorage(), nil, 2, schema, util.NewChunkFetchMetrics(reg))
+
+	var chunks []chunk.Chunk
+	for i := 0; i < 3; i++ {
+		metric := labels.Labels{{Name: "foo", Value: string(rune('a' + i))}}
+		c := chunk.NewChunk("fake", model.Fingerprint(metric.Hash()), metric, encoding.New(), MustParseDayTime("2022-01-02").Time, MustParseDayTime("2022-01-03").Time)
+		require.NoError(t, c.Encode())
+		chunks = append(chunks, c)
+	}
+	require.NoError(t, client.PutChunks(context.Background(), chunks))
+
+	fetched, err := client.GetChunks(context.Background(), chunks)
+	require.NoError(t, err)
+	require.Len(t, fetched, len(chunks))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	require.Equal(t, "loki_chunk_fetch_bytes", families[0].GetName())
+	require.Equal(t, uint64(len(chunks)), families[0].GetMetric()[0].GetHistogram().GetSampleCount())
+}
+
+// inFlightObjectClient records the most bytes read concurrently from the objects it returns.
+type inFlightObjectClient struct {
+	chunk.ObjectClient
+
+	mtx            sync.Mutex
+	inFlight, peak int64
+	objectsRead    int
+}
+
+func (c *inFlightObjectClient) GetObject(ctx context.Context, key string) (io.ReadCloser, int64, error) {
+	rc, size, err := c.ObjectClient.GetObject(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	buf, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ioutil.NopCloser(&inFlightReader{client: c, buf: buf}), size, nil
+}
+
+type inFlightReader struct {
+	client  *inFlightObjectClient
+	buf     []byte
+	started bool
+}
+
+func (r *inFlightReader) Read(p []byte) (int, error) {
+	c := r.client
+	if !r.started {
+		r.started = true
+		c.mtx.Lock()
+		c.inFlight += int64(len(r.buf))
+		if c.inFlight > c.peak {
+			c.peak = c.inFlight
+		}
+		c.mtx.Unlock()
+		// let the other fetches catch up
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(r.buf) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	if len(r.buf) == 0 {
+		c.mtx.Lock()
+		c.inFlight -= int64(n)
+		c.objectsRead++
+		c.mtx.Unlock()
+		return n, io.EOF
+	}
+	c.mtx.Lock()
+	c.inFlight -= int64(n)
+	c.mtx.Unlock()
+	return n, nil
+}
+
+func TestClient_GetChunksMemoryBudget(t *testing.T) {
+	schema := chunk.SchemaConfig{
+		Configs: []chunk.PeriodConfig{{From: MustParseDayTime("2020-01-01"), Schema: "v12"}},
+	}
+
+	var chunks []chunk.Chunk
+	for i := 0; i < 10; i++ {
+		metric := labels.Labels{{Name: "foo", Value: string(rune('a' + i))}}
+		c := chunk.NewChunk("fake", model.Fingerprint(metric.Hash()), metric, encoding.New(), MustParseDayTime("2022-01-02").Time, MustParseDayTime("2022-01-03").Time)
+		require.NoError(t, c.Encode())
+		chunks = append(chunks, c)
+	}
+	encoded, err := chunks[0].Encoded()
+	require.NoError(t, err)
+	chunkSize := int64(len(encoded))
+
+	for _, tc := range []struct {
+		desc   string
+		budget int64
+		// maxPeak is the most bytes expected to be read at once.
+		maxPeak int64
+	}{
+		{desc: "budget fits three chunks", budget: 3*chunkSize + chunkSize/2, maxPeak: 3 * chunkSize},
+		{desc: "chunks larger than the budget are read alone", budget: chunkSize / 2, maxPeak: chunkSize},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			store := &inFlightObjectClient{ObjectClient: chunk.NewMockStorage()}
+			client := NewClientWithMemoryBudget(store, nil, 10, tc.budget, schema, nil)
+			require.NoError(t, client.PutChunks(context.Background(), chunks))
+
+			fetched, err := client.GetChunks(context.Background(), chunks)
+			require.NoError(t, err)
+			require.Len(t, fetched, len(chunks))
+			require.Equal(t, len(chunks), store.objectsRead)
+			require.LessOrEqual(t, store.peak, tc.maxPeak)
+			require.Positive(t, store.peak)
+		})
+	}
+}
@@ -129,7 +129,7 @@ func (s *SwiftObjectClient) GetObject(ctx context.Context, objectKey string) (io
 	var buf bytes.Buffer
 	_, err := s.hedgingConn.ObjectGet(s.cfg.ContainerName, objectKey, &buf, false, nil)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, chunk.WrapObjectError(chunk.OpGetObject, objectKey, err)
 	}
 
 	return ioutil.NopCloser(&buf), int64(buf.Len()), nil
@@ -138,7 +138,7 @@ func (s *SwiftObjectClient) GetObject(ctx context.Context, objectKey string) (io
 // PutObject puts the specified bytes into the configured Swift container at the provided key
 func (s *SwiftObjectClient) PutObject(ctx context.Context, objectKey string, object io.ReadSeeker) error {
 	_, err := s.conn.ObjectPut(s.cfg.ContainerName, objectKey, object, false, "", "", nil)
-	return err
+	return chunk.WrapObjectError(chunk.OpPutObject, objectKey, err)
 }
 
 // List only objects from the store non-recursively
@@ -156,7 +156,7 @@ func (s *SwiftObjectClient) List(ctx context.Context, prefix, delimiter string)
 
 	objs, err := s.conn.Objects(s.cfg.ContainerName, opts)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, chunk.WrapObjectError(chunk.OpList, prefix, err)
 	}
 
 	var storageObjects []chunk.StorageObject
@@ -181,7 +181,7 @@ func (s *SwiftObjectClient) List(ctx context.Context, prefix, delimiter string)
 
 // DeleteObject deletes the specified object key from the configured Swift container.
 func (s *SwiftObjectClient) DeleteObject(ctx context.Context, objectKey string) error {
-	return s.conn.ObjectDelete(s.cfg.ContainerName, objectKey)
+	return chunk.WrapObjectError(chunk.OpDeleteObject, objectKey, s.conn.ObjectDelete(s.cfg.ContainerName, objectKey))
 }
 
 // IsObjectNotFoundErr returns true if error means that object is not found. Relevant to GetObject and DeleteObject operations.
@@ -23,6 +23,7 @@ import (
 	"github.com/pao214/loki/pkg/storage/chunk/local"
 	"github.com/pao214/loki/pkg/storage/chunk/objectclient"
 	"github.com/pao214/loki/pkg/storage/chunk/openstack"
+	"github.com/pao214/loki/pkg/storage/chunk/util"
 	"github.com/pao214/loki/pkg/storage/stores/shipper/downloads"
 	util_log "github.com/pao214/loki/pkg/util/log"
 )
@@ -96,6 +97,8 @@ type Config struct {
 	IndexQueriesCacheConfig  cache.Config `yaml:"index_queries_cache_config"`
 	DisableBroadIndexQueries bool         `yaml:"disable_broad_index_queries"`
 	MaxParallelGetChunk      int          `yaml:"max_parallel_get_chunk"`
+	MaxGetChunkMemoryBytes   int64        `yaml:"max_get_chunk_memory_bytes"`
+	MaxIdleDecodeContexts    int          `yaml:"max_idle_decode_contexts"`
 
 	GrpcConfig grpc.Config `yaml:"grpc_store"`
 
@@ -103,17 +106,22 @@ type Config struct {
 }
 
 type ClientMetrics struct {
-	AzureMetrics azure.BlobStorageMetrics
+	AzureMetrics      azure.BlobStorageMetrics
+	ChunkFetchMetrics *util.ChunkFetchMetrics
 }
 
 func NewClientMetrics() ClientMetrics {
 	return ClientMetrics{
-		AzureMetrics: azure.NewBlobStorageMetrics(),
+		AzureMetrics:      azure.NewBlobStorageMetrics(),
+		ChunkFetchMetrics: util.NewChunkFetchMetrics(prometheus.DefaultRegisterer),
 	}
 }
 
 func (c *ClientMetrics) Unregister() {
 	c.AzureMetrics.Unregister()
+	if c.ChunkFetchMetrics != nil {
+		c.ChunkFetchMetrics.Unregister(prometheus.DefaultRegisterer)
+	}
 }
 
 // RegisterFlags adds the flags required to configure this flag set.
@@ -134,6 +142,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.IndexCacheValidity, "store.index-cache-validity", 5*time.Minute, "Cache validity for active index entries. Should be no higher than -ingester.max-chunk-idle.")
 	f.BoolVar(&cfg.DisableBroadIndexQueries, "store.disable-broad-index-queries", false, "Disable broad index queries which results in reduced cache usage and faster query performance at the expense of somewhat higher QPS on the index store.")
 	f.IntVar(&cfg.MaxParallelGetChunk, "store.max-parallel-get-chunk", 150, "Maximum number of parallel chunk reads.")
+	f.Int64Var(&cfg.MaxGetChunkMemoryBytes, "store.max-get-chunk-memory-bytes", 0, "Maximum number of bytes of the chunks read in parallel from object stores. A chunk larger than the limit is read alone. 0 to disable.")
+	f.IntVar(&cfg.MaxIdleDecodeContexts, "store.max-idle-decode-contexts", 0, "Maximum number of idle chunk decode contexts kept for reuse by parallel chunk reads. 0 to keep them all.")
 }
 
 // Validate config and returns error on failure
@@ -141,12 +151,18 @@ func (cfg *Config) Validate() error {
 	if cfg.Engine != StorageEngineChunks && cfg.Engine != StorageEngineBlocks {
 		return errors.New("unsupported storage engine")
 	}
+	if cfg.MaxIdleDecodeContexts < 0 {
+		return errors.New("max idle decode contexts must not be negative")
+	}
 	if err := cfg.CassandraStorageConfig.Validate(); err != nil {
 		return errors.Wrap(err, "invalid Cassandra Storage config")
 	}
 	if err := cfg.GCPStorageConfig.Validate(util_log.Logger); err != nil {
 		return errors.Wrap(err, "invalid GCP Storage Storage config")
 	}
+	if err := cfg.GCSConfig.Validate(); err != nil {
+		return errors.Wrap(err, "invalid GCS Storage config")
+	}
 	if err := cfg.Swift.Validate(); err != nil {
 		return errors.Wrap(err, "invalid Swift Storage config")
 	}
@@ -174,6 +190,9 @@ func NewStore(
 	logger log.Logger,
 ) (chunk.Store, error) {
 	chunkMetrics := newChunkClientMetrics(reg)
+	if cfg.MaxIdleDecodeContexts > 0 {
+		util.SetMaxIdleDecodeContexts(cfg.MaxIdleDecodeContexts)
+	}
 
 	indexReadCache, err := cache.New(cfg.IndexQueriesCacheConfig, reg, logger)
 	if err != nil {
@@ -293,7 +312,7 @@ func NewChunkClient(name string, cfg Config, schemaCfg chunk.SchemaConfig, clien
 		if err != nil {
 			return nil, err
 		}
-		return objectclient.NewClientWithMaxParallel(c, nil, cfg.MaxParallelGetChunk, schemaCfg), nil
+		return objectclient.NewClientWithMemoryBudget(c, nil, cfg.MaxParallelGetChunk, cfg.MaxGetChunkMemoryBytes, schemaCfg, clientMetrics.ChunkFetchMetrics), nil
 	case StorageTypeAWSDynamo:
 		if cfg.AWSStorageConfig.DynamoDB.URL == nil {
 			return nil, fmt.Errorf("Must set -dynamodb.url in aws mode")
@@ -308,7 +327,7 @@ func NewChunkClient(name string, cfg Config, schemaCfg chunk.SchemaConfig, clien
 		if err != nil {
 			return nil, err
 		}
-		return objectclient.NewClientWithMaxParallel(c, nil, cfg.MaxParallelGetChunk, schemaCfg), nil
+		return objectclient.NewClientWithMemoryBudget(c, nil, cfg.MaxParallelGetChunk, cfg.MaxGetChunkMemoryBytes, schemaCfg, clientMetrics.ChunkFetchMetrics), nil
 	case StorageTypeGCP:
 		return gcp.NewBigtableObjectClient(context.Background(), cfg.GCPStorageConfig, schemaCfg)
 	case StorageTypeGCPColumnKey, StorageTypeBigTable, StorageTypeBigTableHashed:
@@ -318,13 +337,13 @@ func NewChunkClient(name string, cfg Config, schemaCfg chunk.SchemaConfig, clien
 		if err != nil {
 			return nil, err
 		}
-		return objectclient.NewClientWithMaxParallel(c, nil, cfg.MaxParallelGetChunk, schemaCfg), nil
+		return objectclient.NewClientWithMemoryBudget(c, nil, cfg.MaxParallelGetChunk, cfg.MaxGetChunkMemoryBytes, schemaCfg, clientMetrics.ChunkFetchMetrics), nil
 	case StorageTypeSwift:
 		c, err := openstack.NewSwiftObjectClient(cfg.Swift, cfg.Hedging)
 		if err != nil {
 			return nil, err
 		}
-		return objectclient.NewClientWithMaxParallel(c, nil, cfg.MaxParallelGetChunk, schemaCfg), nil
+		return objectclient.NewClientWithMemoryBudget(c, nil, cfg.MaxParallelGetChunk, cfg.MaxGetChunkMemoryBytes, schemaCfg, clientMetrics.ChunkFetchMetrics), nil
 	case StorageTypeCassandra:
 		return cassandra.NewObjectClient(cfg.CassandraStorageConfig, schemaCfg, registerer, cfg.MaxParallelGetChunk)
 	case StorageTypeFileSystem:
@@ -332,7 +351,7 @@ func NewChunkClient(name string, cfg Config, schemaCfg chunk.SchemaConfig, clien
 		if err != nil {
 			return nil, err
 		}
-		return objectclient.NewClientWithMaxParallel(store, objectclient.FSEncoder, cfg.MaxParallelGetChunk, schemaCfg), nil
+		return objectclient.NewClientWithMemoryBudget(store, objectclient.FSEncoder, cfg.MaxParallelGetChunk, cfg.MaxGetChunkMemoryBytes, schemaCfg, clientMetrics.ChunkFetchMetrics), nil
 	case StorageTypeGrpc:
 		return grpc.NewStorageClient(cfg.GrpcConfig, schemaCfg)
 	default:
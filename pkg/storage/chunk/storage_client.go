@@ -84,6 +84,14 @@ type ObjectClient interface {
 	Stop()
 }
 
+// MissingTolerantLister is implemented by the object clients able to skip the objects deleted while they are
+// being listed, e.g. by retention or compaction, instead of failing the whole listing.
+type MissingTolerantLister interface {
+	// ListSkippingMissing behaves like List, except that the objects which vanished mid-scan are left out
+	// of the result and onMissing is called with the key of each of them.
+	ListSkippingMissing(ctx context.Context, prefix, delimiter string, onMissing func(key string)) ([]StorageObject, []StorageCommonPrefix, error)
+}
+
 // StorageObject represents an object being stored in an Object Store
 type StorageObject struct {
 	Key        string
@@ -0,0 +1,89 @@
+package util
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/pao214/loki/pkg/storage/chunk"
+)
+
+var decodeContextPoolInUse = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: "loki",
+	Name:      "chunk_decode_context_pool_inuse",
+	Help:      "Number of chunk decode contexts currently taken from the pool by parallel chunk fetches.",
+})
+
+// decodeContexts holds the *decodeContextPool used by parallel chunk fetches.
+// It's swapped atomically as stores may be created while fetches are running.
+var decodeContexts atomic.Value
+
+func init() {
+	setDecodeContextPool(newDecodeContextPool(0, decodeContextPoolInUse))
+}
+
+// SetMaxIdleDecodeContexts caps the number of idle decode contexts kept for reuse
+// by parallel chunk fetches. Zero, the default, leaves the pool unbounded.
+// Fetches already running keep using the previous pool.
+func SetMaxIdleDecodeContexts(maxIdle int) {
+	setDecodeContextPool(newDecodeContextPool(maxIdle, decodeContextPoolInUse))
+}
+
+func setDecodeContextPool(p *decodeContextPool) {
+	decodeContexts.Store(p)
+}
+
+func getDecodeContextPool() *decodeContextPool {
+	return decodeContexts.Load().(*decodeContextPool)
+}
+
+// decodeContextPool hands out decode contexts, tracking how many are in use.
+// When bounded, at most maxIdle contexts are kept around once returned, so a burst
+// of parallel fetches doesn't pin their buffers indefinitely.
+type decodeContextPool struct {
+	inUse prometheus.Gauge
+
+	// pool is used when unbounded, idle otherwise.
+	pool sync.Pool
+	idle chan *chunk.DecodeContext
+}
+
+func newDecodeContextPool(maxIdle int, inUse prometheus.Gauge) *decodeContextPool {
+	p := &decodeContextPool{inUse: inUse}
+	if maxIdle > 0 {
+		p.idle = make(chan *chunk.DecodeContext, maxIdle)
+	} else {
+		p.pool.New = func() interface{} {
+			return chunk.NewDecodeContext()
+		}
+	}
+	return p
+}
+
+func (p *decodeContextPool) Get() *chunk.DecodeContext {
+	p.inUse.Inc()
+	if p.idle == nil {
+		return p.pool.Get().(*chunk.DecodeContext)
+	}
+	select {
+	case c := <-p.idle:
+		return c
+	default:
+		return chunk.NewDecodeContext()
+	}
+}
+
+func (p *decodeContextPool) Put(c *chunk.DecodeContext) {
+	p.inUse.Dec()
+	if p.idle == nil {
+		p.pool.Put(c)
+		return
+	}
+	select {
+	case p.idle <- c:
+	default:
+		// the pool is full, let the context be garbage collected
+	}
+}
@@ -2,76 +2,232 @@ package util
 
 import (
 	"context"
+	"errors"
 	"sync"
 
 	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 
+	lokiutil "github.com/pao214/loki/pkg/util"
 	"github.com/pao214/loki/pkg/util/spanlogger"
 
 	"github.com/pao214/loki/pkg/storage/chunk"
 )
 
-var decodeContextPool = sync.Pool{
-	New: func() interface{} {
-		return chunk.NewDecodeContext()
-	},
+type ChunkFetchMetrics struct {
+	chunkFetchBytes prometheus.Histogram
 }
 
-// GetParallelChunks fetches chunks in parallel (up to maxParallel).
+func NewChunkFetchMetrics(r prometheus.Registerer) *ChunkFetchMetrics {
+	return &ChunkFetchMetrics{
+		chunkFetchBytes: promauto.With(r).NewHistogram(prometheus.HistogramOpts{
+			Namespace: "loki",
+			Name:      "chunk_fetch_bytes",
+			Help:      "Size in bytes of the chunks fetched by GetParallelChunks.",
+			// 1KB -> 16MB
+			Buckets: prometheus.ExponentialBuckets(1024, 4, 8),
+		}),
+	}
+}
+
+// Unregister unregisters the metrics from r, useful for tests where we frequently need to create
+// multiple instances of the metrics struct on the default registerer.
+func (m *ChunkFetchMetrics) Unregister(r prometheus.Registerer) {
+	r.Unregister(m.chunkFetchBytes)
+}
+
+func (m *ChunkFetchMetrics) observe(c chunk.Chunk) {
+	if m == nil {
+		return
+	}
+	var size int
+	if c.Data != nil {
+		size = c.Data.Size()
+	}
+	m.chunkFetchBytes.Observe(float64(size))
+}
+
+// GetParallelChunks fetches chunks in parallel (up to maxParallel). The first failed
+// fetch cancels the others: the chunks fetched successfully are returned along with
+// the errors of every fetch which ran. A single error is returned as is, several
+// are combined in a util.MultiError, which still matches context errors with errors.Is.
 func GetParallelChunks(ctx context.Context, maxParallel int, chunks []chunk.Chunk, f func(context.Context, *chunk.DecodeContext, chunk.Chunk) (chunk.Chunk, error)) ([]chunk.Chunk, error) {
-	log, ctx := spanlogger.New(ctx, "GetParallelChunks")
-	defer log.Finish()
-	log.LogFields(otlog.Int("requested", len(chunks)))
+	return GetParallelChunksWithMetrics(ctx, maxParallel, chunks, f, nil)
+}
 
+// GetParallelChunksWithMetrics is like GetParallelChunks, but records the size of every
+// fetched chunk in metrics. metrics may be nil.
+func GetParallelChunksWithMetrics(ctx context.Context, maxParallel int, chunks []chunk.Chunk, f func(context.Context, *chunk.DecodeContext, chunk.Chunk) (chunk.Chunk, error), metrics *ChunkFetchMetrics) ([]chunk.Chunk, error) {
+	return GetParallelChunksUntil(ctx, maxParallel, chunks, f, metrics, nil)
+}
+
+// GetParallelChunksUntil is like GetParallelChunksWithMetrics, but stops as soon as
+// done returns true for the chunks fetched so far: queued fetches are dropped and
+// in-flight ones are cancelled through their context. done may be nil, in which case
+// all chunks are fetched.
+func GetParallelChunksUntil(ctx context.Context, maxParallel int, chunks []chunk.Chunk, f func(context.Context, *chunk.DecodeContext, chunk.Chunk) (chunk.Chunk, error), metrics *ChunkFetchMetrics, done func([]chunk.Chunk) bool) ([]chunk.Chunk, error) {
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
 
+	result := make([]chunk.Chunk, 0, len(chunks))
+	err := getParallelChunks(ctx, maxParallel, chunks, f, metrics, func(c chunk.Chunk) (bool, error) {
+		result = append(result, c)
+		return done != nil && done(result), nil
+	})
+
+	// Return any chunks we did receive: a partial result may be useful
+	return result, err
+}
+
+// GetParallelChunksStream is like GetParallelChunks, but hands every chunk to onChunk
+// as soon as it is fetched instead of collecting them, so callers can start
+// processing before all chunks arrive. onChunk is never called concurrently. If it
+// returns an error, the remaining fetches are cancelled and that error is returned.
+func GetParallelChunksStream(ctx context.Context, maxParallel int, chunks []chunk.Chunk, f func(context.Context, *chunk.DecodeContext, chunk.Chunk) (chunk.Chunk, error), onChunk func(chunk.Chunk) error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	var onChunkErr error
+	err := getParallelChunks(ctx, maxParallel, chunks, f, nil, func(c chunk.Chunk) (bool, error) {
+		onChunkErr = onChunk(c)
+		return onChunkErr != nil, onChunkErr
+	})
+	if onChunkErr != nil {
+		return onChunkErr
+	}
+	return err
+}
+
+// getParallelChunks fetches chunks with up to maxParallel workers, calling onChunk
+// for each fetched chunk until it returns true. The first fetch error cancels the
+// remaining fetches, the errors of those already in flight are still collected.
+// They are all returned once the workers exit, a single one as is, several combined
+// in a util.MultiError.
+func getParallelChunks(ctx context.Context, maxParallel int, chunks []chunk.Chunk, f func(context.Context, *chunk.DecodeContext, chunk.Chunk) (chunk.Chunk, error), metrics *ChunkFetchMetrics, onChunk func(chunk.Chunk) (bool, error)) error {
+	log, parentCtx := spanlogger.New(ctx, "GetParallelChunks")
+	defer log.Finish()
+	log.LogFields(otlog.Int("requested", len(chunks)))
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
 	queuedChunks := make(chan chunk.Chunk)
 
 	go func() {
+		defer close(queuedChunks)
 		for _, c := range chunks {
-			queuedChunks <- c
+			select {
+			case queuedChunks <- c:
+			case <-ctx.Done():
+				return
+			}
 		}
-		close(queuedChunks)
 	}()
 
 	processedChunks := make(chan chunk.Chunk)
-	errors := make(chan error)
+	fetchErrors := make(chan error)
 
+	// workers are joined before returning, so their decode contexts are back in the pool
+	var wg sync.WaitGroup
 	for i := 0; i < min(maxParallel, len(chunks)); i++ {
+		wg.Add(1)
 		go func() {
-			decodeContext := decodeContextPool.Get().(*chunk.DecodeContext)
+			defer wg.Done()
+			ctx, finish := spanlogger.ChildContext(ctx, "GetParallelChunks-worker")
+			defer finish()
+
+			pool := getDecodeContextPool()
+			decodeContext := pool.Get()
+			defer pool.Put(decodeContext)
 			for c := range queuedChunks {
+				if ctx.Err() != nil {
+					return
+				}
+				// the results of in-flight fetches are always received, until all workers exit
 				c, err := f(ctx, decodeContext, c)
 				if err != nil {
-					errors <- err
+					fetchErrors <- err
 				} else {
 					processedChunks <- c
 				}
 			}
-			decodeContextPool.Put(decodeContext)
 		}()
 	}
 
-	result := make([]chunk.Chunk, 0, len(chunks))
-	var lastErr error
-	for i := 0; i < len(chunks); i++ {
+	workersDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(workersDone)
+	}()
+
+	var (
+		fetched int
+		errs    lokiutil.MultiError
+		// set once the remaining fetches are cancelled, after the first error or by onChunk
+		cancelled bool
+		// set once onChunk asked to stop, the chunks still in flight are then dropped
+		stopped bool
+	)
+	cancelFetches := func() {
+		cancelled = true
+		// cancel in-flight and queued fetches
+		cancel()
+	}
+outer:
+	for {
 		select {
 		case chunk := <-processedChunks:
-			result = append(result, chunk)
-		case err := <-errors:
-			lastErr = err
+			if stopped {
+				continue
+			}
+			metrics.observe(chunk)
+			fetched++
+			done, err := onChunk(chunk)
+			if err != nil {
+				errs.Add(err)
+			}
+			if done {
+				log.LogFields(otlog.Bool("stopped_early", true))
+				stopped = true
+				cancelFetches()
+			}
+		case err := <-fetchErrors:
+			// fetches interrupted by our own cancellation didn't fail
+			if cancelled && parentCtx.Err() == nil && isContextErr(err) {
+				continue
+			}
+			errs.Add(err)
+			if !cancelled {
+				cancelFetches()
+			}
+		case <-workersDone:
+			break outer
 		}
 	}
+	// the queued chunks were dropped without being fetched
+	if len(errs) == 0 && parentCtx.Err() != nil {
+		errs.Add(parentCtx.Err())
+	}
 
-	log.LogFields(otlog.Int("fetched", len(result)))
-	if lastErr != nil {
-		log.Error(lastErr)
+	log.LogFields(otlog.Int("fetched", fetched))
+	if len(errs) == 0 {
+		return nil
 	}
+	log.LogFields(otlog.Int("failed", len(errs)))
+	log.Error(errs.Err())
 
-	// Return any chunks we did receive: a partial result may be useful
-	return result, lastErr
+	// Keep a single error unwrapped so callers can still check its type.
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errs.Err()
+}
+
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
 }
 
 func min(a, b int) int {
@@ -2,11 +2,282 @@ package util
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 
 	"github.com/pao214/loki/pkg/storage/chunk"
+	"github.com/pao214/loki/pkg/storage/chunk/encoding"
 )
 
+func TestGetParallelChunksWithMetrics(t *testing.T) {
+	metrics := NewChunkFetchMetrics(prometheus.NewRegistry())
+
+	in := make([]chunk.Chunk, 10)
+	for i := range in {
+		in[i] = chunk.Chunk{Data: encoding.New()}
+	}
+	// the last chunk fails to fetch and must not be observed
+	in[len(in)-1].Checksum = 1
+
+	res, err := GetParallelChunksWithMetrics(context.Background(), 3, in,
+		func(_ context.Context, _ *chunk.DecodeContext, c chunk.Chunk) (chunk.Chunk, error) {
+			if c.Checksum == 1 {
+				return chunk.Chunk{}, errors.New("fetch failed")
+			}
+			return c, nil
+		}, metrics)
+	require.EqualError(t, err, "fetch failed")
+	require.Len(t, res, len(in)-1)
+
+	var m dto.Metric
+	require.NoError(t, metrics.chunkFetchBytes.Write(&m))
+	require.Equal(t, uint64(len(in)-1), m.GetHistogram().GetSampleCount())
+	require.Equal(t, float64((len(in)-1)*encoding.New().Size()), m.GetHistogram().GetSampleSum())
+}
+
+func TestGetParallelChunks_ChildSpans(t *testing.T) {
+	// GetParallelChunks starts its own span from the global tracer
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	parent := tracer.StartSpan("parent")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+
+	in := make([]chunk.Chunk, 10)
+	res, err := GetParallelChunks(ctx, 3, in, func(_ context.Context, _ *chunk.DecodeContext, c chunk.Chunk) (chunk.Chunk, error) {
+		return c, nil
+	})
+	require.NoError(t, err)
+	require.Len(t, res, len(in))
+	parent.Finish()
+
+	spans := map[string][]*mocktracer.MockSpan{}
+	for _, sp := range tracer.FinishedSpans() {
+		spans[sp.OperationName] = append(spans[sp.OperationName], sp)
+	}
+	require.Len(t, spans["parent"], 1)
+	require.Len(t, spans["GetParallelChunks"], 1)
+	require.Len(t, spans["GetParallelChunks-worker"], 3)
+
+	fetch := spans["GetParallelChunks"][0]
+	require.Equal(t, parent.Context().(mocktracer.MockSpanContext).SpanID, fetch.ParentID)
+	for _, sp := range spans["GetParallelChunks-worker"] {
+		require.Equal(t, fetch.SpanContext.SpanID, sp.ParentID)
+	}
+}
+
+func TestGetParallelChunksUntil(t *testing.T) {
+	in := make([]chunk.Chunk, 10)
+	for i := range in {
+		in[i].Checksum = uint32(i)
+	}
+
+	var started, cancelled atomic.Int32
+	res, err := GetParallelChunksUntil(context.Background(), 4, in,
+		func(ctx context.Context, _ *chunk.DecodeContext, c chunk.Chunk) (chunk.Chunk, error) {
+			if c.Checksum < 2 {
+				return c, nil
+			}
+			// every other fetch only finishes once cancelled
+			started.Inc()
+			<-ctx.Done()
+			cancelled.Inc()
+			return chunk.Chunk{}, ctx.Err()
+		}, nil, func(res []chunk.Chunk) bool {
+			return len(res) == 2
+		})
+	require.NoError(t, err)
+	require.ElementsMatch(t, in[:2], res)
+
+	require.Eventually(t, func() bool {
+		return started.Load() == cancelled.Load()
+	}, time.Second, 10*time.Millisecond)
+	require.Less(t, int(started.Load()), len(in)-2)
+}
+
+func TestGetParallelChunks_CollectsAllErrors(t *testing.T) {
+	in := make([]chunk.Chunk, 20)
+	for i := range in {
+		in[i].Checksum = uint32(i)
+	}
+
+	// the first 4 fetches all fail together, the remaining ones are cancelled
+	var (
+		barrier sync.WaitGroup
+		calls   atomic.Int32
+	)
+	barrier.Add(4)
+	before := runtime.NumGoroutine()
+	res, err := GetParallelChunks(context.Background(), 4, in,
+		func(ctx context.Context, _ *chunk.DecodeContext, c chunk.Chunk) (chunk.Chunk, error) {
+			calls.Inc()
+			if c.Checksum < 4 {
+				barrier.Done()
+				barrier.Wait()
+				return chunk.Chunk{}, fmt.Errorf("fetch %d failed", c.Checksum)
+			}
+			<-ctx.Done()
+			return chunk.Chunk{}, ctx.Err()
+		})
+	require.Empty(t, res)
+	require.Less(t, int(calls.Load()), len(in))
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "4 errors: ")
+	for id := 0; id < 4; id++ {
+		require.Contains(t, err.Error(), fmt.Sprintf("fetch %d failed", id))
+	}
+	require.NotContains(t, err.Error(), context.Canceled.Error())
+
+	// the producer and all workers must exit. Poll in this goroutine, as
+	// require.Eventually would start its own.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
+
+func TestGetParallelChunks_CallerCancelled(t *testing.T) {
+	in := make([]chunk.Chunk, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	before := runtime.NumGoroutine()
+	var calls atomic.Int32
+	_, err := GetParallelChunks(ctx, 4, in,
+		func(ctx context.Context, _ *chunk.DecodeContext, c chunk.Chunk) (chunk.Chunk, error) {
+			if calls.Inc() == 1 {
+				cancel()
+			}
+			<-ctx.Done()
+			return chunk.Chunk{}, ctx.Err()
+		})
+	require.ErrorIs(t, err, context.Canceled)
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.LessOrEqual(t, runtime.NumGoroutine(), before)
+	require.Less(t, int(calls.Load()), len(in))
+}
+
+func TestGetParallelChunks_DeadlineExceeded(t *testing.T) {
+	in := make([]chunk.Chunk, 10)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := GetParallelChunks(ctx, 4, in,
+		func(ctx context.Context, _ *chunk.DecodeContext, c chunk.Chunk) (chunk.Chunk, error) {
+			<-ctx.Done()
+			return chunk.Chunk{}, ctx.Err()
+		})
+	require.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+}
+
+func TestGetParallelChunksStream(t *testing.T) {
+	in := make([]chunk.Chunk, 5)
+	release := make([]chan struct{}, len(in))
+	for i := range in {
+		in[i].Checksum = uint32(i)
+		release[i] = make(chan struct{})
+	}
+	fetch := func(ctx context.Context, _ *chunk.DecodeContext, c chunk.Chunk) (chunk.Chunk, error) {
+		select {
+		case <-release[c.Checksum]:
+			return c, nil
+		case <-ctx.Done():
+			return chunk.Chunk{}, ctx.Err()
+		}
+	}
+
+	t.Run("incremental", func(t *testing.T) {
+		received := make(chan chunk.Chunk)
+		errc := make(chan error, 1)
+		go func() {
+			errc <- GetParallelChunksStream(context.Background(), len(in), in, fetch, func(c chunk.Chunk) error {
+				received <- c
+				return nil
+			})
+		}()
+
+		// every chunk is handed over as soon as its fetch completes, before the next one does
+		for i := len(in) - 1; i >= 0; i-- {
+			close(release[i])
+			require.Equal(t, in[i], <-received)
+		}
+		require.NoError(t, <-errc)
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		var calls atomic.Int32
+		err := GetParallelChunksStream(context.Background(), 2, in,
+			func(ctx context.Context, _ *chunk.DecodeContext, c chunk.Chunk) (chunk.Chunk, error) {
+				return c, nil
+			}, func(c chunk.Chunk) error {
+				calls.Inc()
+				return errors.New("client gone")
+			})
+		require.EqualError(t, err, "client gone")
+		require.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := GetParallelChunksStream(ctx, 2, in, fetch, func(c chunk.Chunk) error {
+			t.Fatal("unexpected chunk")
+			return nil
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestGetParallelChunks_ReturnsDecodeContexts(t *testing.T) {
+	in := make([]chunk.Chunk, 20)
+	for i := range in {
+		in[i].Checksum = uint32(i)
+	}
+
+	for _, maxIdle := range []int{0, 2} {
+		inUse := prometheus.NewGauge(prometheus.GaugeOpts{Name: "inuse"})
+		pool := newDecodeContextPool(maxIdle, inUse)
+		setDecodeContextPool(pool)
+
+		var maxInUse atomic.Float64
+		_, err := GetParallelChunks(context.Background(), 4, in,
+			func(_ context.Context, _ *chunk.DecodeContext, c chunk.Chunk) (chunk.Chunk, error) {
+				if v := testutil.ToFloat64(inUse); v > maxInUse.Load() {
+					maxInUse.Store(v)
+				}
+				return c, nil
+			})
+		require.NoError(t, err)
+
+		require.Greater(t, maxInUse.Load(), 0.0)
+		require.LessOrEqual(t, maxInUse.Load(), 4.0)
+		require.Equal(t, 0.0, testutil.ToFloat64(inUse))
+		if maxIdle > 0 {
+			require.LessOrEqual(t, len(pool.idle), maxIdle)
+			require.NotEmpty(t, pool.idle)
+		}
+	}
+	setDecodeContextPool(newDecodeContextPool(0, decodeContextPoolInUse))
+}
+
 func BenchmarkGetParallelChunks(b *testing.B) {
 	ctx := context.Background()
 	in := make([]chunk.Chunk, 1024)
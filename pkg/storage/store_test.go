@@ -836,7 +836,7 @@ func TestStore_MultipleBoltDBShippersInConfig(t *testing.T) {
 		},
 	}
 
-	RegisterCustomIndexClients(&config, cm, nil)
+	RegisterCustomIndexClients(&config, schemaConfig, cm, nil)
 
 	chunkStore, err := storage.NewStore(
 		config.Config,
@@ -110,6 +110,7 @@ type Compactor struct {
 	DeleteRequestsHandler *deletion.DeleteRequestHandler
 	deleteRequestsManager *deletion.DeleteRequestsManager
 	expirationChecker     retention.ExpirationChecker
+	schemaConfig          loki_storage.SchemaConfig
 	metrics               *metrics
 	running               bool
 	wg                    sync.WaitGroup
@@ -138,6 +139,7 @@ func NewCompactor(cfg Config, storageConfig storage.Config, schemaConfig loki_st
 
 	compactor := &Compactor{
 		cfg:            cfg,
+		schemaConfig:   schemaConfig,
 		ringPollPeriod: 5 * time.Second,
 	}
 
@@ -412,14 +414,17 @@ func (c *Compactor) stopping(_ error) error {
 }
 
 func (c *Compactor) CompactTable(ctx context.Context, tableName string, applyRetention bool) error {
+	// a table which doesn't belong to any schema period is expected to hold chunks of any time.
+	schemaCfg, _ := shipper_util.SchemaPeriodForTable(c.schemaConfig.Configs, tableName)
+	interval := retention.ExtractIntervalFromTableName(tableName, schemaCfg.IndexTables)
+
 	table, err := newTable(ctx, filepath.Join(c.cfg.WorkingDirectory, tableName), c.indexStorageClient,
-		c.tableMarker, c.expirationChecker)
+		c.tableMarker, c.expirationChecker, interval)
 	if err != nil {
 		level.Error(util_log.Logger).Log("msg", "failed to initialize table for compaction", "table", tableName, "err", err)
 		return err
 	}
 
-	interval := retention.ExtractIntervalFromTableName(tableName)
 	intervalMayHaveExpiredChunks := false
 	if applyRetention {
 		intervalMayHaveExpiredChunks = c.expirationChecker.IntervalMayHaveExpiredChunks(interval, "")
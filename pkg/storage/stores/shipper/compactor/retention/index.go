@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/go-kit/log/level"
@@ -14,6 +13,7 @@ import (
 	"github.com/pao214/loki/pkg/storage"
 	"github.com/pao214/loki/pkg/storage/chunk"
 	"github.com/pao214/loki/pkg/storage/stores/shipper"
+	shipper_util "github.com/pao214/loki/pkg/storage/stores/shipper/util"
 	util_log "github.com/pao214/loki/pkg/util/log"
 )
 
@@ -229,32 +229,7 @@ func validatePeriods(config storage.SchemaConfig) error {
 }
 
 func schemaPeriodForTable(config storage.SchemaConfig, tableName string) (chunk.PeriodConfig, bool) {
-	// first round removes configs that does not have the prefix.
-	candidates := []chunk.PeriodConfig{}
-	for _, schema := range config.Configs {
-		if strings.HasPrefix(tableName, schema.IndexTables.Prefix) {
-			candidates = append(candidates, schema)
-		}
-	}
-	// WARN we  assume period is always daily. This is only true for boltdb-shipper.
-	var (
-		matched chunk.PeriodConfig
-		found   bool
-	)
-	for _, schema := range candidates {
-		periodIndex, err := strconv.ParseInt(strings.TrimPrefix(tableName, schema.IndexTables.Prefix), 10, 64)
-		if err != nil {
-			continue
-		}
-		periodSec := int64(schema.IndexTables.Period / time.Second)
-		tableTs := model.TimeFromUnix(periodIndex * periodSec)
-		if tableTs.After(schema.From.Time) || tableTs == schema.From.Time {
-			matched = schema
-			found = true
-		}
-	}
-
-	return matched, found
+	return shipper_util.SchemaPeriodForTable(config.Configs, tableName)
 }
 
 func seriesFromHash(h []byte) (seriesID []byte) {
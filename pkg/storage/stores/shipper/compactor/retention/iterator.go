@@ -115,7 +115,7 @@ func newSeriesCleaner(bucket *bbolt.Bucket, config chunk.PeriodConfig, tableName
 	}
 
 	return &seriesCleaner{
-		tableInterval: ExtractIntervalFromTableName(tableName),
+		tableInterval: ExtractIntervalFromTableName(tableName, config.IndexTables),
 		schema:        schema,
 		bucket:        bucket,
 		buf:           make([]byte, 0, 1024),
@@ -106,7 +106,7 @@ func (t *Marker) markTable(ctx context.Context, tableName, userID string, db *bb
 			return err
 		}
 
-		empty, modified, err = markforDelete(ctx, tableName, markerWriter, chunkIt, newSeriesCleaner(bucket, schemaCfg, tableName), t.expiration, chunkRewriter)
+		empty, modified, err = markforDelete(ctx, ExtractIntervalFromTableName(tableName, schemaCfg.IndexTables), markerWriter, chunkIt, newSeriesCleaner(bucket, schemaCfg, tableName), t.expiration, chunkRewriter)
 		if err != nil {
 			return err
 		}
@@ -131,10 +131,9 @@ func (t *Marker) markTable(ctx context.Context, tableName, userID string, db *bb
 	return empty, modified, nil
 }
 
-func markforDelete(ctx context.Context, tableName string, marker MarkerStorageWriter, chunkIt ChunkEntryIterator, seriesCleaner SeriesCleaner, expiration ExpirationChecker, chunkRewriter *chunkRewriter) (bool, bool, error) {
+// markforDelete marks the expired chunks of a table. tableInterval is the interval for which the table is expected to have the chunks indexed.
+func markforDelete(ctx context.Context, tableInterval model.Interval, marker MarkerStorageWriter, chunkIt ChunkEntryIterator, seriesCleaner SeriesCleaner, expiration ExpirationChecker, chunkRewriter *chunkRewriter) (bool, bool, error) {
 	seriesMap := newUserSeriesMap()
-	// tableInterval holds the interval for which the table is expected to have the chunks indexed
-	tableInterval := ExtractIntervalFromTableName(tableName)
 	empty := true
 	modified := false
 	now := model.Now()
@@ -215,7 +215,7 @@ func Test_EmptyTable(t *testing.T) {
 	err := tables[0].DB.Update(func(tx *bbolt.Tx) error {
 		it, err := NewChunkIndexIterator(tx.Bucket(local.IndexBucketName), schema.config)
 		require.NoError(t, err)
-		empty, _, err := markforDelete(context.Background(), tables[0].name, noopWriter{}, it, noopCleaner{},
+		empty, _, err := markforDelete(context.Background(), ExtractIntervalFromTableName(tables[0].name, schema.config.IndexTables), noopWriter{}, it, noopCleaner{},
 			NewExpirationChecker(&fakeLimits{perTenant: map[string]retentionLimit{"1": {retentionPeriod: 0}, "2": {retentionPeriod: 0}}}), nil)
 		require.NoError(t, err)
 		require.True(t, empty)
@@ -233,7 +233,7 @@ func Test_EmptyTable(t *testing.T) {
 
 		it, err := NewChunkIndexIterator(bucket, schema.config)
 		require.NoError(t, err)
-		_, _, err = markforDelete(context.Background(), tables[0].name, noopWriter{}, it, noopCleaner{},
+		_, _, err = markforDelete(context.Background(), ExtractIntervalFromTableName(tables[0].name, schema.config.IndexTables), noopWriter{}, it, noopCleaner{},
 			NewExpirationChecker(&fakeLimits{}), nil)
 		require.Equal(t, err, errNoChunksFound)
 		return nil
@@ -476,7 +476,7 @@ func TestMarkForDelete_SeriesCleanup(t *testing.T) {
 	now := model.Now()
 	schema := allSchemas[2]
 	userID := "1"
-	todaysTableInterval := ExtractIntervalFromTableName(schema.config.IndexTables.TableFor(now))
+	todaysTableInterval := ExtractIntervalFromTableName(schema.config.IndexTables.TableFor(now), schema.config.IndexTables)
 
 	for _, tc := range []struct {
 		name                  string
@@ -679,7 +679,7 @@ func TestMarkForDelete_SeriesCleanup(t *testing.T) {
 
 					cr, err := newChunkRewriter(chunkClient, schema.config, table.name, tx.Bucket(local.IndexBucketName))
 					require.NoError(t, err)
-					empty, isModified, err := markforDelete(context.Background(), table.name, noopWriter{}, it, seriesCleanRecorder,
+					empty, isModified, err := markforDelete(context.Background(), ExtractIntervalFromTableName(table.name, schema.config.IndexTables), noopWriter{}, it, seriesCleanRecorder,
 						expirationChecker, cr)
 					require.NoError(t, err)
 					require.Equal(t, tc.expectedEmpty[i], empty)
@@ -700,7 +700,7 @@ func TestMarkForDelete_DropChunkFromIndex(t *testing.T) {
 	defer cm.Unregister()
 	store := newTestStore(t, cm)
 	now := model.Now()
-	todaysTableInterval := ExtractIntervalFromTableName(schema.config.IndexTables.TableFor(now))
+	todaysTableInterval := ExtractIntervalFromTableName(schema.config.IndexTables.TableFor(now), schema.config.IndexTables)
 	retentionPeriod := now.Sub(todaysTableInterval.Start) / 2
 
 	// chunks in retention
@@ -725,7 +725,7 @@ func TestMarkForDelete_DropChunkFromIndex(t *testing.T) {
 		err := table.DB.Update(func(tx *bbolt.Tx) error {
 			it, err := NewChunkIndexIterator(tx.Bucket(local.IndexBucketName), schema.config)
 			require.NoError(t, err)
-			empty, _, err := markforDelete(context.Background(), table.name, noopWriter{}, it, noopCleaner{},
+			empty, _, err := markforDelete(context.Background(), ExtractIntervalFromTableName(table.name, schema.config.IndexTables), noopWriter{}, it, noopCleaner{},
 				NewExpirationChecker(fakeLimits{perTenant: map[string]retentionLimit{"1": {retentionPeriod: retentionPeriod}}}), nil)
 			require.NoError(t, err)
 			if i == 7 {
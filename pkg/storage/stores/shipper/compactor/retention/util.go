@@ -4,11 +4,12 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strconv"
-	"time"
 	"unsafe"
 
 	"github.com/prometheus/common/model"
+
+	"github.com/pao214/loki/pkg/storage/chunk"
+	shipper_util "github.com/pao214/loki/pkg/storage/stores/shipper/util"
 )
 
 // unsafeGetString is like yolostring but with a meaningful name
@@ -41,19 +42,20 @@ func copyFile(src, dst string) (int64, error) {
 	return nBytes, err
 }
 
-// ExtractIntervalFromTableName gives back the time interval for which the table is expected to hold the chunks index.
-func ExtractIntervalFromTableName(tableName string) model.Interval {
+// ExtractIntervalFromTableName gives back the time interval for which the table is expected to hold the chunks index,
+// given the index tables config of the schema period the table belongs to.
+func ExtractIntervalFromTableName(tableName string, cfg chunk.PeriodicTableConfig) model.Interval {
 	interval := model.Interval{
 		Start: 0,
 		End:   model.Now(),
 	}
-	tableNumber, err := strconv.ParseInt(tableName[len(tableName)-5:], 10, 64)
+	start, end, err := shipper_util.ParseTableName(tableName, cfg)
 	if err != nil {
 		return interval
 	}
 
-	interval.Start = model.TimeFromUnix(tableNumber * 86400)
-	// subtract a millisecond here so that interval only covers a single table since adding 24 hours ends up covering the start time of next table as well.
-	interval.End = interval.Start.Add(24*time.Hour) - 1
+	interval.Start = model.TimeFromUnixNano(start.UnixNano())
+	// subtract a millisecond here so that interval only covers a single table since the end is the start time of next table.
+	interval.End = model.TimeFromUnixNano(end.UnixNano()) - 1
 	return interval
 }
@@ -311,34 +311,53 @@ func TestExtractIntervalFromTableName(t *testing.T) {
 		Prefix: "dummy",
 		Period: 24 * time.Hour,
 	}
+	weeklyTableConfig := chunk.PeriodicTableConfig{
+		Prefix: "dummy",
+		Period: 7 * 24 * time.Hour,
+	}
 
 	const millisecondsInDay = model.Time(24 * time.Hour / time.Millisecond)
+	const millisecondsInWeek = 7 * millisecondsInDay
 
-	calculateInterval := func(tm model.Time) (m model.Interval) {
-		m.Start = tm - tm%millisecondsInDay
-		m.End = m.Start + millisecondsInDay - 1
+	calculateInterval := func(tm model.Time, period model.Time) (m model.Interval) {
+		m.Start = tm - tm%period
+		m.End = m.Start + period - 1
 		return
 	}
 
 	for i, tc := range []struct {
 		tableName        string
+		cfg              chunk.PeriodicTableConfig
 		expectedInterval model.Interval
 	}{
 		{
 			tableName:        periodicTableConfig.TableFor(model.Now()),
-			expectedInterval: calculateInterval(model.Now()),
+			cfg:              periodicTableConfig,
+			expectedInterval: calculateInterval(model.Now(), millisecondsInDay),
 		},
 		{
 			tableName:        periodicTableConfig.TableFor(model.Now().Add(-24 * time.Hour)),
-			expectedInterval: calculateInterval(model.Now().Add(-24 * time.Hour)),
+			cfg:              periodicTableConfig,
+			expectedInterval: calculateInterval(model.Now().Add(-24*time.Hour), millisecondsInDay),
 		},
 		{
 			tableName:        periodicTableConfig.TableFor(model.Now().Add(-24 * time.Hour).Add(time.Minute)),
-			expectedInterval: calculateInterval(model.Now().Add(-24 * time.Hour).Add(time.Minute)),
+			cfg:              periodicTableConfig,
+			expectedInterval: calculateInterval(model.Now().Add(-24*time.Hour).Add(time.Minute), millisecondsInDay),
+		},
+		{
+			tableName:        weeklyTableConfig.TableFor(model.Now()),
+			cfg:              weeklyTableConfig,
+			expectedInterval: calculateInterval(model.Now(), millisecondsInWeek),
 		},
 	} {
 		t.Run(fmt.Sprint(i), func(t *testing.T) {
-			require.Equal(t, tc.expectedInterval, ExtractIntervalFromTableName(tc.tableName))
+			require.Equal(t, tc.expectedInterval, ExtractIntervalFromTableName(tc.tableName, tc.cfg))
 		})
 	}
+
+	t.Run("table of another config", func(t *testing.T) {
+		interval := ExtractIntervalFromTableName("other_1", periodicTableConfig)
+		require.Equal(t, model.Time(0), interval.Start)
+	})
 }
@@ -85,6 +85,7 @@ type table struct {
 	indexStorageClient storage.Client
 	tableMarker        retention.TableMarker
 	expirationChecker  tableExpirationChecker
+	tableInterval      model.Interval
 
 	baseUserIndexSet, baseCommonIndexSet storage.IndexSet
 
@@ -98,8 +99,9 @@ type table struct {
 	ctx context.Context
 }
 
+// newTable creates a table to compact, tableInterval is the interval it's expected to hold the chunks index of.
 func newTable(ctx context.Context, workingDirectory string, indexStorageClient storage.Client,
-	tableMarker retention.TableMarker, expirationChecker tableExpirationChecker) (*table, error) {
+	tableMarker retention.TableMarker, expirationChecker tableExpirationChecker, tableInterval model.Interval) (*table, error) {
 	err := chunk_util.EnsureDirectory(workingDirectory)
 	if err != nil {
 		return nil, err
@@ -112,6 +114,7 @@ func newTable(ctx context.Context, workingDirectory string, indexStorageClient s
 		indexStorageClient: indexStorageClient,
 		tableMarker:        tableMarker,
 		expirationChecker:  expirationChecker,
+		tableInterval:      tableInterval,
 		indexSets:          map[string]*indexSet{},
 		baseUserIndexSet:   storage.NewIndexSet(indexStorageClient, true),
 		baseCommonIndexSet: storage.NewIndexSet(indexStorageClient, false),
@@ -255,10 +258,9 @@ func (t *table) done() error {
 
 // applyRetention applies retention on the index sets
 func (t *table) applyRetention() error {
-	tableInterval := retention.ExtractIntervalFromTableName(t.name)
 	// call runRetention on the already initialized index sets which may have expired chunks
 	for userID, is := range t.indexSets {
-		if !t.expirationChecker.IntervalMayHaveExpiredChunks(tableInterval, userID) {
+		if !t.expirationChecker.IntervalMayHaveExpiredChunks(t.tableInterval, userID) {
 			continue
 		}
 		err := is.runRetention(t.tableMarker)
@@ -272,7 +274,7 @@ func (t *table) applyRetention() error {
 		if _, ok := t.indexSets[userID]; ok {
 			continue
 		}
-		if !t.expirationChecker.IntervalMayHaveExpiredChunks(tableInterval, userID) {
+		if !t.expirationChecker.IntervalMayHaveExpiredChunks(t.tableInterval, userID) {
 			continue
 		}
 
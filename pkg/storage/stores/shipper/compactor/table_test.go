@@ -207,7 +207,7 @@ func TestTable_Compaction(t *testing.T) {
 					require.NoError(t, err)
 
 					table, err := newTable(context.Background(), tableWorkingDirectory, storage.NewIndexStorageClient(objectClient, ""),
-						nil, nil)
+						nil, nil, model.Interval{})
 					require.NoError(t, err)
 
 					require.NoError(t, table.compact(false))
@@ -256,7 +256,7 @@ func TestTable_Compaction(t *testing.T) {
 
 					// running compaction again should not do anything.
 					table, err = newTable(context.Background(), tableWorkingDirectory, storage.NewIndexStorageClient(objectClient, ""),
-						nil, nil)
+						nil, nil, model.Interval{})
 					require.NoError(t, err)
 
 					require.NoError(t, table.compact(false))
@@ -371,7 +371,7 @@ func TestTable_CompactionRetention(t *testing.T) {
 				table, err := newTable(context.Background(), tableWorkingDirectory, storage.NewIndexStorageClient(objectClient, ""),
 					tt.tableMarker, IntervalMayHaveExpiredChunksFunc(func(interval model.Interval, userID string) bool {
 						return true
-					}))
+					}), model.Interval{})
 				require.NoError(t, err)
 
 				require.NoError(t, table.compact(true))
@@ -448,7 +448,7 @@ func TestTable_CompactionFailure(t *testing.T) {
 	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: objectStoragePath})
 	require.NoError(t, err)
 
-	table, err := newTable(context.Background(), tableWorkingDirectory, storage.NewIndexStorageClient(objectClient, ""), nil, nil)
+	table, err := newTable(context.Background(), tableWorkingDirectory, storage.NewIndexStorageClient(objectClient, ""), nil, nil, model.Interval{})
 	require.NoError(t, err)
 
 	// compaction should fail due to a non-boltdb file.
@@ -465,7 +465,7 @@ func TestTable_CompactionFailure(t *testing.T) {
 	// remove the non-boltdb file and ensure that compaction succeeds now.
 	require.NoError(t, os.Remove(filepath.Join(tablePathInStorage, "fail.txt")))
 
-	table, err = newTable(context.Background(), tableWorkingDirectory, storage.NewIndexStorageClient(objectClient, ""), nil, nil)
+	table, err = newTable(context.Background(), tableWorkingDirectory, storage.NewIndexStorageClient(objectClient, ""), nil, nil, model.Interval{})
 	require.NoError(t, err)
 	require.NoError(t, table.compact(false))
 
@@ -675,7 +675,7 @@ func TestTable_RecreateCompactedDB(t *testing.T) {
 			table, err := newTable(context.Background(), tableWorkingDirectory, storage.NewIndexStorageClient(objectClient, ""),
 				tt.tableMarker, IntervalMayHaveExpiredChunksFunc(func(interval model.Interval, userID string) bool {
 					return true
-				}))
+				}), model.Interval{})
 			require.NoError(t, err)
 
 			require.NoError(t, table.compact(true))
@@ -700,7 +700,7 @@ func TestTable_RecreateCompactedDB(t *testing.T) {
 				table, err := newTable(context.Background(), tableWorkingDirectory, storage.NewIndexStorageClient(objectClient, ""),
 					tt.tableMarker, IntervalMayHaveExpiredChunksFunc(func(interval model.Interval, userID string) bool {
 						return true
-					}))
+					}), model.Interval{})
 				require.NoError(t, err)
 
 				require.NoError(t, table.compact(true))
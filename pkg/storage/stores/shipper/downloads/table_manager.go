@@ -5,8 +5,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
-	"regexp"
-	"strconv"
 	"sync"
 	"time"
 
@@ -37,6 +35,8 @@ type Config struct {
 	CacheTTL          time.Duration
 	QueryReadyNumDays int
 	Limits            Limits
+	// SchemaConfig holds the index tables config of the tables to keep ready for querying.
+	SchemaConfig chunk.SchemaConfig
 }
 
 type TableManager struct {
@@ -269,21 +269,17 @@ func (tm *TableManager) ensureQueryReadiness(ctx context.Context) error {
 		return err
 	}
 
-	// regex for finding daily tables which have a 5 digit number at the end.
-	re, err := regexp.Compile(`.+[0-9]{5}$`)
-	if err != nil {
-		return err
-	}
-
 	for _, tableName := range tables {
-		if !re.MatchString(tableName) {
+		periodCfg, ok := util.SchemaPeriodForTable(tm.cfg.SchemaConfig.Configs, tableName)
+		if !ok {
 			continue
 		}
-
-		tableNumber, err := strconv.ParseInt(tableName[len(tableName)-5:], 10, 64)
+		_, periodEnd, err := util.ParseTableName(tableName, periodCfg.IndexTables)
 		if err != nil {
 			return err
 		}
+		// query readiness is in days, a table is numbered after the last day it covers.
+		tableNumber := (periodEnd.Unix() - 1) / int64(durationDay/time.Second)
 
 		// continue if the table is not within query readiness
 		if activeTableNumber-tableNumber > int64(largestQueryReadinessNum) {
@@ -123,6 +123,11 @@ func TestTableManager_ensureQueryReadiness(t *testing.T) {
 	cfg := Config{
 		SyncInterval: time.Hour,
 		CacheTTL:     time.Hour,
+		SchemaConfig: chunk.SchemaConfig{
+			Configs: []chunk.PeriodConfig{{
+				IndexTables: chunk.PeriodicTableConfig{Prefix: "table_", Period: 24 * time.Hour},
+			}},
+		},
 	}
 
 	tableManager := &TableManager{
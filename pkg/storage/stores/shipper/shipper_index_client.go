@@ -63,6 +63,7 @@ type Config struct {
 	QueryReadyNumDays        int                      `yaml:"query_ready_num_days"`
 	IndexGatewayClientConfig IndexGatewayClientConfig `yaml:"index_gateway_client"`
 	BuildPerTenantIndex      bool                     `yaml:"build_per_tenant_index"`
+	TableNameRegex           string                   `yaml:"table_name_regex"`
 	IngesterName             string                   `yaml:"-"`
 	Mode                     int                      `yaml:"-"`
 	IngesterDBRetainPeriod   time.Duration            `yaml:"-"`
@@ -80,14 +81,19 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.ResyncInterval, "boltdb.shipper.resync-interval", 5*time.Minute, "Resync downloaded files with the storage")
 	f.IntVar(&cfg.QueryReadyNumDays, "boltdb.shipper.query-ready-num-days", 0, "Number of days of common index to be kept downloaded for queries. For per tenant index query readiness, use limits overrides config.")
 	f.BoolVar(&cfg.BuildPerTenantIndex, "boltdb.shipper.build-per-tenant-index", false, "Build per tenant index files")
+	f.StringVar(&cfg.TableNameRegex, "boltdb.shipper.table-name-regex", "", "Regex matching the names of the tables to list from the shared store, e.g. index_\\d+. Useful to ignore other index generations kept in the same bucket. All tables are listed when empty.")
 }
 
 func (cfg *Config) Validate() error {
+	if _, err := compileTableNameRegex(cfg.TableNameRegex); err != nil {
+		return err
+	}
 	return shipper_util.ValidateSharedStoreKeyPrefix(cfg.SharedStoreKeyPrefix)
 }
 
 type Shipper struct {
 	cfg               Config
+	schemaCfg         chunk.SchemaConfig
 	boltDBIndexClient boltDBIndexClient
 	uploadsManager    *uploads.TableManager
 	downloadsManager  *downloads.TableManager
@@ -97,10 +103,11 @@ type Shipper struct {
 }
 
 // NewShipper creates a shipper for syncing local objects with a store
-func NewShipper(cfg Config, storageClient chunk.ObjectClient, limits downloads.Limits, registerer prometheus.Registerer) (chunk.IndexClient, error) {
+func NewShipper(cfg Config, schemaCfg chunk.SchemaConfig, storageClient chunk.ObjectClient, limits downloads.Limits, registerer prometheus.Registerer) (chunk.IndexClient, error) {
 	shipper := Shipper{
-		cfg:     cfg,
-		metrics: newMetrics(registerer),
+		cfg:       cfg,
+		schemaCfg: schemaCfg,
+		metrics:   newMetrics(registerer),
 	}
 
 	err := shipper.init(storageClient, limits, registerer)
@@ -157,6 +164,7 @@ func (s *Shipper) init(storageClient chunk.ObjectClient, limits downloads.Limits
 			CacheTTL:          s.cfg.CacheTTL,
 			QueryReadyNumDays: s.cfg.QueryReadyNumDays,
 			Limits:            limits,
+			SchemaConfig:      s.schemaCfg,
 		}
 		downloadsManager, err := downloads.NewTableManager(cfg, s.boltDBIndexClient, indexStorageClient, registerer)
 		if err != nil {
@@ -49,7 +49,7 @@ type IndexFile struct {
 }
 
 func NewIndexStorageClient(origObjectClient chunk.ObjectClient, storagePrefix string) Client {
-	objectClient := newPrefixedObjectClient(origObjectClient, storagePrefix)
+	objectClient := newMissingTolerantObjectClient(newPrefixedObjectClient(origObjectClient, storagePrefix))
 	if _, ok := origObjectClient.(*local.FSObjectClient); !ok {
 		objectClient = newCachedObjectClient(objectClient)
 	}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/pao214/loki/pkg/storage/chunk"
+	util_log "github.com/pao214/loki/pkg/util/log"
+)
+
+var listSkippedMissing = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: "loki",
+	Name:      "shipper_list_skipped_missing_total",
+	Help:      "Total number of objects skipped by listings because they were deleted mid-scan.",
+})
+
+// missingTolerantObjectClient makes List resilient to objects being deleted while they are being listed,
+// e.g. by retention or compaction. When the downstream client implements chunk.MissingTolerantLister,
+// the objects which vanished mid-scan are skipped and counted, and the listing carries on.
+type missingTolerantObjectClient struct {
+	chunk.ObjectClient
+}
+
+func newMissingTolerantObjectClient(downstreamClient chunk.ObjectClient) chunk.ObjectClient {
+	return missingTolerantObjectClient{ObjectClient: downstreamClient}
+}
+
+func (c missingTolerantObjectClient) List(ctx context.Context, prefix, delimiter string) ([]chunk.StorageObject, []chunk.StorageCommonPrefix, error) {
+	lister, ok := c.ObjectClient.(chunk.MissingTolerantLister)
+	if !ok {
+		return c.ObjectClient.List(ctx, prefix, delimiter)
+	}
+
+	return lister.ListSkippingMissing(ctx, prefix, delimiter, func(key string) {
+		listSkippedMissing.Inc()
+		level.Info(util_log.Logger).Log("msg", "skipping object deleted mid-scan", "prefix", prefix, "key", key)
+	})
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pao214/loki/pkg/storage/chunk"
+)
+
+// deletingObjectClient deletes objects while they are being listed, reporting them as missing
+// like an object store does when an object it was about to return vanished.
+type deletingObjectClient struct {
+	chunk.ObjectClient
+	objects      []string
+	deleteOnList []string
+}
+
+func (d *deletingObjectClient) List(_ context.Context, _, _ string) ([]chunk.StorageObject, []chunk.StorageCommonPrefix, error) {
+	storageObjects := make([]chunk.StorageObject, 0, len(d.objects))
+	for _, object := range d.objects {
+		storageObjects = append(storageObjects, chunk.StorageObject{Key: object})
+	}
+	return storageObjects, []chunk.StorageCommonPrefix{}, nil
+}
+
+func (d *deletingObjectClient) ListSkippingMissing(_ context.Context, _, _ string, onMissing func(key string)) ([]chunk.StorageObject, []chunk.StorageCommonPrefix, error) {
+	storageObjects := make([]chunk.StorageObject, 0, len(d.objects))
+	for _, object := range d.objects {
+		if d.deleted(object) {
+			onMissing(object)
+			continue
+		}
+		storageObjects = append(storageObjects, chunk.StorageObject{Key: object})
+	}
+	return storageObjects, []chunk.StorageCommonPrefix{}, nil
+}
+
+func (d *deletingObjectClient) deleted(object string) bool {
+	for _, toDelete := range d.deleteOnList {
+		if object == toDelete {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *deletingObjectClient) Stop() {}
+
+func TestMissingTolerantObjectClient(t *testing.T) {
+	t.Run("object deleted mid-scan", func(t *testing.T) {
+		objectClient := &deletingObjectClient{
+			objects:      []string{"index/table1/db1.gz", "index/table1/db2.gz", "index/table1/user1/db1.gz"},
+			deleteOnList: []string{"index/table1/db2.gz"},
+		}
+		skippedBefore := testutil.ToFloat64(listSkippedMissing)
+
+		indexStorageClient := NewIndexStorageClient(objectClient, "index/")
+		files, userIDs, err := indexStorageClient.ListFiles(context.Background(), "table1")
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		require.Equal(t, "db1.gz", files[0].Name)
+		require.Equal(t, []string{"user1"}, userIDs)
+
+		require.Equal(t, 1.0, testutil.ToFloat64(listSkippedMissing)-skippedBefore)
+	})
+}
@@ -27,6 +27,23 @@ func (p prefixedObjectClient) GetObject(ctx context.Context, objectKey string) (
 
 func (p prefixedObjectClient) List(ctx context.Context, prefix, delimiter string) ([]chunk.StorageObject, []chunk.StorageCommonPrefix, error) {
 	objects, commonPrefixes, err := p.downstreamClient.List(ctx, p.prefix+prefix, delimiter)
+	return p.trimPrefix(objects, commonPrefixes, err)
+}
+
+// ListSkippingMissing implements chunk.MissingTolerantLister, listing like List if the downstream client doesn't.
+func (p prefixedObjectClient) ListSkippingMissing(ctx context.Context, prefix, delimiter string, onMissing func(key string)) ([]chunk.StorageObject, []chunk.StorageCommonPrefix, error) {
+	lister, ok := p.downstreamClient.(chunk.MissingTolerantLister)
+	if !ok {
+		return p.List(ctx, prefix, delimiter)
+	}
+
+	objects, commonPrefixes, err := lister.ListSkippingMissing(ctx, p.prefix+prefix, delimiter, func(key string) {
+		onMissing(strings.TrimPrefix(key, p.prefix))
+	})
+	return p.trimPrefix(objects, commonPrefixes, err)
+}
+
+func (p prefixedObjectClient) trimPrefix(objects []chunk.StorageObject, commonPrefixes []chunk.StorageCommonPrefix, err error) ([]chunk.StorageObject, []chunk.StorageCommonPrefix, error) {
 	if err != nil {
 		return nil, nil, err
 	}
@@ -2,22 +2,64 @@ package shipper
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/grafana/dskit/concurrency"
 
 	"github.com/pao214/loki/pkg/storage/stores/shipper/storage"
 
 	"github.com/pao214/loki/pkg/storage/chunk"
 )
 
+// maxDeleteTablesConcurrency is the number of tables deleted in parallel by DeleteTables.
+const maxDeleteTablesConcurrency = 10
+
 type boltDBShipperTableClient struct {
 	indexStorageClient storage.Client
+	tableNameRegex     *regexp.Regexp
 }
 
-func NewBoltDBShipperTableClient(objectClient chunk.ObjectClient, storageKeyPrefix string) chunk.TableClient {
-	return &boltDBShipperTableClient{storage.NewIndexStorageClient(objectClient, storageKeyPrefix)}
+// NewBoltDBShipperTableClient creates a table client for the boltdb files stored under storageKeyPrefix.
+// When tableNameRegex is not empty, only the tables whose whole name matches it are listed.
+func NewBoltDBShipperTableClient(objectClient chunk.ObjectClient, storageKeyPrefix, tableNameRegex string) (chunk.TableClient, error) {
+	re, err := compileTableNameRegex(tableNameRegex)
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltDBShipperTableClient{
+		indexStorageClient: storage.NewIndexStorageClient(objectClient, storageKeyPrefix),
+		tableNameRegex:     re,
+	}, nil
+}
+
+// compileTableNameRegex compiles expr anchored to match whole table names. It returns nil for an empty expr.
+func compileTableNameRegex(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid table name regex %q: %w", expr, err)
+	}
+	return re, nil
 }
 
 func (b *boltDBShipperTableClient) ListTables(ctx context.Context) ([]string, error) {
-	return b.indexStorageClient.ListTables(ctx)
+	tables, err := b.indexStorageClient.ListTables(ctx)
+	if err != nil || b.tableNameRegex == nil {
+		return tables, err
+	}
+
+	filtered := tables[:0]
+	for _, table := range tables {
+		if b.tableNameRegex.MatchString(table) {
+			filtered = append(filtered, table)
+		}
+	}
+	return filtered, nil
 }
 
 func (b *boltDBShipperTableClient) CreateTable(ctx context.Context, desc chunk.TableDesc) error {
@@ -44,6 +86,17 @@ func (b *boltDBShipperTableClient) DeleteTable(ctx context.Context, tableName st
 	return nil
 }
 
+// DeleteTables deletes the given tables in parallel. A failure to delete a table doesn't stop the
+// deletion of the others, the returned error combines the failure of every table.
+func (b *boltDBShipperTableClient) DeleteTables(ctx context.Context, tableNames []string) error {
+	return concurrency.ForEachUser(ctx, tableNames, maxDeleteTablesConcurrency, func(ctx context.Context, tableName string) error {
+		if err := b.DeleteTable(ctx, tableName); err != nil {
+			return fmt.Errorf("failed to delete table %s: %w", tableName, err)
+		}
+		return nil
+	})
+}
+
 func (b *boltDBShipperTableClient) DescribeTable(ctx context.Context, name string) (desc chunk.TableDesc, isActive bool, err error) {
 	return chunk.TableDesc{
 		Name: name,
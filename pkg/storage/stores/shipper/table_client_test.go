@@ -3,7 +3,9 @@ package shipper
 import (
 	"bytes"
 	"context"
+	"errors"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -35,7 +37,8 @@ func TestBoltDBShipperTableClient(t *testing.T) {
 		}
 	}
 
-	tableClient := NewBoltDBShipperTableClient(objectClient, "index/")
+	tableClient, err := NewBoltDBShipperTableClient(objectClient, "index/", "")
+	require.NoError(t, err)
 
 	// check list of tables returns all the folders/tables created above
 	checkExpectedTables(t, tableClient, foldersWithFiles)
@@ -46,6 +49,72 @@ func TestBoltDBShipperTableClient(t *testing.T) {
 
 	delete(foldersWithFiles, "table1")
 	checkExpectedTables(t, tableClient, foldersWithFiles)
+
+	// delete a couple of tables at once, only table4 must survive
+	for _, folder := range []string{"table4", "table5"} {
+		err := objectClient.PutObject(context.Background(), path.Join("index", folder, "file7"), bytes.NewReader([]byte{}))
+		require.NoError(t, err)
+	}
+	err = tableClient.(*boltDBShipperTableClient).DeleteTables(context.Background(), []string{"table2", "table3", "table5"})
+	require.NoError(t, err)
+
+	checkExpectedTables(t, tableClient, map[string][]string{"table4": {"file7"}})
+}
+
+type failingDeleteObjectClient struct {
+	chunk.ObjectClient
+	failingTable string
+}
+
+func (c failingDeleteObjectClient) DeleteObject(ctx context.Context, objectKey string) error {
+	if strings.HasPrefix(objectKey, path.Join("index", c.failingTable)+"/") {
+		return errors.New("delete failed")
+	}
+	return c.ObjectClient.DeleteObject(ctx, objectKey)
+}
+
+func TestBoltDBShipperTableClient_DeleteTablesPartialFailure(t *testing.T) {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+
+	for _, folder := range []string{"table1", "table2", "table3"} {
+		err := objectClient.PutObject(context.Background(), path.Join("index", folder, "file1"), bytes.NewReader([]byte{}))
+		require.NoError(t, err)
+	}
+
+	tableClient, err := NewBoltDBShipperTableClient(failingDeleteObjectClient{ObjectClient: objectClient, failingTable: "table1"}, "index/", "")
+	require.NoError(t, err)
+
+	err = tableClient.(*boltDBShipperTableClient).DeleteTables(context.Background(), []string{"table1", "table2", "table3"})
+	require.EqualError(t, err, "failed to delete table table1: delete failed")
+
+	// the failure on table1 must not prevent the others from being deleted.
+	// Check with a new client, the failing one caches the list of tables.
+	tableClient, err = NewBoltDBShipperTableClient(objectClient, "index/", "")
+	require.NoError(t, err)
+	checkExpectedTables(t, tableClient, map[string][]string{"table1": {"file1"}})
+}
+
+func TestBoltDBShipperTableClient_TableNameRegex(t *testing.T) {
+	tempDir := t.TempDir()
+
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: tempDir})
+	require.NoError(t, err)
+
+	for _, folder := range []string{"index_18000", "index_18001", "index_18001_old", "old_index_18000", "index_"} {
+		err := objectClient.PutObject(context.Background(), path.Join("index", folder, "file1"), bytes.NewReader([]byte{}))
+		require.NoError(t, err)
+	}
+
+	tableClient, err := NewBoltDBShipperTableClient(objectClient, "index/", `index_\d+`)
+	require.NoError(t, err)
+	checkExpectedTables(t, tableClient, map[string][]string{
+		"index_18000": nil,
+		"index_18001": nil,
+	})
+
+	_, err = NewBoltDBShipperTableClient(objectClient, "index/", `index_(`)
+	require.Error(t, err)
 }
 
 func checkExpectedTables(t *testing.T, tableClient chunk.TableClient, expectedTables map[string][]string) {
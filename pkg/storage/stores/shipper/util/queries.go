@@ -2,9 +2,13 @@ package util
 
 import (
 	"context"
+	"fmt"
+	"runtime/debug"
 	"sync"
 
 	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 
 	"github.com/pao214/loki/pkg/storage/chunk"
 	util_math "github.com/pao214/loki/pkg/util/math"
@@ -19,16 +23,21 @@ type TableQuerier interface {
 
 // QueriesByTable groups and returns queries by tables.
 func QueriesByTable(queries []chunk.IndexQuery) map[string][]chunk.IndexQuery {
-	queriesByTable := make(map[string][]chunk.IndexQuery)
-	for _, query := range queries {
-		if _, ok := queriesByTable[query.TableName]; !ok {
-			queriesByTable[query.TableName] = []chunk.IndexQuery{}
-		}
+	return QueriesByKey(queries, func(query chunk.IndexQuery) string {
+		return query.TableName
+	})
+}
 
-		queriesByTable[query.TableName] = append(queriesByTable[query.TableName], query)
+// QueriesByKey groups and returns queries by the key returned by keyFn, for instance
+// to group the queries of a logical table sharded across multiple physical tables.
+func QueriesByKey(queries []chunk.IndexQuery, keyFn func(chunk.IndexQuery) string) map[string][]chunk.IndexQuery {
+	queriesByKey := make(map[string][]chunk.IndexQuery)
+	for _, query := range queries {
+		key := keyFn(query)
+		queriesByKey[key] = append(queriesByKey[key], query)
 	}
 
-	return queriesByTable
+	return queriesByKey
 }
 
 func DoParallelQueries(ctx context.Context, tableQuerier TableQuerier, queries []chunk.IndexQuery, callback chunk.QueryPagesCallback) error {
@@ -37,7 +46,7 @@ func DoParallelQueries(ctx context.Context, tableQuerier TableQuerier, queries [
 	}
 	errs := make(chan error)
 
-	id := NewIndexDeduper(callback)
+	id := NewIndexDeduperWithMetrics(callback, indexDeduperMetrics)
 	defer func() {
 		logger := spanlogger.FromContext(ctx)
 		level.Debug(logger).Log("msg", "done processing index queries", "table-name", queries[0].TableName,
@@ -51,7 +60,19 @@ func DoParallelQueries(ctx context.Context, tableQuerier TableQuerier, queries [
 	for i := 0; i < len(queries); i += maxQueriesPerGoroutine {
 		q := queries[i:util_math.Min(i+maxQueriesPerGoroutine, len(queries))]
 		go func(queries []chunk.IndexQuery) {
-			errs <- tableQuerier.MultiQueries(ctx, queries, id.Callback)
+			ctx, finish := spanlogger.ChildContext(ctx, "DoParallelQueries-worker")
+			defer finish()
+
+			var err error
+			defer func() {
+				if r := recover(); r != nil {
+					level.Error(spanlogger.FromContext(ctx)).Log("msg", "panic while running index queries", "table-name", queries[0].TableName,
+						"err", r, "stack", string(debug.Stack()))
+					err = fmt.Errorf("panic while running index queries: %v", r)
+				}
+				errs <- err
+			}()
+			err = tableQuerier.MultiQueries(ctx, queries, id.Callback)
 		}(q)
 	}
 
@@ -66,37 +87,105 @@ func DoParallelQueries(ctx context.Context, tableQuerier TableQuerier, queries [
 	return lastErr
 }
 
+// indexDeduperMetrics are the metrics of the IndexDeduper instances created by DoParallelQueries.
+var indexDeduperMetrics = NewIndexDeduperMetrics(prometheus.DefaultRegisterer)
+
+// IndexDeduperMetrics holds the metrics shared by all the IndexDeduper instances created with it.
+type IndexDeduperMetrics struct {
+	entriesSentTotal       prometheus.Counter
+	duplicatesDroppedTotal prometheus.Counter
+}
+
+func NewIndexDeduperMetrics(r prometheus.Registerer) *IndexDeduperMetrics {
+	return &IndexDeduperMetrics{
+		entriesSentTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "index_deduper_entries_sent_total",
+			Help:      "Total number of unique index entries sent to the callback",
+		}),
+		duplicatesDroppedTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "index_deduper_duplicates_dropped_total",
+			Help:      "Total number of duplicate index entries dropped",
+		}),
+	}
+}
+
 // IndexDeduper should always be used on table level not the whole query level because it just looks at range values which can be repeated across tables
 // Cortex anyways dedupes entries across tables
+// Use NewCrossTableIndexDeduper to dedupe the queries of multiple tables at once.
 type IndexDeduper struct {
 	callback        chunk.QueryPagesCallback
-	seenRangeValues map[string]map[string]struct{}
+	seenRangeValues map[seenKey]map[string]struct{}
 	numEntriesSent  int
 	mtx             sync.RWMutex
+	metrics         *IndexDeduperMetrics
+	crossTable      bool
+}
+
+// seenKey identifies the range values seen for a hash value, the table being only set across tables.
+type seenKey struct {
+	tableName string
+	hashValue string
 }
 
 func NewIndexDeduper(callback chunk.QueryPagesCallback) *IndexDeduper {
 	return &IndexDeduper{
 		callback:        callback,
-		seenRangeValues: map[string]map[string]struct{}{},
+		seenRangeValues: map[seenKey]map[string]struct{}{},
 	}
 }
 
+// NewCrossTableIndexDeduper is like NewIndexDeduper but keys the seen range values by table and hash value,
+// so that it can be used for the queries of multiple tables at once without mixing up their entries.
+func NewCrossTableIndexDeduper(callback chunk.QueryPagesCallback) *IndexDeduper {
+	id := NewIndexDeduper(callback)
+	id.crossTable = true
+	return id
+}
+
+// NewIndexDeduperWithMetrics is like NewIndexDeduper but also records the entries sent and the duplicates dropped in the given metrics.
+func NewIndexDeduperWithMetrics(callback chunk.QueryPagesCallback, metrics *IndexDeduperMetrics) *IndexDeduper {
+	id := NewIndexDeduper(callback)
+	id.metrics = metrics
+	return id
+}
+
 func (i *IndexDeduper) Callback(query chunk.IndexQuery, batch chunk.ReadBatch) bool {
+	var tableName string
+	if i.crossTable {
+		tableName = query.TableName
+	}
+	isSeen := func(hashValue string, rangeValue []byte) bool {
+		return i.isSeen(seenKey{tableName: tableName, hashValue: hashValue}, rangeValue)
+	}
+	if i.metrics != nil {
+		entriesSent, duplicatesDropped := i.metrics.entriesSentTotal, i.metrics.duplicatesDroppedTotal
+		seen := isSeen
+		isSeen = func(hashValue string, rangeValue []byte) bool {
+			if seen(hashValue, rangeValue) {
+				duplicatesDropped.Inc()
+				return true
+			}
+			entriesSent.Inc()
+			return false
+		}
+	}
+
 	return i.callback(query, &filteringBatch{
 		query:     query,
 		ReadBatch: batch,
-		isSeen:    i.isSeen,
+		isSeen:    isSeen,
 	})
 }
 
-func (i *IndexDeduper) isSeen(hashValue string, rangeValue []byte) bool {
+func (i *IndexDeduper) isSeen(key seenKey, rangeValue []byte) bool {
 	i.mtx.RLock()
 
 	// index entries are never modified during query processing so it should be safe to reference a byte slice as a string.
 	rangeValueStr := GetUnsafeString(rangeValue)
 
-	if _, ok := i.seenRangeValues[hashValue][rangeValueStr]; ok {
+	if _, ok := i.seenRangeValues[key][rangeValueStr]; ok {
 		i.mtx.RUnlock()
 		return true
 	}
@@ -107,17 +196,17 @@ func (i *IndexDeduper) isSeen(hashValue string, rangeValue []byte) bool {
 	defer i.mtx.Unlock()
 
 	// re-check if another concurrent call added the values already, if so do not add it again and return true
-	if _, ok := i.seenRangeValues[hashValue][rangeValueStr]; ok {
+	if _, ok := i.seenRangeValues[key][rangeValueStr]; ok {
 		return true
 	}
 
-	// add the hashValue first if missing
-	if _, ok := i.seenRangeValues[hashValue]; !ok {
-		i.seenRangeValues[hashValue] = map[string]struct{}{}
+	// add the key first if missing
+	if _, ok := i.seenRangeValues[key]; !ok {
+		i.seenRangeValues[key] = map[string]struct{}{}
 	}
 
 	// add the rangeValue
-	i.seenRangeValues[hashValue][rangeValueStr] = struct{}{}
+	i.seenRangeValues[key][rangeValueStr] = struct{}{}
 	i.numEntriesSent++
 	return false
 }
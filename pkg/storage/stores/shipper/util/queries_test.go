@@ -3,9 +3,14 @@ package util
 import (
 	"context"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 
 	"github.com/pao214/loki/pkg/storage/chunk"
@@ -74,6 +79,80 @@ func TestDoParallelQueries(t *testing.T) {
 	}
 }
 
+func TestDoParallelQueries_ChildSpans(t *testing.T) {
+	tracer := mocktracer.New()
+	parent := tracer.StartSpan("parent")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+
+	tableQuerier := mockTableQuerier{
+		queries: map[string]chunk.IndexQuery{},
+	}
+	err := DoParallelQueries(ctx, &tableQuerier, buildQueries(maxQueriesPerGoroutine*3), func(query chunk.IndexQuery, batch chunk.ReadBatch) bool {
+		return false
+	})
+	require.NoError(t, err)
+	parent.Finish()
+
+	parentID := parent.Context().(mocktracer.MockSpanContext).SpanID
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 4)
+	for _, sp := range spans {
+		if sp.OperationName == "parent" {
+			continue
+		}
+		require.Equal(t, "DoParallelQueries-worker", sp.OperationName)
+		require.Equal(t, parentID, sp.ParentID)
+	}
+}
+
+type panickingTableQuerier struct{}
+
+func (panickingTableQuerier) MultiQueries(ctx context.Context, queries []chunk.IndexQuery, callback chunk.QueryPagesCallback) error {
+	panic("bad batch")
+}
+
+func TestDoParallelQueries_Panic(t *testing.T) {
+	queries := buildQueries(maxQueriesPerGoroutine * 2)
+
+	err := DoParallelQueries(context.Background(), panickingTableQuerier{}, queries, func(query chunk.IndexQuery, batch chunk.ReadBatch) bool {
+		return false
+	})
+	require.EqualError(t, err, "panic while running index queries: bad batch")
+}
+
+// duplicatingTableQuerier answers every query with the same batch twice.
+type duplicatingTableQuerier struct {
+	rangeValues [][]byte
+}
+
+func (d duplicatingTableQuerier) MultiQueries(_ context.Context, queries []chunk.IndexQuery, callback chunk.QueryPagesCallback) error {
+	for _, query := range queries {
+		for i := 0; i < 2; i++ {
+			callback(query, batch{hashValue: query.HashValue, rangeValues: d.rangeValues})
+		}
+	}
+	return nil
+}
+
+func TestDoParallelQueries_Metrics(t *testing.T) {
+	const table = "do-parallel-queries-metrics"
+	entriesSent, duplicatesDropped := indexDeduperMetrics.entriesSentTotal, indexDeduperMetrics.duplicatesDroppedTotal
+	entriesSentBefore, duplicatesDroppedBefore := testutil.ToFloat64(entriesSent), testutil.ToFloat64(duplicatesDropped)
+
+	queries := []chunk.IndexQuery{{TableName: table, HashValue: "1"}, {TableName: table, HashValue: "2"}}
+	err := DoParallelQueries(context.Background(), duplicatingTableQuerier{rangeValues: [][]byte{[]byte("a"), []byte("b")}}, queries,
+		func(query chunk.IndexQuery, batch chunk.ReadBatch) bool {
+			itr := batch.Iterator()
+			for itr.Next() {
+			}
+			return true
+		})
+	require.NoError(t, err)
+
+	require.Equal(t, float64(4), testutil.ToFloat64(entriesSent)-entriesSentBefore)
+	require.Equal(t, float64(4), testutil.ToFloat64(duplicatesDropped)-duplicatesDroppedBefore)
+}
+
 func buildQueries(n int) []chunk.IndexQuery {
 	queries := make([]chunk.IndexQuery, 0, n)
 	for i := 0; i < n; i++ {
@@ -175,6 +254,32 @@ func TestIndexDeduper(t *testing.T) {
 	}
 }
 
+func TestIndexDeduper_Metrics(t *testing.T) {
+	metrics := NewIndexDeduperMetrics(prometheus.NewRegistry())
+	deduper := NewIndexDeduperWithMetrics(func(query chunk.IndexQuery, readBatch chunk.ReadBatch) bool {
+		itr := readBatch.Iterator()
+		for itr.Next() {
+		}
+		return true
+	}, metrics)
+
+	for _, b := range []batch{
+		{
+			hashValue:   "1",
+			rangeValues: [][]byte{[]byte("a"), []byte("b"), []byte("c")},
+		},
+		{
+			hashValue:   "1",
+			rangeValues: [][]byte{[]byte("a"), []byte("b"), []byte("d")},
+		},
+	} {
+		deduper.Callback(chunk.IndexQuery{TableName: "table1", HashValue: b.hashValue}, b)
+	}
+
+	require.Equal(t, float64(4), testutil.ToFloat64(metrics.entriesSentTotal))
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics.duplicatesDroppedTotal))
+}
+
 type batch struct {
 	hashValue   string
 	rangeValues [][]byte
@@ -207,3 +312,79 @@ func (b batchIterator) RangeValue() []byte {
 func (b batchIterator) Value() []byte {
 	panic("implement me")
 }
+
+func TestQueriesByKey(t *testing.T) {
+	queries := []chunk.IndexQuery{
+		{TableName: "index_1", HashValue: "a"},
+		{TableName: "index_1_shard_0", HashValue: "b"},
+		{TableName: "index_1_shard_1", HashValue: "c"},
+		{TableName: "index_2_shard_0", HashValue: "d"},
+	}
+
+	require.Equal(t, map[string][]chunk.IndexQuery{
+		"index_1":         {queries[0]},
+		"index_1_shard_0": {queries[1]},
+		"index_1_shard_1": {queries[2]},
+		"index_2_shard_0": {queries[3]},
+	}, QueriesByTable(queries))
+
+	// collapses the shards of each table
+	byLogicalTable := QueriesByKey(queries, func(query chunk.IndexQuery) string {
+		if i := strings.Index(query.TableName, "_shard_"); i >= 0 {
+			return query.TableName[:i]
+		}
+		return query.TableName
+	})
+	require.Equal(t, map[string][]chunk.IndexQuery{
+		"index_1": {queries[0], queries[1], queries[2]},
+		"index_2": {queries[3]},
+	}, byLogicalTable)
+}
+
+func TestCrossTableIndexDeduper(t *testing.T) {
+	queries := []chunk.IndexQuery{
+		{TableName: "index_1", HashValue: "1"},
+		{TableName: "index_2", HashValue: "1"},
+		{TableName: "index_2", HashValue: "1"},
+	}
+	rangeValues := [][]byte{[]byte("a"), []byte("b")}
+
+	for _, tc := range []struct {
+		name           string
+		newDeduper     func(chunk.QueryPagesCallback) *IndexDeduper
+		expectedValues map[string][][]byte
+	}{
+		{
+			name:       "per table deduper",
+			newDeduper: NewIndexDeduper,
+			expectedValues: map[string][][]byte{
+				"index_1": rangeValues,
+			},
+		},
+		{
+			name:       "cross table deduper",
+			newDeduper: NewCrossTableIndexDeduper,
+			expectedValues: map[string][][]byte{
+				"index_1": rangeValues,
+				"index_2": rangeValues,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actualValues := map[string][][]byte{}
+			deduper := tc.newDeduper(func(query chunk.IndexQuery, readBatch chunk.ReadBatch) bool {
+				itr := readBatch.Iterator()
+				for itr.Next() {
+					actualValues[query.TableName] = append(actualValues[query.TableName], itr.RangeValue())
+				}
+				return true
+			})
+
+			for _, query := range queries {
+				deduper.Callback(query, batch{hashValue: query.HashValue, rangeValues: rangeValues})
+			}
+
+			require.Equal(t, tc.expectedValues, actualValues)
+		})
+	}
+}
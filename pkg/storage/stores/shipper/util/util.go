@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -258,3 +259,51 @@ func GetUnsafeBytes(s string) []byte {
 func GetUnsafeString(buf []byte) string {
 	return *((*string)(unsafe.Pointer(&buf)))
 }
+
+// ParseTableName returns the period covered by the periodic table with the given name, i.e. the
+// cfg.Prefix followed by the number of periods since the Unix epoch, as done by cfg.TableFor.
+// periodEnd is exclusive, it's the start of the next period.
+func ParseTableName(name string, cfg chunk.PeriodicTableConfig) (periodStart, periodEnd time.Time, err error) {
+	if cfg.Period <= 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf("table %s: non-periodic table config", name)
+	}
+	if !strings.HasPrefix(name, cfg.Prefix) {
+		return time.Time{}, time.Time{}, fmt.Errorf("table %s: missing prefix %s", name, cfg.Prefix)
+	}
+
+	periodIndex, err := strconv.ParseInt(strings.TrimPrefix(name, cfg.Prefix), 10, 64)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("table %s: invalid period number: %w", name, err)
+	}
+	if periodIndex < 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf("table %s: negative period number", name)
+	}
+
+	periodSecs := int64(cfg.Period / time.Second)
+	periodStart = time.Unix(periodIndex*periodSecs, 0).UTC()
+	return periodStart, periodStart.Add(time.Duration(periodSecs) * time.Second), nil
+}
+
+// SchemaPeriodForTable returns the schema period config the periodic table with the given name belongs to,
+// i.e. the last one with the prefix of the table which starts at or before the period of the table.
+func SchemaPeriodForTable(configs []chunk.PeriodConfig, tableName string) (chunk.PeriodConfig, bool) {
+	var (
+		matched chunk.PeriodConfig
+		found   bool
+	)
+	for _, cfg := range configs {
+		if !strings.HasPrefix(tableName, cfg.IndexTables.Prefix) {
+			continue
+		}
+		start, _, err := ParseTableName(tableName, cfg.IndexTables)
+		if err != nil {
+			continue
+		}
+		if !start.Before(cfg.From.Time.Time()) {
+			matched = cfg
+			found = true
+		}
+	}
+
+	return matched, found
+}
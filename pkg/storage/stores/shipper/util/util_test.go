@@ -6,9 +6,12 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 
+	"github.com/pao214/loki/pkg/storage/chunk"
 	"github.com/pao214/loki/pkg/storage/chunk/local"
 	"github.com/pao214/loki/pkg/storage/chunk/util"
 	"github.com/pao214/loki/pkg/storage/stores/shipper/storage"
@@ -79,3 +82,88 @@ func Test_CompressFile(t *testing.T) {
 
 	require.Equal(t, testData, b)
 }
+
+func TestParseTableName(t *testing.T) {
+	daily := chunk.PeriodicTableConfig{Prefix: "index_", Period: 24 * time.Hour}
+	weekly := chunk.PeriodicTableConfig{Prefix: "index_", Period: 7 * 24 * time.Hour}
+	day := func(n int64) time.Time { return time.Unix(n*86400, 0).UTC() }
+
+	for _, tc := range []struct {
+		name          string
+		tableName     string
+		cfg           chunk.PeriodicTableConfig
+		expectedStart time.Time
+		expectedEnd   time.Time
+		expectedErr   bool
+	}{
+		{
+			name:          "daily",
+			tableName:     "index_19234",
+			cfg:           daily,
+			expectedStart: day(19234),
+			expectedEnd:   day(19235),
+		},
+		{
+			name:          "weekly",
+			tableName:     "index_2747",
+			cfg:           weekly,
+			expectedStart: day(2747 * 7),
+			expectedEnd:   day(2748 * 7),
+		},
+		{
+			name:          "first period",
+			tableName:     "index_0",
+			cfg:           daily,
+			expectedStart: day(0),
+			expectedEnd:   day(1),
+		},
+		{
+			name:          "round trips with TableFor",
+			tableName:     daily.TableFor(model.TimeFromUnix(day(19234).Add(24*time.Hour - time.Second).Unix())),
+			cfg:           daily,
+			expectedStart: day(19234),
+			expectedEnd:   day(19235),
+		},
+		{
+			name:        "wrong prefix",
+			tableName:   "table_19234",
+			cfg:         daily,
+			expectedErr: true,
+		},
+		{
+			name:        "no period number",
+			tableName:   "index_",
+			cfg:         daily,
+			expectedErr: true,
+		},
+		{
+			name:        "invalid period number",
+			tableName:   "index_19234a",
+			cfg:         daily,
+			expectedErr: true,
+		},
+		{
+			name:        "negative period number",
+			tableName:   "index_-1",
+			cfg:         daily,
+			expectedErr: true,
+		},
+		{
+			name:        "non-periodic config",
+			tableName:   "index_19234",
+			cfg:         chunk.PeriodicTableConfig{Prefix: "index_"},
+			expectedErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			start, end, err := ParseTableName(tc.tableName, tc.cfg)
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStart, start)
+			require.Equal(t, tc.expectedEnd, end)
+		})
+	}
+}
@@ -20,3 +20,13 @@ func Overlap(a, b Bounded) bool {
 
 	return aFrom < bThrough && aThrough > bFrom
 }
+
+// inclusiveOverlap is like Overlap, but treats both ends of both bounds as inclusive.
+// Chunk MinTime/MaxTime are the timestamps of their first and last entries, so a chunk
+// ending exactly at a query's start (or starting exactly at its end) still overlaps it.
+func inclusiveOverlap(a, b Bounded) bool {
+	aFrom, aThrough := a.Bounds()
+	bFrom, bThrough := b.Bounds()
+
+	return aFrom <= bThrough && aThrough >= bFrom
+}
@@ -0,0 +1,20 @@
+package tsdb
+
+import (
+	"github.com/pao214/loki/pkg/storage/tsdb/index"
+)
+
+// ChunkFilter reports whether a chunk should be kept in the result of a filtered query.
+type ChunkFilter func(index.ChunkMeta) bool
+
+// AvgEntrySizeAbove returns a ChunkFilter keeping only chunks whose average entry size
+// (KB*1024/Entries) exceeds threshold bytes. It's useful for finding streams with
+// pathologically long lines. Chunks without entries never match.
+func AvgEntrySizeAbove(threshold uint64) ChunkFilter {
+	return func(chk index.ChunkMeta) bool {
+		if chk.Entries == 0 {
+			return false
+		}
+		return uint64(chk.KB)*1024/uint64(chk.Entries) > threshold
+	}
+}
@@ -0,0 +1,81 @@
+package tsdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pao214/loki/pkg/storage/tsdb/index"
+)
+
+func TestAvgEntrySizeAbove(t *testing.T) {
+	idx := BuildIndex(t, []LoadableSeries{
+		{
+			Labels: mustParseLabels(`{foo="bar"}`),
+			Chunks: []index.ChunkMeta{
+				{
+					// 100 bytes per entry
+					MinTime:  0,
+					MaxTime:  3,
+					Checksum: 0,
+					KB:       100,
+					Entries:  1024,
+				},
+				{
+					// 1KB per entry
+					MinTime:  1,
+					MaxTime:  4,
+					Checksum: 1,
+					KB:       10,
+					Entries:  10,
+				},
+			},
+		},
+		{
+			Labels: mustParseLabels(`{foo="bar", bazz="buzz"}`),
+			Chunks: []index.ChunkMeta{
+				{
+					// 2KB per entry
+					MinTime:  1,
+					MaxTime:  10,
+					Checksum: 2,
+					KB:       20,
+					Entries:  10,
+				},
+				{
+					// no entries
+					MinTime:  2,
+					MaxTime:  10,
+					Checksum: 3,
+					KB:       20,
+				},
+			},
+		},
+	})
+
+	refs, err := idx.GetFilteredChunkRefs(context.Background(), "fake", 1, 5, nil, nil, AvgEntrySizeAbove(512), labels.MustNewMatcher(labels.MatchEqual, "foo", "bar"))
+	require.Nil(t, err)
+	require.Equal(t, []ChunkRef{
+		{
+			User:        "fake",
+			Fingerprint: model.Fingerprint(mustParseLabels(`{foo="bar"}`).Hash()),
+			Start:       1,
+			End:         4,
+			Checksum:    1,
+		},
+		{
+			User:        "fake",
+			Fingerprint: model.Fingerprint(mustParseLabels(`{foo="bar", bazz="buzz"}`).Hash()),
+			Start:       1,
+			End:         10,
+			Checksum:    2,
+		},
+	}, refs)
+
+	refs, err = idx.GetFilteredChunkRefs(context.Background(), "fake", 1, 5, nil, nil, AvgEntrySizeAbove(2048), labels.MustNewMatcher(labels.MatchEqual, "foo", "bar"))
+	require.Nil(t, err)
+	require.Empty(t, refs)
+}
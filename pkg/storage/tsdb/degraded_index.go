@@ -0,0 +1,102 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/pao214/loki/pkg/storage/tsdb/index"
+)
+
+type DegradedIndexMetrics struct {
+	indexUnavailableTotal *prometheus.CounterVec
+}
+
+func NewDegradedIndexMetrics(r prometheus.Registerer) *DegradedIndexMetrics {
+	return &DegradedIndexMetrics{
+		indexUnavailableTotal: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "tsdb_index_unavailable_total",
+			Help:      "Total number of TSDB index requests answered with empty results because the index was unavailable.",
+		}, []string{"method"}),
+	}
+}
+
+// DegradedIndex wraps an Index and, when degraded mode is enabled, turns errors
+// from the underlying index into empty results so a partial outage doesn't fail
+// every query. Each swallowed error increments loki_tsdb_index_unavailable_total.
+// When degraded mode is disabled, errors are returned as is.
+type DegradedIndex struct {
+	Index
+	degrade bool
+	metrics *DegradedIndexMetrics
+	logger  log.Logger
+}
+
+func NewDegradedIndex(idx Index, degrade bool, metrics *DegradedIndexMetrics, logger log.Logger) *DegradedIndex {
+	return &DegradedIndex{
+		Index:   idx,
+		degrade: degrade,
+		metrics: metrics,
+		logger:  logger,
+	}
+}
+
+// unavailable reports whether err should be swallowed, recording it if so.
+func (i *DegradedIndex) unavailable(method string, err error) bool {
+	if err == nil || !i.degrade {
+		return false
+	}
+	// never swallow cancellations, the caller isn't waiting on the result anyway
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	i.metrics.indexUnavailableTotal.WithLabelValues(method).Inc()
+	level.Warn(i.logger).Log("msg", "tsdb index unavailable, returning empty result", "method", method, "err", err)
+	return true
+}
+
+func (i *DegradedIndex) GetChunkRefs(ctx context.Context, userID string, from, through model.Time, res []ChunkRef, shard *index.ShardAnnotation, matchers ...*labels.Matcher) ([]ChunkRef, error) {
+	refs, err := i.Index.GetChunkRefs(ctx, userID, from, through, res, shard, matchers...)
+	if i.unavailable("GetChunkRefs", err) {
+		if res == nil {
+			res = ChunkRefsPool.Get()
+		}
+		return res[:0], nil
+	}
+	return refs, err
+}
+
+func (i *DegradedIndex) Series(ctx context.Context, userID string, from, through model.Time, res []Series, shard *index.ShardAnnotation, matchers ...*labels.Matcher) ([]Series, error) {
+	xs, err := i.Index.Series(ctx, userID, from, through, res, shard, matchers...)
+	if i.unavailable("Series", err) {
+		if res == nil {
+			res = SeriesPool.Get()
+		}
+		return res[:0], nil
+	}
+	return xs, err
+}
+
+func (i *DegradedIndex) LabelNames(ctx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([]string, error) {
+	names, err := i.Index.LabelNames(ctx, userID, from, through, matchers...)
+	if i.unavailable("LabelNames", err) {
+		return nil, nil
+	}
+	return names, err
+}
+
+func (i *DegradedIndex) LabelValues(ctx context.Context, userID string, from, through model.Time, name string, matchers ...*labels.Matcher) ([]string, error) {
+	values, err := i.Index.LabelValues(ctx, userID, from, through, name, matchers...)
+	if i.unavailable("LabelValues", err) {
+		return nil, nil
+	}
+	return values, err
+}
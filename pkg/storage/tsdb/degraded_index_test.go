@@ -0,0 +1,115 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pao214/loki/pkg/storage/tsdb/index"
+)
+
+var errIndexUnavailable = errors.New("index unavailable")
+
+type failingIndex struct{}
+
+func (failingIndex) Bounds() (model.Time, model.Time) { return 0, 10 }
+
+func (failingIndex) GetChunkRefs(_ context.Context, _ string, _, _ model.Time, _ []ChunkRef, _ *index.ShardAnnotation, _ ...*labels.Matcher) ([]ChunkRef, error) {
+	return nil, errIndexUnavailable
+}
+
+func (failingIndex) Series(_ context.Context, _ string, _, _ model.Time, _ []Series, _ *index.ShardAnnotation, _ ...*labels.Matcher) ([]Series, error) {
+	return nil, errIndexUnavailable
+}
+
+func (failingIndex) LabelNames(_ context.Context, _ string, _, _ model.Time, _ ...*labels.Matcher) ([]string, error) {
+	return nil, errIndexUnavailable
+}
+
+func (failingIndex) LabelValues(_ context.Context, _ string, _, _ model.Time, _ string, _ ...*labels.Matcher) ([]string, error) {
+	return nil, errIndexUnavailable
+}
+
+// interruptedIndex fails GetChunkRefs with err.
+type interruptedIndex struct {
+	failingIndex
+	err error
+}
+
+func (i interruptedIndex) GetChunkRefs(_ context.Context, _ string, _, _ model.Time, _ []ChunkRef, _ *index.ShardAnnotation, _ ...*labels.Matcher) ([]ChunkRef, error) {
+	return nil, i.err
+}
+
+func TestDegradedIndex(t *testing.T) {
+	ctx := context.Background()
+	matcher := labels.MustNewMatcher(labels.MatchEqual, "foo", "bar")
+
+	t.Run("degraded", func(t *testing.T) {
+		metrics := NewDegradedIndexMetrics(prometheus.NewRegistry())
+		idx := NewDegradedIndex(failingIndex{}, true, metrics, log.NewNopLogger())
+
+		refs, err := idx.GetChunkRefs(ctx, "fake", 0, 10, nil, nil, matcher)
+		require.Nil(t, err)
+		require.Empty(t, refs)
+
+		xs, err := idx.Series(ctx, "fake", 0, 10, nil, nil, matcher)
+		require.Nil(t, err)
+		require.Empty(t, xs)
+
+		names, err := idx.LabelNames(ctx, "fake", 0, 10)
+		require.Nil(t, err)
+		require.Empty(t, names)
+
+		values, err := idx.LabelValues(ctx, "fake", 0, 10, "foo")
+		require.Nil(t, err)
+		require.Empty(t, values)
+
+		for _, method := range []string{"GetChunkRefs", "Series", "LabelNames", "LabelValues"} {
+			require.Equal(t, 1.0, testutil.ToFloat64(metrics.indexUnavailableTotal.WithLabelValues(method)), method)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		metrics := NewDegradedIndexMetrics(prometheus.NewRegistry())
+		idx := NewDegradedIndex(failingIndex{}, false, metrics, log.NewNopLogger())
+
+		_, err := idx.GetChunkRefs(ctx, "fake", 0, 10, nil, nil, matcher)
+		require.Equal(t, errIndexUnavailable, err)
+		require.Equal(t, 0.0, testutil.ToFloat64(metrics.indexUnavailableTotal.WithLabelValues("GetChunkRefs")))
+	})
+
+	t.Run("interrupted", func(t *testing.T) {
+		for _, cause := range []error{context.Canceled, context.DeadlineExceeded} {
+			metrics := NewDegradedIndexMetrics(prometheus.NewRegistry())
+			wrapped := fmt.Errorf("reading postings: %w", cause)
+			idx := NewDegradedIndex(interruptedIndex{err: wrapped}, true, metrics, log.NewNopLogger())
+
+			_, err := idx.GetChunkRefs(ctx, "fake", 0, 10, nil, nil, matcher)
+			require.Equal(t, wrapped, err)
+			require.Equal(t, 0.0, testutil.ToFloat64(metrics.indexUnavailableTotal.WithLabelValues("GetChunkRefs")))
+		}
+	})
+
+	t.Run("healthy", func(t *testing.T) {
+		metrics := NewDegradedIndexMetrics(prometheus.NewRegistry())
+		idx := NewDegradedIndex(BuildIndex(t, []LoadableSeries{
+			{
+				Labels: mustParseLabels(`{foo="bar"}`),
+				Chunks: []index.ChunkMeta{{MinTime: 1, MaxTime: 5, Checksum: 1}},
+			},
+		}), true, metrics, log.NewNopLogger())
+
+		refs, err := idx.GetChunkRefs(ctx, "fake", 0, 10, nil, nil, matcher)
+		require.Nil(t, err)
+		require.Len(t, refs, 1)
+		require.Equal(t, 0.0, testutil.ToFloat64(metrics.indexUnavailableTotal.WithLabelValues("GetChunkRefs")))
+	})
+}
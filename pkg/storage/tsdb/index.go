@@ -2,6 +2,7 @@ package tsdb
 
 import (
 	"context"
+	"sort"
 
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
@@ -30,6 +31,53 @@ func (r ChunkRef) Less(x ChunkRef) bool {
 	return r.End <= x.End
 }
 
+// SortChunkRefs sorts refs by (Start, End), using Fingerprint and Checksum as
+// tiebreakers so identical refs end up adjacent to each other.
+func SortChunkRefs(refs []ChunkRef) {
+	sort.Slice(refs, func(i, j int) bool {
+		a, b := refs[i], refs[j]
+		if a.Start != b.Start {
+			return a.Start < b.Start
+		}
+		if a.End != b.End {
+			return a.End < b.End
+		}
+		if a.Fingerprint != b.Fingerprint {
+			return a.Fingerprint < b.Fingerprint
+		}
+		return a.Checksum < b.Checksum
+	})
+}
+
+// DedupeChunkRefs removes refs with the same Fingerprint, Start, End and Checksum,
+// such as those returned by multiple overlapping indices.
+// refs must already be sorted with SortChunkRefs. It's deduped in place
+// and the resulting subslice is returned.
+func DedupeChunkRefs(refs []ChunkRef) []ChunkRef {
+	if len(refs) == 0 {
+		return refs
+	}
+
+	n := 1
+	for _, ref := range refs[1:] {
+		prior := refs[n-1]
+		if ref.Fingerprint == prior.Fingerprint && ref.Start == prior.Start && ref.End == prior.End && ref.Checksum == prior.Checksum {
+			continue
+		}
+		refs[n] = ref
+		n++
+	}
+	return refs[:n]
+}
+
+// Stats holds aggregate counts over the chunks an index returns for a query.
+type Stats struct {
+	Streams uint64
+	Chunks  uint64
+	KB      uint64
+	Entries uint64
+}
+
 type Index interface {
 	Bounded
 	// GetChunkRefs accepts an optional []ChunkRef argument.
@@ -42,6 +90,7 @@ type Index interface {
 	// the requested shard. If it is nil, TSDB will return all results,
 	// regardless of shard.
 	// Note: any shard used must be a valid factor of two, meaning `0_of_2` and `3_of_4` are fine, but `0_of_3` is not.
+	// Invalid shards are rejected with an error.
 	GetChunkRefs(ctx context.Context, userID string, from, through model.Time, res []ChunkRef, shard *index.ShardAnnotation, matchers ...*labels.Matcher) ([]ChunkRef, error)
 	// Series follows the same semantics regarding the passed slice and shard as GetChunkRefs.
 	Series(ctx context.Context, userID string, from, through model.Time, res []Series, shard *index.ShardAnnotation, matchers ...*labels.Matcher) ([]Series, error)
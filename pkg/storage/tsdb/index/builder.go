@@ -2,6 +2,8 @@ package index
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sort"
 
 	"github.com/prometheus/prometheus/model/labels"
@@ -11,10 +13,36 @@ import (
 // Builder is a helper used to create tsdb indices.
 // It can accept streams in any order and will create the tsdb
 // index appropriately via `Build()`
-// It can even receive multiple writes for the same stream with the caveat
-// that chunks must be added in order and not duplicated
+// It can even receive multiple writes for the same stream, whose chunks are merged,
+// regardless of the order of its labels.
+// Label sets with duplicate label names are rejected by `Build()`
+// Deletes are recorded as tombstones and applied by `Build()`, so the written
+// index never contains the deleted chunks.
 type Builder struct {
-	streams map[string]*stream
+	streams    map[string]*stream
+	tombstones []tombstone
+	// first invalid series added, returned by Build
+	err error
+}
+
+// tombstone marks the chunks of the series matching matchers within [mint, maxt] as deleted.
+type tombstone struct {
+	mint, maxt int64
+	matchers   []*labels.Matcher
+}
+
+// covers reports whether chk is entirely within the deleted window of a series matching the tombstone.
+// Chunks only partially covered still hold data outside of the window and are kept.
+func (t tombstone) covers(ls labels.Labels, chk ChunkMeta) bool {
+	if chk.MinTime < t.mint || chk.MaxTime > t.maxt {
+		return false
+	}
+	for _, m := range t.matchers {
+		if !m.Matches(ls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
 }
 
 type stream struct {
@@ -27,6 +55,18 @@ func NewBuilder() *Builder {
 }
 
 func (b *Builder) AddSeries(ls labels.Labels, chks []ChunkMeta) {
+	// key series by their sorted labels so the same label set is never indexed twice
+	if !sort.IsSorted(ls) {
+		ls = ls.Copy()
+		sort.Sort(ls)
+	}
+	if name, dup := ls.HasDuplicateLabelNames(); dup {
+		if b.err == nil {
+			b.err = fmt.Errorf("series %s has duplicate label name %q", ls, name)
+		}
+		return
+	}
+
 	id := ls.String()
 	s, ok := b.streams[id]
 	if !ok {
@@ -39,7 +79,45 @@ func (b *Builder) AddSeries(ls labels.Labels, chks []ChunkMeta) {
 	s.chunks = append(s.chunks, chks...)
 }
 
+// DeleteSeries records a tombstone for the series matching the matchers within [mint, maxt],
+// regardless of whether they are added before or after it.
+// Their chunks entirely in the window are left out of the built index, as well as the series
+// whose chunks were all deleted. Chunks only partly in the window are kept, as the index can't trim their data.
+func (b *Builder) DeleteSeries(mint, maxt int64, matchers ...*labels.Matcher) {
+	if len(matchers) == 0 {
+		if b.err == nil {
+			b.err = errors.New("at least one matcher is required to delete series")
+		}
+		return
+	}
+	b.tombstones = append(b.tombstones, tombstone{mint: mint, maxt: maxt, matchers: matchers})
+}
+
+// deleted removes the chunks covered by a tombstone from chks in place and returns the resulting subslice.
+func (b *Builder) deleted(ls labels.Labels, chks ChunkMetas) ChunkMetas {
+	if len(b.tombstones) == 0 {
+		return chks
+	}
+
+	n := 0
+Outer:
+	for _, chk := range chks {
+		for _, t := range b.tombstones {
+			if t.covers(ls, chk) {
+				continue Outer
+			}
+		}
+		chks[n] = chk
+		n++
+	}
+	return chks[:n]
+}
+
 func (b *Builder) Build(ctx context.Context, dir string) error {
+	if b.err != nil {
+		return b.err
+	}
+
 	writer, err := NewWriter(ctx, dir)
 	if err != nil {
 		return err
@@ -49,6 +127,11 @@ func (b *Builder) Build(ctx context.Context, dir string) error {
 	// Sort series
 	streams := make([]*stream, 0, len(b.streams))
 	for _, s := range b.streams {
+		n := len(s.chunks)
+		if s.chunks = b.deleted(s.labels, s.chunks); n > 0 && len(s.chunks) == 0 {
+			// every chunk of the series was deleted
+			continue
+		}
 		streams = append(streams, s)
 	}
 	sort.Slice(streams, func(i, j int) bool {
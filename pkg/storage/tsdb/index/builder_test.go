@@ -0,0 +1,104 @@
+package index
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder_DuplicateSeries(t *testing.T) {
+	dir := t.TempDir()
+	b := NewBuilder()
+	b.AddSeries(labels.Labels{{Name: "foo", Value: "bar"}, {Name: "bazz", Value: "buzz"}}, []ChunkMeta{{MinTime: 1, MaxTime: 5, Checksum: 1}})
+	b.AddSeries(labels.FromStrings("foo", "bar", "bazz", "buzz"), []ChunkMeta{{MinTime: 5, MaxTime: 10, Checksum: 2}})
+	b.AddSeries(labels.FromStrings("foo", "bar"), []ChunkMeta{{MinTime: 1, MaxTime: 10, Checksum: 3}})
+	b.AddSeries(labels.FromStrings("foo", "bar"), []ChunkMeta{{MinTime: 1, MaxTime: 10, Checksum: 3}})
+	require.NoError(t, b.Build(context.Background(), dir))
+
+	ir, err := NewFileReader(dir)
+	require.NoError(t, err)
+	defer ir.Close()
+
+	p, err := ir.Postings("foo", nil, "bar")
+	require.NoError(t, err)
+
+	var (
+		ls   labels.Labels
+		chks []ChunkMeta
+		got  = map[string][]ChunkMeta{}
+	)
+	for p.Next() {
+		_, err := ir.Series(p.At(), &ls, &chks)
+		require.NoError(t, err)
+		got[ls.String()] = append([]ChunkMeta(nil), chks...)
+	}
+	require.NoError(t, p.Err())
+
+	// each label set is indexed once with the chunks of all its writes
+	require.Equal(t, map[string][]ChunkMeta{
+		`{bazz="buzz", foo="bar"}`: {{MinTime: 1, MaxTime: 5, Checksum: 1}, {MinTime: 5, MaxTime: 10, Checksum: 2}},
+		`{foo="bar"}`:              {{MinTime: 1, MaxTime: 10, Checksum: 3}},
+	}, got)
+}
+
+func TestBuilder_DuplicateLabelNames(t *testing.T) {
+	b := NewBuilder()
+	b.AddSeries(labels.FromStrings("foo", "bar"), []ChunkMeta{{MinTime: 1, MaxTime: 5, Checksum: 1}})
+	b.AddSeries(labels.Labels{{Name: "foo", Value: "bar"}, {Name: "foo", Value: "bard"}}, []ChunkMeta{{MinTime: 1, MaxTime: 5, Checksum: 2}})
+	require.EqualError(t, b.Build(context.Background(), t.TempDir()), `series {foo="bar", foo="bard"} has duplicate label name "foo"`)
+}
+
+func TestBuilder_DeleteSeries(t *testing.T) {
+	dir := t.TempDir()
+	b := NewBuilder()
+	b.AddSeries(labels.FromStrings("foo", "bar"), []ChunkMeta{
+		{MinTime: 0, MaxTime: 3, Checksum: 0},
+		{MinTime: 4, MaxTime: 6, Checksum: 1},
+		{MinTime: 8, MaxTime: 12, Checksum: 2},
+	})
+	// only the chunks of {foo="bar"} entirely within the window are deleted
+	b.DeleteSeries(0, 7, labels.MustNewMatcher(labels.MatchEqual, "foo", "bar"), labels.MustNewMatcher(labels.MatchEqual, "bazz", ""))
+	// series added after the delete are subject to it too
+	b.AddSeries(labels.FromStrings("foo", "bar", "bazz", "buzz"), []ChunkMeta{{MinTime: 1, MaxTime: 10, Checksum: 3}})
+	b.AddSeries(labels.FromStrings("foo", "bard"), []ChunkMeta{{MinTime: 1, MaxTime: 5, Checksum: 4}})
+	b.DeleteSeries(0, 10, labels.MustNewMatcher(labels.MatchEqual, "foo", "bard"))
+	require.NoError(t, b.Build(context.Background(), dir))
+
+	// the deletes are part of the index file
+	ir, err := NewFileReader(dir)
+	require.NoError(t, err)
+	defer ir.Close()
+
+	values, err := ir.LabelValues("foo")
+	require.NoError(t, err)
+	require.Equal(t, []string{"bar"}, values)
+
+	p, err := ir.Postings("foo", nil, "bar")
+	require.NoError(t, err)
+
+	var (
+		ls   labels.Labels
+		chks []ChunkMeta
+		got  = map[string][]ChunkMeta{}
+	)
+	for p.Next() {
+		_, err := ir.Series(p.At(), &ls, &chks)
+		require.NoError(t, err)
+		got[ls.String()] = append([]ChunkMeta(nil), chks...)
+	}
+	require.NoError(t, p.Err())
+
+	require.Equal(t, map[string][]ChunkMeta{
+		`{bazz="buzz", foo="bar"}`: {{MinTime: 1, MaxTime: 10, Checksum: 3}},
+		`{foo="bar"}`:              {{MinTime: 8, MaxTime: 12, Checksum: 2}},
+	}, got)
+}
+
+func TestBuilder_DeleteSeriesWithoutMatchers(t *testing.T) {
+	b := NewBuilder()
+	b.AddSeries(labels.FromStrings("foo", "bar"), []ChunkMeta{{MinTime: 1, MaxTime: 5, Checksum: 1}})
+	b.DeleteSeries(0, 10)
+	require.EqualError(t, b.Build(context.Background(), t.TempDir()), "at least one matcher is required to delete series")
+}
@@ -26,6 +26,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"unsafe"
 
@@ -1128,6 +1129,9 @@ type StringIter interface {
 	Err() error
 }
 
+// Reader is safe for concurrent use: the underlying byte slice and the tables
+// loaded by newReader are never modified afterwards, and the slices passed to
+// Series belong to the caller.
 type Reader struct {
 	b   ByteSlice
 	toc *TOC
@@ -1537,6 +1541,46 @@ func (r *Reader) Close() error {
 	return r.c.Close()
 }
 
+// Preload faults in the pages backing the symbol table, the postings lists and
+// the postings offset table so the first query against a freshly opened (mmapped)
+// reader doesn't pay for reading them from disk.
+func (r *Reader) Preload(ctx context.Context) error {
+	for _, rng := range []Range{
+		{Start: int64(r.toc.Symbols), End: int64(r.toc.Series)},
+		{Start: int64(r.toc.Postings), End: int64(r.toc.LabelIndicesTable)},
+		{Start: int64(r.toc.PostingsTable), End: int64(r.toc.FingerprintOffsets)},
+	} {
+		if err := r.preloadRange(ctx, rng); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// preloadCheckInterval is the number of pages touched between context checks.
+const preloadCheckInterval = 1 << 10
+
+func (r *Reader) preloadRange(ctx context.Context, rng Range) error {
+	end := int(rng.End)
+	if end > r.b.Len() {
+		end = r.b.Len()
+	}
+	pageSize := os.Getpagesize()
+
+	var sum byte
+	for i, off := 0, int(rng.Start); off < end; i, off = i+1, off+pageSize {
+		if i%preloadCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		// reading a single byte is enough to fault in the whole page
+		sum += r.b.Range(off, off+1)[0]
+	}
+	runtime.KeepAlive(sum)
+	return nil
+}
+
 func (r *Reader) lookupSymbol(o uint32) (string, error) {
 	if s, ok := r.nameSymbols[o]; ok {
 		return s, nil
@@ -1698,6 +1742,7 @@ func (r *Reader) LabelValueFor(id storage.SeriesRef, label string) (string, erro
 }
 
 // Series reads the series with the given ID and writes its labels and chunks into lbls and chks.
+// lbls and chks are reused to avoid allocations, so each goroutine must pass its own.
 func (r *Reader) Series(id storage.SeriesRef, lbls *labels.Labels, chks *[]ChunkMeta) (uint64, error) {
 	offset := id
 	// In version 2 series IDs are no longer exact references but series are 16-byte padded
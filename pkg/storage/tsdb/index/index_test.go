@@ -22,6 +22,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"sync"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -544,3 +545,87 @@ func TestDecoder_Postings_WrongInput(t *testing.T) {
 	_, _, err := (&Decoder{}).Postings([]byte("the cake is a lie"))
 	require.Error(t, err)
 }
+
+func TestReaderPreload(t *testing.T) {
+	dir := t.TempDir()
+	b := NewBuilder()
+	for i := 0; i < 100; i++ {
+		b.AddSeries(labels.FromStrings("foo", "bar", "i", fmt.Sprint(i)), []ChunkMeta{{MinTime: 1, MaxTime: 10, Checksum: 1}})
+	}
+	require.NoError(t, b.Build(context.Background(), dir))
+
+	ir, err := NewFileReader(dir)
+	require.NoError(t, err)
+	defer ir.Close()
+
+	require.NoError(t, ir.Preload(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Equal(t, context.Canceled, ir.Preload(ctx))
+
+	// the reader is still usable afterwards
+	vals, err := ir.LabelValues("foo")
+	require.NoError(t, err)
+	require.Equal(t, []string{"bar"}, vals)
+}
+
+func TestReader_ConcurrentSeries(t *testing.T) {
+	dir := t.TempDir()
+	b := NewBuilder()
+	for i := 0; i < 1000; i++ {
+		b.AddSeries(
+			labels.FromStrings("foo", fmt.Sprintf("bar-%d", i%10), "i", fmt.Sprint(i)),
+			[]ChunkMeta{{MinTime: int64(i), MaxTime: int64(i + 10), Checksum: uint32(i)}},
+		)
+	}
+	require.NoError(t, b.Build(context.Background(), dir))
+
+	ir, err := NewFileReader(dir)
+	require.NoError(t, err)
+	defer ir.Close()
+
+	readAll := func() (map[string][]ChunkMeta, error) {
+		var (
+			ls   labels.Labels
+			chks []ChunkMeta
+			res  = map[string][]ChunkMeta{}
+		)
+		values, err := ir.LabelValues("foo")
+		if err != nil {
+			return nil, err
+		}
+		p, err := ir.Postings("foo", nil, values...)
+		if err != nil {
+			return nil, err
+		}
+		for p.Next() {
+			if _, err := ir.Series(p.At(), &ls, &chks); err != nil {
+				return nil, err
+			}
+			res[ls.String()] = append([]ChunkMeta(nil), chks...)
+		}
+		return res, p.Err()
+	}
+
+	expected, err := readAll()
+	require.NoError(t, err)
+	require.Len(t, expected, 1000)
+
+	var wg sync.WaitGroup
+	results := make([]map[string][]ChunkMeta, 16)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = readAll()
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range results {
+		require.NoError(t, errs[i])
+		require.Equal(t, expected, results[i])
+	}
+}
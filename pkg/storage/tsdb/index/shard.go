@@ -28,6 +28,18 @@ func NewShard(x, of uint32) ShardAnnotation {
 	}
 }
 
+// Validate returns an error if the shard factor isn't a power of two
+// or the shard isn't within [0, Of).
+func (shard ShardAnnotation) Validate() error {
+	if shard.Of == 0 || shard.Of&(shard.Of-1) != 0 {
+		return fmt.Errorf("shard factor must be a power of two, got %s", shard)
+	}
+	if shard.Shard >= shard.Of {
+		return fmt.Errorf("shard must be less than its factor, got %s", shard)
+	}
+	return nil
+}
+
 // Match returns whether a fingerprint belongs to a certain shard.
 // The Shard must be a power of 2.
 // Inclusion in a shard is calculated by determining the arbitrary bit prefix
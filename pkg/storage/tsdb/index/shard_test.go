@@ -9,6 +9,44 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+func TestShardValidate(t *testing.T) {
+	for _, tc := range []struct {
+		shard ShardAnnotation
+		err   bool
+	}{
+		{
+			shard: NewShard(0, 2),
+		},
+		{
+			shard: NewShard(3, 4),
+		},
+		{
+			shard: NewShard(0, 1),
+		},
+		{
+			shard: NewShard(0, 3),
+			err:   true,
+		},
+		{
+			shard: NewShard(4, 4),
+			err:   true,
+		},
+		{
+			shard: NewShard(0, 0),
+			err:   true,
+		},
+	} {
+		t.Run(tc.shard.String(), func(t *testing.T) {
+			err := tc.shard.Validate()
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.Nil(t, err)
+		})
+	}
+}
+
 func TestShardMatch(t *testing.T) {
 	for _, tc := range []struct {
 		shard ShardAnnotation
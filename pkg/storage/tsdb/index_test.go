@@ -0,0 +1,70 @@
+package tsdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortAndDedupeChunkRefs(t *testing.T) {
+	for _, tc := range []struct {
+		desc   string
+		shards [][]ChunkRef
+		exp    []ChunkRef
+	}{
+		{
+			desc: "empty",
+			exp:  []ChunkRef{},
+		},
+		{
+			desc: "no duplicates",
+			shards: [][]ChunkRef{
+				{
+					{User: "fake", Fingerprint: 1, Start: 5, End: 10, Checksum: 1},
+					{User: "fake", Fingerprint: 1, Start: 0, End: 5, Checksum: 0},
+				},
+			},
+			exp: []ChunkRef{
+				{User: "fake", Fingerprint: 1, Start: 0, End: 5, Checksum: 0},
+				{User: "fake", Fingerprint: 1, Start: 5, End: 10, Checksum: 1},
+			},
+		},
+		{
+			desc: "overlapping shards with duplicates",
+			shards: [][]ChunkRef{
+				{
+					{User: "fake", Fingerprint: 2, Start: 0, End: 5, Checksum: 2},
+					{User: "fake", Fingerprint: 1, Start: 0, End: 5, Checksum: 0},
+					{User: "fake", Fingerprint: 1, Start: 3, End: 8, Checksum: 1},
+				},
+				{
+					{User: "fake", Fingerprint: 1, Start: 3, End: 8, Checksum: 1},
+					{User: "fake", Fingerprint: 1, Start: 0, End: 5, Checksum: 0},
+					{User: "fake", Fingerprint: 3, Start: 4, End: 6, Checksum: 3},
+				},
+				{
+					{User: "fake", Fingerprint: 2, Start: 0, End: 5, Checksum: 2},
+					// same bounds and fingerprint, different checksum
+					{User: "fake", Fingerprint: 2, Start: 0, End: 5, Checksum: 4},
+				},
+			},
+			exp: []ChunkRef{
+				{User: "fake", Fingerprint: 1, Start: 0, End: 5, Checksum: 0},
+				{User: "fake", Fingerprint: 2, Start: 0, End: 5, Checksum: 2},
+				{User: "fake", Fingerprint: 2, Start: 0, End: 5, Checksum: 4},
+				{User: "fake", Fingerprint: 1, Start: 3, End: 8, Checksum: 1},
+				{User: "fake", Fingerprint: 3, Start: 4, End: 6, Checksum: 3},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			refs := []ChunkRef{}
+			for _, shard := range tc.shards {
+				refs = append(refs, shard...)
+			}
+
+			SortChunkRefs(refs)
+			require.Equal(t, tc.exp, DedupeChunkRefs(refs))
+		})
+	}
+}
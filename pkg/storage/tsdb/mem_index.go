@@ -0,0 +1,235 @@
+package tsdb
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/pao214/loki/pkg/storage/tsdb/index"
+)
+
+type memSeries struct {
+	labels labels.Labels
+	fp     model.Fingerprint
+	chunks index.ChunkMetas
+}
+
+// MemIndex is an Index held entirely in memory, for tests and embedded use cases
+// which don't want to build index files.
+// Like the index.Builder, it accepts series in any order and merges the chunks
+// added for the same labels. Results are returned in the same order as a TSDBIndex,
+// that is by fingerprint.
+type MemIndex struct {
+	mtx    sync.RWMutex
+	byID   map[string]*memSeries
+	series []*memSeries // sorted by fingerprint
+}
+
+func NewMemIndex() *MemIndex {
+	return &MemIndex{byID: make(map[string]*memSeries)}
+}
+
+func (i *MemIndex) AddSeries(ls labels.Labels, chks []index.ChunkMeta) {
+	if !sort.IsSorted(ls) {
+		ls = ls.Copy()
+		sort.Sort(ls)
+	}
+
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	id := ls.String()
+	s, ok := i.byID[id]
+	if !ok {
+		s = &memSeries{labels: ls.Copy(), fp: model.Fingerprint(ls.Hash())}
+		i.byID[id] = s
+
+		at := sort.Search(len(i.series), func(j int) bool { return i.series[j].fp >= s.fp })
+		i.series = append(i.series, nil)
+		copy(i.series[at+1:], i.series[at:])
+		i.series[at] = s
+	}
+
+	for _, chk := range chks {
+		if !s.hasChunk(chk) {
+			s.chunks = append(s.chunks, chk)
+		}
+	}
+	sort.Sort(s.chunks)
+}
+
+func (s *memSeries) hasChunk(chk index.ChunkMeta) bool {
+	for _, x := range s.chunks {
+		if x.MinTime == chk.MinTime && x.MaxTime == chk.MaxTime && x.Checksum == chk.Checksum {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *MemIndex) Bounds() (model.Time, model.Time) {
+	i.mtx.RLock()
+	defer i.mtx.RUnlock()
+
+	var from, through model.Time
+	first := true
+	for _, s := range i.series {
+		for _, chk := range s.chunks {
+			if first || chk.From() < from {
+				from = chk.From()
+			}
+			if first || chk.Through() > through {
+				through = chk.Through()
+			}
+			first = false
+		}
+	}
+	return from, through
+}
+
+// forSeries calls fn for the series matching the shard and matchers, with their chunks not deleted.
+// fn must NOT capture the chunks, they're reused across series.
+func (i *MemIndex) forSeries(
+	ctx context.Context,
+	shard *index.ShardAnnotation,
+	fn func(labels.Labels, model.Fingerprint, []index.ChunkMeta),
+	matchers ...*labels.Matcher,
+) error {
+	if shard != nil {
+		if err := shard.Validate(); err != nil {
+			return err
+		}
+	}
+
+	i.mtx.RLock()
+	defer i.mtx.RUnlock()
+
+	chks := chunkMetasPool.Get()
+	defer chunkMetasPool.Put(chks)
+
+	for n, s := range i.series {
+		if n%seriesCtxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		if shard != nil && !shard.Match(s.fp) {
+			continue
+		}
+		if !matchesAll(s.labels, matchers) {
+			continue
+		}
+
+		chks = append(chks[:0], s.chunks...)
+		fn(s.labels, s.fp, chks)
+	}
+	return nil
+}
+
+// matchesAll reports whether ls matches every matcher, a missing label matching as the empty value.
+func matchesAll(ls labels.Labels, matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		if !m.Matches(ls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (i *MemIndex) GetChunkRefs(ctx context.Context, userID string, from, through model.Time, res []ChunkRef, shard *index.ShardAnnotation, matchers ...*labels.Matcher) ([]ChunkRef, error) {
+	queryBounds := newBounds(from, through)
+	if res == nil {
+		res = ChunkRefsPool.Get()
+	}
+	res = res[:0]
+
+	if err := i.forSeries(ctx, shard,
+		func(_ labels.Labels, fp model.Fingerprint, chks []index.ChunkMeta) {
+			for _, chk := range chks {
+				if !inclusiveOverlap(queryBounds, chk) {
+					continue
+				}
+				res = append(res, ChunkRef{
+					User:        userID,
+					Fingerprint: fp,
+					Start:       chk.From(),
+					End:         chk.Through(),
+					Checksum:    chk.Checksum,
+				})
+			}
+		},
+		matchers...); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (i *MemIndex) Series(ctx context.Context, _ string, from, through model.Time, res []Series, shard *index.ShardAnnotation, matchers ...*labels.Matcher) ([]Series, error) {
+	queryBounds := newBounds(from, through)
+	if res == nil {
+		res = SeriesPool.Get()
+	}
+	res = res[:0]
+
+	if err := i.forSeries(ctx, shard,
+		func(ls labels.Labels, fp model.Fingerprint, chks []index.ChunkMeta) {
+			for _, chk := range chks {
+				if inclusiveOverlap(queryBounds, chk) {
+					res = append(res, Series{
+						Labels:      ls.Copy(),
+						Fingerprint: fp,
+					})
+					break
+				}
+			}
+		},
+		matchers...); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// LabelNames returns the sorted label names of the series matching the matchers, regardless of time.
+func (i *MemIndex) LabelNames(ctx context.Context, _ string, _, _ model.Time, matchers ...*labels.Matcher) ([]string, error) {
+	seen := make(map[string]struct{})
+	if err := i.forSeries(ctx, nil,
+		func(ls labels.Labels, _ model.Fingerprint, _ []index.ChunkMeta) {
+			for _, l := range ls {
+				seen[l.Name] = struct{}{}
+			}
+		},
+		matchers...); err != nil {
+		return nil, err
+	}
+	return sortedKeys(seen), nil
+}
+
+// LabelValues returns the sorted values of the label name of the series matching the matchers, regardless of time.
+func (i *MemIndex) LabelValues(ctx context.Context, _ string, _, _ model.Time, name string, matchers ...*labels.Matcher) ([]string, error) {
+	seen := make(map[string]struct{})
+	if err := i.forSeries(ctx, nil,
+		func(ls labels.Labels, _ model.Fingerprint, _ []index.ChunkMeta) {
+			if v := ls.Get(name); v != "" {
+				seen[v] = struct{}{}
+			}
+		},
+		matchers...); err != nil {
+		return nil, err
+	}
+	return sortedKeys(seen), nil
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
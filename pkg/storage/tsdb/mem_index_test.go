@@ -0,0 +1,130 @@
+package tsdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pao214/loki/pkg/storage/tsdb/index"
+)
+
+var memIndexCases = []LoadableSeries{
+	{
+		Labels: mustParseLabels(`{foo="bar"}`),
+		Chunks: []index.ChunkMeta{
+			{MinTime: 0, MaxTime: 3, Checksum: 0},
+			{MinTime: 1, MaxTime: 4, Checksum: 1},
+			{MinTime: 2, MaxTime: 5, Checksum: 2},
+		},
+	},
+	{
+		Labels: mustParseLabels(`{foo="bar", bazz="buzz"}`),
+		Chunks: []index.ChunkMeta{
+			{MinTime: 1, MaxTime: 10, Checksum: 3},
+		},
+	},
+	{
+		Labels: mustParseLabels(`{foo="bard", bazz="bozz", bonk="borb"}`),
+		Chunks: []index.ChunkMeta{
+			{MinTime: 1, MaxTime: 7, Checksum: 4},
+		},
+	},
+}
+
+// The in-memory index must answer exactly like the file one.
+func TestMemIndex(t *testing.T) {
+	file := BuildIndex(t, memIndexCases)
+	mem := BuildMemIndex(memIndexCases)
+	ctx := context.Background()
+	shard := index.NewShard(1, 2)
+
+	for _, tc := range []struct {
+		name          string
+		from, through model.Time
+		shard         *index.ShardAnnotation
+		matchers      []*labels.Matcher
+	}{
+		{name: "equal", from: 1, through: 5, matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "foo", "bar")}},
+		{name: "out of range", from: 8, through: 9, matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "foo", "bar")}},
+		{name: "sharded", from: 0, through: 10, shard: &shard, matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "foo", "bar")}},
+		{name: "regexp", from: 0, through: 10, matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "foo", "ba.*")}},
+		{name: "missing label", from: 0, through: 10, matchers: []*labels.Matcher{
+			labels.MustNewMatcher(labels.MatchRegexp, "foo", ".+"),
+			labels.MustNewMatcher(labels.MatchEqual, "bazz", ""),
+		}},
+		{name: "not equal", from: 0, through: 10, matchers: []*labels.Matcher{
+			labels.MustNewMatcher(labels.MatchRegexp, "foo", ".+"),
+			labels.MustNewMatcher(labels.MatchNotEqual, "bazz", "buzz"),
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			expectedRefs, err := file.GetChunkRefs(ctx, "fake", tc.from, tc.through, nil, tc.shard, tc.matchers...)
+			require.Nil(t, err)
+			refs, err := mem.GetChunkRefs(ctx, "fake", tc.from, tc.through, nil, tc.shard, tc.matchers...)
+			require.Nil(t, err)
+			require.Equal(t, expectedRefs, refs)
+
+			expectedSeries, err := file.Series(ctx, "fake", tc.from, tc.through, nil, tc.shard, tc.matchers...)
+			require.Nil(t, err)
+			xs, err := mem.Series(ctx, "fake", tc.from, tc.through, nil, tc.shard, tc.matchers...)
+			require.Nil(t, err)
+			require.Equal(t, expectedSeries, xs)
+
+			expectedNames, err := file.LabelNames(ctx, "fake", tc.from, tc.through, tc.matchers...)
+			require.Nil(t, err)
+			names, err := mem.LabelNames(ctx, "fake", tc.from, tc.through, tc.matchers...)
+			require.Nil(t, err)
+			require.Equal(t, expectedNames, names)
+
+			expectedValues, err := file.LabelValues(ctx, "fake", tc.from, tc.through, "bazz", tc.matchers...)
+			require.Nil(t, err)
+			values, err := mem.LabelValues(ctx, "fake", tc.from, tc.through, "bazz", tc.matchers...)
+			require.Nil(t, err)
+			require.Equal(t, expectedValues, values)
+		})
+	}
+
+	t.Run("Bounds", func(t *testing.T) {
+		from, through := mem.Bounds()
+		require.Equal(t, model.Time(0), from)
+		require.Equal(t, model.Time(10), through)
+	})
+
+	t.Run("LabelNames", func(t *testing.T) {
+		ls, err := mem.LabelNames(ctx, "fake", 9, 10)
+		require.Nil(t, err)
+		require.Equal(t, []string{"bazz", "bonk", "foo"}, ls)
+	})
+
+	t.Run("LabelValues", func(t *testing.T) {
+		vs, err := mem.LabelValues(ctx, "fake", 9, 10, "foo")
+		require.Nil(t, err)
+		require.Equal(t, []string{"bar", "bard"}, vs)
+	})
+
+	t.Run("InvalidShard", func(t *testing.T) {
+		shard := index.NewShard(0, 3)
+		_, err := mem.GetChunkRefs(ctx, "fake", 1, 5, nil, &shard, labels.MustNewMatcher(labels.MatchEqual, "foo", "bar"))
+		require.Error(t, err)
+	})
+}
+
+func TestMemIndex_AddSeries(t *testing.T) {
+	idx := NewMemIndex()
+	idx.AddSeries(mustParseLabels(`{foo="bar"}`), []index.ChunkMeta{{MinTime: 5, MaxTime: 10, Checksum: 2}})
+	idx.AddSeries(mustParseLabels(`{foo="bar"}`), []index.ChunkMeta{
+		{MinTime: 0, MaxTime: 5, Checksum: 1},
+		{MinTime: 5, MaxTime: 10, Checksum: 2},
+	})
+
+	refs, err := idx.GetChunkRefs(context.Background(), "fake", 0, 10, nil, nil, labels.MustNewMatcher(labels.MatchEqual, "foo", "bar"))
+	require.Nil(t, err)
+	fp := model.Fingerprint(mustParseLabels(`{foo="bar"}`).Hash())
+	require.Equal(t, []ChunkRef{
+		{User: "fake", Fingerprint: fp, Start: 0, End: 5, Checksum: 1},
+		{User: "fake", Fingerprint: fp, Start: 5, End: 10, Checksum: 2},
+	}, refs)
+}
@@ -66,6 +66,7 @@ func (i *MultiIndex) forIndices(ctx context.Context, from, through model.Time, f
 	for _, idx := range i.indices {
 		// ignore indices which can't match this query
 		if Overlap(queryBounds, idx) {
+			idx := idx
 			// run all queries in linked goroutines (cancel after first err),
 			// bounded by parallelism controls if applicable.
 			g.Go(func() error {
@@ -14,6 +14,7 @@
 package tsdb
 
 import (
+	"context"
 	"sort"
 	"strings"
 	"unicode/utf8"
@@ -277,7 +278,7 @@ func findSetMatches(pattern string) []string {
 	return matches
 }
 
-func labelValuesWithMatchers(r IndexReader, name string, matchers ...*labels.Matcher) ([]string, error) {
+func labelValuesWithMatchers(ctx context.Context, r IndexReader, name string, matchers ...*labels.Matcher) ([]string, error) {
 	// We're only interested in metrics which have the label <name>.
 	requireLabel, err := labels.NewMatcher(labels.MatchNotEqual, name, "")
 	if err != nil {
@@ -291,7 +292,13 @@ func labelValuesWithMatchers(r IndexReader, name string, matchers ...*labels.Mat
 	}
 
 	dedupe := map[string]interface{}{}
-	for p.Next() {
+	for n := 0; p.Next(); n++ {
+		if n%seriesCtxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
 		v, err := r.LabelValueFor(p.At(), name)
 		if err != nil {
 			if err == storage.ErrNotFound {
@@ -311,18 +318,25 @@ func labelValuesWithMatchers(r IndexReader, name string, matchers ...*labels.Mat
 	for value := range dedupe {
 		values = append(values, value)
 	}
+	// match the ordering of the matcher-less path, which returns sorted values
+	sort.Strings(values)
 
 	return values, nil
 }
 
-func labelNamesWithMatchers(r IndexReader, matchers ...*labels.Matcher) ([]string, error) {
+func labelNamesWithMatchers(ctx context.Context, r IndexReader, matchers ...*labels.Matcher) ([]string, error) {
 	p, err := PostingsForMatchers(r, nil, matchers...)
 	if err != nil {
 		return nil, err
 	}
 
 	var postings []storage.SeriesRef
-	for p.Next() {
+	for n := 0; p.Next(); n++ {
+		if n%seriesCtxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
 		postings = append(postings, p.At())
 	}
 	if p.Err() != nil {
@@ -2,8 +2,10 @@ package tsdb
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
@@ -117,3 +119,153 @@ func TestQueryIndex(t *testing.T) {
 	require.Equal(t, int64(1), mint)
 	require.Equal(t, int64(50), maxt)
 }
+
+func TestQueryIndexLabelsWithMatchers(t *testing.T) {
+	dir := t.TempDir()
+	b := index.NewBuilder()
+	for _, ls := range []string{
+		`{foo="bar"}`,
+		`{foo="baz", bazz="buzz"}`,
+		`{foo="bar", bazz="buzz"}`,
+		`{foo="bonk", bazz="bozz"}`,
+		`{unrelated="true"}`,
+	} {
+		b.AddSeries(mustParseLabels(ls), []index.ChunkMeta{
+			{
+				Checksum: 1,
+				MinTime:  1,
+				MaxTime:  10,
+				KB:       10,
+				Entries:  10,
+			},
+		})
+	}
+
+	require.Nil(t, b.Build(context.Background(), dir))
+
+	reader, err := index.NewFileReader(dir)
+	require.Nil(t, err)
+
+	for _, tc := range []struct {
+		desc     string
+		matchers []*labels.Matcher
+		values   []string
+		names    []string
+	}{
+		{
+			desc:     "equal",
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "bazz", "buzz")},
+			values:   []string{"bar", "baz"},
+			names:    []string{"bazz", "foo"},
+		},
+		{
+			desc:     "regexp",
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "bazz", "b.zz")},
+			values:   []string{"bar", "baz", "bonk"},
+			names:    []string{"bazz", "foo"},
+		},
+		{
+			desc:     "no match",
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "bazz", "nope")},
+			values:   []string{},
+			names:    []string{},
+		},
+		{
+			desc:     "other label",
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "unrelated", "true")},
+			values:   []string{},
+			names:    []string{"unrelated"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			values, err := labelValuesWithMatchers(context.Background(), reader, "foo", tc.matchers...)
+			require.Nil(t, err)
+			require.Equal(t, tc.values, values)
+
+			names, err := labelNamesWithMatchers(context.Background(), reader, tc.matchers...)
+			require.Nil(t, err)
+			require.Equal(t, tc.names, names)
+		})
+	}
+}
+
+func TestQueryIndexChunkBoundaries(t *testing.T) {
+	ls := mustParseLabels(`{foo="bar"}`)
+	idx := BuildIndex(t, []LoadableSeries{
+		{
+			Labels: ls,
+			Chunks: []index.ChunkMeta{
+				{
+					// ends before the query
+					Checksum: 1,
+					MinTime:  0,
+					MaxTime:  9,
+				},
+				{
+					// ends exactly at the query start
+					Checksum: 2,
+					MinTime:  5,
+					MaxTime:  10,
+				},
+				{
+					// inside the query
+					Checksum: 3,
+					MinTime:  12,
+					MaxTime:  15,
+				},
+				{
+					// starts exactly at the query end
+					Checksum: 4,
+					MinTime:  20,
+					MaxTime:  25,
+				},
+				{
+					// starts after the query
+					Checksum: 5,
+					MinTime:  21,
+					MaxTime:  30,
+				},
+			},
+		},
+	})
+
+	refs, err := idx.GetChunkRefs(context.Background(), "fake", 10, 20, nil, nil, labels.MustNewMatcher(labels.MatchEqual, "foo", "bar"))
+	require.Nil(t, err)
+
+	fp := model.Fingerprint(ls.Hash())
+	require.Equal(t, []ChunkRef{
+		{User: "fake", Fingerprint: fp, Start: 5, End: 10, Checksum: 2},
+		{User: "fake", Fingerprint: fp, Start: 12, End: 15, Checksum: 3},
+		{User: "fake", Fingerprint: fp, Start: 20, End: 25, Checksum: 4},
+	}, refs)
+}
+
+func TestForSeriesContextCancellation(t *testing.T) {
+	series := make([]LoadableSeries, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		series = append(series, LoadableSeries{
+			Labels: labels.FromStrings("foo", "bar", "i", fmt.Sprint(i)),
+			Chunks: []index.ChunkMeta{{MinTime: 1, MaxTime: 10, Checksum: uint32(i)}},
+		})
+	}
+	idx := BuildIndex(t, series)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var seen int
+	err := idx.forSeries(ctx, nil, func(_ labels.Labels, _ model.Fingerprint, _ []index.ChunkMeta) {
+		seen++
+		// cancel mid-iteration
+		cancel()
+	}, labels.MustNewMatcher(labels.MatchEqual, "foo", "bar"))
+
+	require.Equal(t, context.Canceled, err)
+	require.LessOrEqual(t, seen, seriesCtxCheckInterval)
+
+	_, err = idx.GetChunkRefs(ctx, "fake", 1, 10, nil, nil, labels.MustNewMatcher(labels.MatchEqual, "foo", "bar"))
+	require.Equal(t, context.Canceled, err)
+
+	_, err = idx.LabelValues(ctx, "fake", 1, 10, "i", labels.MustNewMatcher(labels.MatchEqual, "foo", "bar"))
+	require.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,89 @@
+package tsdb
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/pao214/loki/pkg/storage/tsdb/index"
+)
+
+// Config configures the TSDB indices opened for querying.
+type Config struct {
+	DegradedMode bool `yaml:"degraded_mode"`
+}
+
+// RegisterFlagsWithPrefix registers flags where every name is prefixed by
+// prefix. If prefix is a non-empty string, prefix should end with a period.
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.DegradedMode, prefix+"tsdb.degraded-mode", false, "Answer queries with empty results from the TSDB index files which can't be opened or read, instead of failing them. Each such request increments loki_tsdb_index_unavailable_total.")
+}
+
+// RegisterFlags registers flags.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	cfg.RegisterFlagsWithPrefix("", f)
+}
+
+// NewQueryIndex opens the index files at paths and combines them into the Index answering queries.
+// When degraded mode is enabled, every index is wrapped in a DegradedIndex and the files which can't
+// be opened are answered with empty results, so one unavailable file doesn't fail every query.
+// Otherwise the first file failing to open is returned as an error.
+func NewQueryIndex(cfg Config, paths []string, metrics *DegradedIndexMetrics, logger log.Logger) (Index, error) {
+	var (
+		readers = make([]*index.Reader, 0, len(paths))
+		indices = make([]Index, 0, len(paths))
+	)
+	for _, path := range paths {
+		var idx Index
+		reader, err := index.NewFileReader(path)
+		switch {
+		case err == nil:
+			readers = append(readers, reader)
+			idx = NewTSDBIndex(reader)
+		case cfg.DegradedMode:
+			level.Warn(logger).Log("msg", "failed to open tsdb index", "path", path, "err", err)
+			idx = unavailableIndex{err: fmt.Errorf("opening tsdb index %s: %w", path, err)}
+		default:
+			for _, r := range readers {
+				r.Close()
+			}
+			return nil, fmt.Errorf("opening tsdb index %s: %w", path, err)
+		}
+
+		if cfg.DegradedMode {
+			idx = NewDegradedIndex(idx, true, metrics, logger)
+		}
+		indices = append(indices, idx)
+	}
+
+	return NewMultiIndex(indices...)
+}
+
+// unavailableIndex stands for an index file which couldn't be opened, and fails every request with err.
+// Its bounds are unknown, so it is assumed to cover all time.
+type unavailableIndex struct {
+	err error
+}
+
+func (unavailableIndex) Bounds() (model.Time, model.Time) { return model.Earliest, model.Latest }
+
+func (i unavailableIndex) GetChunkRefs(_ context.Context, _ string, _, _ model.Time, _ []ChunkRef, _ *index.ShardAnnotation, _ ...*labels.Matcher) ([]ChunkRef, error) {
+	return nil, i.err
+}
+
+func (i unavailableIndex) Series(_ context.Context, _ string, _, _ model.Time, _ []Series, _ *index.ShardAnnotation, _ ...*labels.Matcher) ([]Series, error) {
+	return nil, i.err
+}
+
+func (i unavailableIndex) LabelNames(_ context.Context, _ string, _, _ model.Time, _ ...*labels.Matcher) ([]string, error) {
+	return nil, i.err
+}
+
+func (i unavailableIndex) LabelValues(_ context.Context, _ string, _, _ model.Time, _ string, _ ...*labels.Matcher) ([]string, error) {
+	return nil, i.err
+}
@@ -0,0 +1,55 @@
+package tsdb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pao214/loki/pkg/storage/tsdb/index"
+)
+
+func TestNewQueryIndex(t *testing.T) {
+	ctx := context.Background()
+	matcher := labels.MustNewMatcher(labels.MatchEqual, "foo", "bar")
+
+	healthy := filepath.Join(t.TempDir(), "healthy")
+	b := index.NewBuilder()
+	b.AddSeries(mustParseLabels(`{foo="bar"}`), []index.ChunkMeta{{MinTime: 1, MaxTime: 5, Checksum: 1}})
+	require.Nil(t, b.Build(ctx, healthy))
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	t.Run("degraded", func(t *testing.T) {
+		metrics := NewDegradedIndexMetrics(prometheus.NewRegistry())
+		idx, err := NewQueryIndex(Config{DegradedMode: true}, []string{healthy, missing}, metrics, log.NewNopLogger())
+		require.Nil(t, err)
+
+		// the healthy index still answers, the missing one is counted as unavailable
+		refs, err := idx.GetChunkRefs(ctx, "fake", 0, 10, nil, nil, matcher)
+		require.Nil(t, err)
+		require.Len(t, refs, 1)
+		require.Equal(t, 1.0, testutil.ToFloat64(metrics.indexUnavailableTotal.WithLabelValues("GetChunkRefs")))
+
+		values, err := idx.LabelValues(ctx, "fake", 0, 10, "foo")
+		require.Nil(t, err)
+		require.Equal(t, []string{"bar"}, values)
+		require.Equal(t, 1.0, testutil.ToFloat64(metrics.indexUnavailableTotal.WithLabelValues("LabelValues")))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		metrics := NewDegradedIndexMetrics(prometheus.NewRegistry())
+		_, err := NewQueryIndex(Config{}, []string{healthy, missing}, metrics, log.NewNopLogger())
+		require.Error(t, err)
+
+		idx, err := NewQueryIndex(Config{}, []string{healthy}, metrics, log.NewNopLogger())
+		require.Nil(t, err)
+		refs, err := idx.GetChunkRefs(ctx, "fake", 0, 10, nil, nil, matcher)
+		require.Nil(t, err)
+		require.Len(t, refs, 1)
+	})
+}
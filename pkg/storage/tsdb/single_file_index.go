@@ -2,7 +2,10 @@ package tsdb
 
 import (
 	"context"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 
@@ -20,14 +23,53 @@ func NewTSDBIndex(reader IndexReader) *TSDBIndex {
 	}
 }
 
+type PreloadMetrics struct {
+	preloadDuration *prometheus.HistogramVec
+}
+
+func NewPreloadMetrics(r prometheus.Registerer) *PreloadMetrics {
+	return &PreloadMetrics{
+		preloadDuration: promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "loki",
+			Name:      "tsdb_index_preload_duration_seconds",
+			Help:      "Time spent preloading TSDB index files.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"status"}),
+	}
+}
+
+// Preload warms up the underlying reader, if it supports it, so the first query is fast.
+func (i *TSDBIndex) Preload(ctx context.Context, metrics *PreloadMetrics) error {
+	p, ok := i.reader.(interface {
+		Preload(context.Context) error
+	})
+	if !ok {
+		return nil
+	}
+
+	start := time.Now()
+	err := p.Preload(ctx)
+	status := "success"
+	if err != nil {
+		status = "failure"
+	}
+	metrics.preloadDuration.WithLabelValues(status).Observe(time.Since(start).Seconds())
+	return err
+}
+
 func (i *TSDBIndex) Bounds() (model.Time, model.Time) {
 	from, through := i.reader.Bounds()
 	return model.Time(from), model.Time(through)
 }
 
+// seriesCtxCheckInterval is the number of series iterated between context cancellation checks.
+const seriesCtxCheckInterval = 128
+
 // fn must NOT capture it's arguments. They're reused across series iterations and returned to
 // a pool after completion.
+// The iteration stops with the context's error once ctx is done.
 func (i *TSDBIndex) forSeries(
+	ctx context.Context,
 	shard *index.ShardAnnotation,
 	fn func(labels.Labels, model.Fingerprint, []index.ChunkMeta),
 	matchers ...*labels.Matcher,
@@ -41,7 +83,13 @@ func (i *TSDBIndex) forSeries(
 	chks := chunkMetasPool.Get()
 	defer chunkMetasPool.Put(chks)
 
-	for p.Next() {
+	for n := 0; p.Next(); n++ {
+		if n%seriesCtxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
 		hash, err := i.reader.Series(p.At(), &ls, &chks)
 		if err != nil {
 			return err
@@ -57,20 +105,36 @@ func (i *TSDBIndex) forSeries(
 	return p.Err()
 }
 
-func (i *TSDBIndex) GetChunkRefs(_ context.Context, userID string, from, through model.Time, res []ChunkRef, shard *index.ShardAnnotation, matchers ...*labels.Matcher) ([]ChunkRef, error) {
+func (i *TSDBIndex) GetChunkRefs(ctx context.Context, userID string, from, through model.Time, res []ChunkRef, shard *index.ShardAnnotation, matchers ...*labels.Matcher) ([]ChunkRef, error) {
+	return i.GetFilteredChunkRefs(ctx, userID, from, through, res, shard, nil, matchers...)
+}
+
+// GetFilteredChunkRefs behaves like GetChunkRefs, but only returns chunks accepted by filter.
+// A nil filter accepts every chunk.
+func (i *TSDBIndex) GetFilteredChunkRefs(ctx context.Context, userID string, from, through model.Time, res []ChunkRef, shard *index.ShardAnnotation, filter ChunkFilter, matchers ...*labels.Matcher) ([]ChunkRef, error) {
+	if shard != nil {
+		if err := shard.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	queryBounds := newBounds(from, through)
 	if res == nil {
 		res = ChunkRefsPool.Get()
 	}
 	res = res[:0]
 
-	if err := i.forSeries(shard,
+	if err := i.forSeries(ctx, shard,
 		func(ls labels.Labels, fp model.Fingerprint, chks []index.ChunkMeta) {
 			// TODO(owen-d): use logarithmic approach
 			for _, chk := range chks {
 
 				// current chunk is outside the range of this request
-				if !Overlap(queryBounds, chk) {
+				if !inclusiveOverlap(queryBounds, chk) {
+					continue
+				}
+
+				if filter != nil && !filter(chk) {
 					continue
 				}
 
@@ -90,19 +154,25 @@ func (i *TSDBIndex) GetChunkRefs(_ context.Context, userID string, from, through
 	return res, nil
 }
 
-func (i *TSDBIndex) Series(_ context.Context, _ string, from, through model.Time, res []Series, shard *index.ShardAnnotation, matchers ...*labels.Matcher) ([]Series, error) {
+func (i *TSDBIndex) Series(ctx context.Context, _ string, from, through model.Time, res []Series, shard *index.ShardAnnotation, matchers ...*labels.Matcher) ([]Series, error) {
+	if shard != nil {
+		if err := shard.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	queryBounds := newBounds(from, through)
 	if res == nil {
 		res = SeriesPool.Get()
 	}
 	res = res[:0]
 
-	if err := i.forSeries(shard,
+	if err := i.forSeries(ctx, shard,
 		func(ls labels.Labels, fp model.Fingerprint, chks []index.ChunkMeta) {
 			// TODO(owen-d): use logarithmic approach
 			for _, chk := range chks {
 
-				if Overlap(queryBounds, chk) {
+				if inclusiveOverlap(queryBounds, chk) {
 					// this series has at least one chunk in the desired range
 					res = append(res, Series{
 						Labels:      ls.Copy(),
@@ -119,17 +189,46 @@ func (i *TSDBIndex) Series(_ context.Context, _ string, from, through model.Time
 	return res, nil
 }
 
-func (i *TSDBIndex) LabelNames(_ context.Context, _ string, _, _ model.Time, matchers ...*labels.Matcher) ([]string, error) {
+func (i *TSDBIndex) LabelNames(ctx context.Context, _ string, _, _ model.Time, matchers ...*labels.Matcher) ([]string, error) {
 	if len(matchers) == 0 {
 		return i.reader.LabelNames()
 	}
 
-	return labelNamesWithMatchers(i.reader, matchers...)
+	return labelNamesWithMatchers(ctx, i.reader, matchers...)
 }
 
-func (i *TSDBIndex) LabelValues(_ context.Context, _ string, _, _ model.Time, name string, matchers ...*labels.Matcher) ([]string, error) {
+func (i *TSDBIndex) LabelValues(ctx context.Context, _ string, _, _ model.Time, name string, matchers ...*labels.Matcher) ([]string, error) {
 	if len(matchers) == 0 {
 		return i.reader.LabelValues(name)
 	}
-	return labelValuesWithMatchers(i.reader, name, matchers...)
+	return labelValuesWithMatchers(ctx, i.reader, name, matchers...)
+}
+
+// Stats sums the ChunkMeta stats of every chunk in the requested range for series matching the matchers.
+// A stream is counted once if it has at least one chunk in the range.
+func (i *TSDBIndex) Stats(ctx context.Context, _ string, from, through model.Time, matchers ...*labels.Matcher) (Stats, error) {
+	queryBounds := newBounds(from, through)
+	var stats Stats
+
+	if err := i.forSeries(ctx, nil,
+		func(_ labels.Labels, _ model.Fingerprint, chks []index.ChunkMeta) {
+			var found bool
+			for _, chk := range chks {
+				if !inclusiveOverlap(queryBounds, chk) {
+					continue
+				}
+				found = true
+				stats.Chunks++
+				stats.KB += uint64(chk.KB)
+				stats.Entries += uint64(chk.Entries)
+			}
+			if found {
+				stats.Streams++
+			}
+		},
+		matchers...); err != nil {
+		return Stats{}, err
+	}
+
+	return stats, nil
 }
@@ -2,8 +2,11 @@ package tsdb
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
@@ -20,16 +23,22 @@ func TestSingleIdx(t *testing.T) {
 					MinTime:  0,
 					MaxTime:  3,
 					Checksum: 0,
+					KB:       1,
+					Entries:  10,
 				},
 				{
 					MinTime:  1,
 					MaxTime:  4,
 					Checksum: 1,
+					KB:       2,
+					Entries:  20,
 				},
 				{
 					MinTime:  2,
 					MaxTime:  5,
 					Checksum: 2,
+					KB:       2,
+					Entries:  20,
 				},
 			},
 		},
@@ -40,6 +49,8 @@ func TestSingleIdx(t *testing.T) {
 					MinTime:  1,
 					MaxTime:  10,
 					Checksum: 3,
+					KB:       4,
+					Entries:  40,
 				},
 			},
 		},
@@ -50,6 +61,8 @@ func TestSingleIdx(t *testing.T) {
 					MinTime:  1,
 					MaxTime:  7,
 					Checksum: 4,
+					KB:       8,
+					Entries:  80,
 				},
 			},
 		},
@@ -113,6 +126,15 @@ func TestSingleIdx(t *testing.T) {
 
 	})
 
+	t.Run("InvalidShard", func(t *testing.T) {
+		shard := index.NewShard(0, 3)
+		_, err := idx.GetChunkRefs(context.Background(), "fake", 1, 5, nil, &shard, labels.MustNewMatcher(labels.MatchEqual, "foo", "bar"))
+		require.Error(t, err)
+
+		_, err = idx.Series(context.Background(), "fake", 1, 5, nil, &shard, labels.MustNewMatcher(labels.MatchEqual, "foo", "bar"))
+		require.Error(t, err)
+	})
+
 	t.Run("Series", func(t *testing.T) {
 		xs, err := idx.Series(context.Background(), "fake", 8, 9, nil, nil, labels.MustNewMatcher(labels.MatchEqual, "foo", "bar"))
 		require.Nil(t, err)
@@ -169,4 +191,78 @@ func TestSingleIdx(t *testing.T) {
 		require.Nil(t, err)
 		require.Equal(t, []string{"bar"}, vs)
 	})
+	t.Run("Stats", func(t *testing.T) {
+		stats, err := idx.Stats(context.Background(), "fake", 1, 5, labels.MustNewMatcher(labels.MatchEqual, "foo", "bar"))
+		require.Nil(t, err)
+		require.Equal(t, Stats{
+			Streams: 2,
+			Chunks:  4,
+			KB:      9,
+			Entries: 90,
+		}, stats)
+	})
+
+	t.Run("StatsOutOfRange", func(t *testing.T) {
+		stats, err := idx.Stats(context.Background(), "fake", 8, 9, labels.MustNewMatcher(labels.MatchEqual, "foo", "bar"))
+		require.Nil(t, err)
+		require.Equal(t, Stats{
+			Streams: 1,
+			Chunks:  1,
+			KB:      4,
+			Entries: 40,
+		}, stats)
+	})
+}
+
+func TestTSDBIndexPreload(t *testing.T) {
+	idx := BuildIndex(t, []LoadableSeries{
+		{
+			Labels: mustParseLabels(`{foo="bar"}`),
+			Chunks: []index.ChunkMeta{{MinTime: 1, MaxTime: 5, Checksum: 1}},
+		},
+	})
+
+	reg := prometheus.NewRegistry()
+	metrics := NewPreloadMetrics(reg)
+	require.Nil(t, idx.Preload(context.Background(), metrics))
+
+	count, err := testutil.GatherAndCount(reg, "loki_tsdb_index_preload_duration_seconds")
+	require.Nil(t, err)
+	require.Equal(t, 1, count)
+}
+
+func BenchmarkTSDBIndexFirstQuery(b *testing.B) {
+	dir := b.TempDir()
+	builder := index.NewBuilder()
+	for i := 0; i < 50000; i++ {
+		builder.AddSeries(
+			labels.FromStrings("foo", fmt.Sprintf("bar-%d", i%100), "i", fmt.Sprint(i)),
+			[]index.ChunkMeta{{MinTime: 1, MaxTime: 10, Checksum: uint32(i)}},
+		)
+	}
+	require.Nil(b, builder.Build(context.Background(), dir))
+
+	for _, preload := range []bool{false, true} {
+		b.Run(fmt.Sprintf("preload=%v", preload), func(b *testing.B) {
+			metrics := NewPreloadMetrics(nil)
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				b.StopTimer()
+				reader, err := index.NewFileReader(dir)
+				require.Nil(b, err)
+				idx := NewTSDBIndex(reader)
+				if preload {
+					require.Nil(b, idx.Preload(context.Background(), metrics))
+				}
+				b.StartTimer()
+
+				_, err = idx.GetChunkRefs(context.Background(), "fake", 1, 10, nil, nil, labels.MustNewMatcher(labels.MatchRegexp, "foo", "bar-1.*"))
+				require.Nil(b, err)
+
+				b.StopTimer()
+				require.Nil(b, reader.Close())
+				b.StartTimer()
+			}
+		})
+	}
 }
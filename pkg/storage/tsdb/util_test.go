@@ -30,3 +30,11 @@ func BuildIndex(t *testing.T, cases []LoadableSeries) *TSDBIndex {
 
 	return NewTSDBIndex(reader)
 }
+
+func BuildMemIndex(cases []LoadableSeries) *MemIndex {
+	idx := NewMemIndex()
+	for _, s := range cases {
+		idx.AddSeries(s.Labels, s.Chunks)
+	}
+	return idx
+}
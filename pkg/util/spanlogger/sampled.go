@@ -0,0 +1,49 @@
+package spanlogger
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	util_log "github.com/pao214/loki/pkg/util/log"
+)
+
+// NewSampled is like New but only forwards about sampleRate (between 0 and 1) of the log lines to the
+// logger, to keep hot paths from flooding the logs. Error lines are always forwarded, and all lines are
+// still attached to the span.
+func NewSampled(ctx context.Context, method string, sampleRate float64, kvps ...interface{}) (*SpanLogger, context.Context) {
+	return NewSampledWithLogger(ctx, util_log.Logger, method, sampleRate, kvps...)
+}
+
+// NewSampledWithLogger is like NewSampled but allows to pass a logger.
+func NewSampledWithLogger(ctx context.Context, logger log.Logger, method string, sampleRate float64, kvps ...interface{}) (*SpanLogger, context.Context) {
+	return NewWithLogger(ctx, newSampledLogger(logger, sampleRate), method, kvps...)
+}
+
+type sampledLogger struct {
+	next       log.Logger
+	sampleRate float64
+}
+
+func newSampledLogger(next log.Logger, sampleRate float64) log.Logger {
+	return &sampledLogger{next: next, sampleRate: sampleRate}
+}
+
+func (l *sampledLogger) Log(kvps ...interface{}) error {
+	if isError(kvps) || rand.Float64() < l.sampleRate {
+		return l.next.Log(kvps...)
+	}
+	return nil
+}
+
+// isError reports whether the log line was logged at error level.
+func isError(kvps []interface{}) bool {
+	for i := 0; i+1 < len(kvps); i += 2 {
+		if kvps[i] == level.Key() && kvps[i+1] == level.ErrorValue() {
+			return true
+		}
+	}
+	return false
+}
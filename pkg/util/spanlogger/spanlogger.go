@@ -2,10 +2,14 @@ package spanlogger
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/spanlogger"
 	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 
 	"github.com/pao214/loki/pkg/tenant"
 	util_log "github.com/pao214/loki/pkg/util/log"
@@ -23,25 +27,60 @@ var (
 )
 
 // SpanLogger unifies tracing and logging, to reduce repetition.
-type SpanLogger = spanlogger.SpanLogger
+type SpanLogger struct {
+	*spanlogger.SpanLogger
+}
 
 // New makes a new SpanLogger with a log.Logger to send logs to. The provided context will have the logger attached
 // to it and can be retrieved with FromContext.
+// The tenant IDs of the context, if any, are added to the span under TenantIDsTagName and to the logs.
 func New(ctx context.Context, method string, kvps ...interface{}) (*SpanLogger, context.Context) {
-	return spanlogger.New(ctx, util_log.Logger, method, tenant.DefaultResolver, kvps...)
+	return NewWithLogger(ctx, util_log.Logger, method, kvps...)
 }
 
 // NewWithLogger is like New but allows to pass a logger.
 func NewWithLogger(ctx context.Context, logger log.Logger, method string, kvps ...interface{}) (*SpanLogger, context.Context) {
-	return spanlogger.New(ctx, logger, method, tenant.DefaultResolver, kvps...)
+	l, ctx := spanlogger.New(ctx, logger, method, tenant.DefaultResolver)
+	s := withTenantIDs(ctx, &SpanLogger{l})
+	if len(kvps) > 0 {
+		level.Debug(s).Log(kvps...)
+	}
+	return s, ctx
 }
 
 // FromContext returns a SpanLogger using the current parent span.
 // If there is no parent span, the SpanLogger will only log to the logger
 // within the context. If the context doesn't have a logger, the fallback
 // logger is used.
+// Like New, the tenant IDs of the context are added to the logs.
 func FromContext(ctx context.Context) *SpanLogger {
-	return spanlogger.FromContext(ctx, util_log.Logger, tenant.DefaultResolver)
+	return withTenantIDs(ctx, &SpanLogger{spanlogger.FromContext(ctx, util_log.Logger, tenant.DefaultResolver)})
+}
+
+// withTenantIDs adds the tenant IDs of multi-tenant contexts to the logs of s under org_id.
+// Single tenant IDs are already logged under org_id, but multi-tenant contexts are left out.
+// Spans are tagged with the tenant IDs when started, by New.
+func withTenantIDs(ctx context.Context, s *SpanLogger) *SpanLogger {
+	ids, err := tenant.TenantIDs(ctx)
+	if err != nil || len(ids) < 2 {
+		return s
+	}
+	s.Logger = log.With(s.Logger, "org_id", tenant.JoinTenantIDs(ids))
+	return s
+}
+
+// ChildContext starts a child span of the span in ctx and returns a context holding it,
+// so that FromContext called with the returned context logs to the child span.
+// It is meant for work done in goroutines, whose logs would otherwise be attached to the parent span.
+// The returned function finishes the child span.
+// Without a span in ctx, no span is started and ctx is returned as is.
+func ChildContext(ctx context.Context, operationName string) (context.Context, func()) {
+	parent := opentracing.SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, func() {}
+	}
+	sp, ctx := opentracing.StartSpanFromContextWithTracer(ctx, parent.Tracer(), operationName)
+	return ctx, sp.Finish
 }
 
 // FromContextWithFallback returns a span logger using the current parent span.
@@ -57,8 +96,28 @@ func FromContextWithFallback(ctx context.Context, fallback log.Logger) *SpanLogg
 	if sp == nil {
 		sp = defaultNoopSpan
 	}
-	return &SpanLogger{
+	return withTenantIDs(ctx, &SpanLogger{&spanlogger.SpanLogger{
 		Logger: util_log.WithContext(ctx, logger),
 		Span:   sp,
+	}})
+}
+
+// Errorf logs the formatted error at error level, marks the span as errored and returns the error,
+// so that call sites can just return log.Errorf(...).
+func (s *SpanLogger) Errorf(format string, args ...interface{}) error {
+	err := fmt.Errorf(format, args...)
+	level.Error(s).Log("err", err)
+	ext.Error.Set(s.Span, true)
+	return err
+}
+
+// Timed returns a function logging name along with the time elapsed since Timed was called,
+// both to the logger and to the span. It is meant to be deferred:
+//
+//	defer log.Timed("fetched chunks")()
+func (s *SpanLogger) Timed(name string) func() {
+	start := time.Now()
+	return func() {
+		level.Debug(s).Log("msg", name, "duration", time.Since(start))
 	}
 }
@@ -0,0 +1,220 @@
+package spanlogger
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/pao214/loki/pkg/tenant"
+)
+
+func TestChildContext(t *testing.T) {
+	tracer := mocktracer.New()
+	parent := tracer.StartSpan("parent")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx, finish := ChildContext(ctx, "worker")
+			defer finish()
+			_ = FromContext(ctx).Log("msg", "from goroutine", "worker", i)
+		}(i)
+	}
+	wg.Wait()
+	parent.Finish()
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 3)
+
+	parentID := parent.Context().(mocktracer.MockSpanContext).SpanID
+	for _, sp := range spans {
+		if sp.OperationName == "parent" {
+			require.Empty(t, sp.Logs())
+			continue
+		}
+		require.Equal(t, "worker", sp.OperationName)
+		require.Equal(t, parentID, sp.ParentID)
+		require.Len(t, sp.Logs(), 1)
+	}
+}
+
+func TestChildContext_NoParent(t *testing.T) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	ctx, finish := ChildContext(context.Background(), "worker")
+	require.Nil(t, opentracing.SpanFromContext(ctx))
+	finish()
+	require.Empty(t, tracer.FinishedSpans())
+}
+
+type countingLogger struct {
+	lines int
+}
+
+func (c *countingLogger) Log(_ ...interface{}) error {
+	c.lines++
+	return nil
+}
+
+func TestNewSampled(t *testing.T) {
+	const lines = 10000
+
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	logger := &countingLogger{}
+	sl, _ := NewSampledWithLogger(context.Background(), logger, "test", 0.1)
+	for i := 0; i < lines; i++ {
+		level.Info(sl).Log("msg", "info", "i", i)
+	}
+	require.InDelta(t, lines/10, logger.lines, lines/50)
+
+	logger.lines = 0
+	for i := 0; i < lines; i++ {
+		level.Error(sl).Log("msg", "error", "i", i)
+	}
+	require.Equal(t, lines, logger.lines)
+	sl.Finish()
+
+	// all lines are attached to the span
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Logs(), 2*lines)
+}
+
+func TestSpanLogger_Errorf(t *testing.T) {
+	tracer := mocktracer.New()
+	sp := tracer.StartSpan("test")
+	ctx := opentracing.ContextWithSpan(context.Background(), sp)
+
+	logger := &countingLogger{}
+	err := FromContextWithFallback(ctx, logger).Errorf("failed to fetch %d chunks", 3)
+	require.EqualError(t, err, "failed to fetch 3 chunks")
+	require.Equal(t, 1, logger.lines)
+	sp.Finish()
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, true, spans[0].Tag("error"))
+	require.Len(t, spans[0].Logs(), 1)
+}
+
+type recordingLogger struct {
+	lines [][]interface{}
+}
+
+func (r *recordingLogger) Log(kvps ...interface{}) error {
+	r.lines = append(r.lines, kvps)
+	return nil
+}
+
+func TestSpanLogger_TenantIDs(t *testing.T) {
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	defer tenant.WithDefaultResolver(tenant.NewSingleResolver())
+
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	for _, tc := range []struct {
+		orgID             string
+		expectedTenantIDs []string
+		expectedLogged    string
+	}{
+		{orgID: "team-a", expectedTenantIDs: []string{"team-a"}, expectedLogged: "team-a"},
+		{orgID: "team-b|team-a", expectedTenantIDs: []string{"team-a", "team-b"}, expectedLogged: "team-a|team-b"},
+	} {
+		t.Run(tc.orgID, func(t *testing.T) {
+			tracer.Reset()
+			ctx := user.InjectOrgID(context.Background(), tc.orgID)
+			logger := &recordingLogger{}
+
+			sl, ctx := NewWithLogger(ctx, logger, "test")
+			level.Info(sl).Log("msg", "from new")
+			level.Info(FromContext(ctx)).Log("msg", "from context")
+			level.Info(FromContextWithFallback(ctx, logger)).Log("msg", "from context with fallback")
+			sl.Finish()
+
+			spans := tracer.FinishedSpans()
+			require.Len(t, spans, 1)
+			require.Equal(t, tc.expectedTenantIDs, spans[0].Tag(TenantIDsTagName))
+
+			require.Len(t, logger.lines, 3)
+			for _, line := range logger.lines {
+				require.NotContains(t, line, TenantIDsTagName)
+				// the tenant IDs are logged once, under org_id
+				var orgIDs []interface{}
+				for i := 0; i+1 < len(line); i += 2 {
+					if line[i] == "org_id" {
+						orgIDs = append(orgIDs, line[i+1])
+					}
+				}
+				require.Equal(t, []interface{}{tc.expectedLogged}, orgIDs)
+			}
+		})
+	}
+}
+
+func TestSpanLogger_FromContextDoesNotTagSpan(t *testing.T) {
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	defer tenant.WithDefaultResolver(tenant.NewSingleResolver())
+
+	tracer := mocktracer.New()
+	sp := tracer.StartSpan("test")
+	ctx := opentracing.ContextWithSpan(user.InjectOrgID(context.Background(), "team-b|team-a"), sp)
+
+	level.Info(FromContext(ctx)).Log("msg", "from context")
+	level.Info(FromContextWithFallback(ctx, &recordingLogger{})).Log("msg", "from context with fallback")
+	sp.Finish()
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	require.Nil(t, spans[0].Tag(TenantIDsTagName))
+}
+
+func TestSpanLogger_Timed(t *testing.T) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	logger := &recordingLogger{}
+	sl, _ := NewWithLogger(context.Background(), logger, "test")
+	func() {
+		defer sl.Timed("fetched chunks")()
+	}()
+	sl.Finish()
+
+	require.Len(t, logger.lines, 1)
+	line := logger.lines[0]
+	require.Contains(t, line, "fetched chunks")
+	var found bool
+	for i := 0; i+1 < len(line); i += 2 {
+		if line[i] == "duration" {
+			found = true
+			require.GreaterOrEqual(t, line[i+1].(time.Duration), time.Duration(0))
+		}
+	}
+	require.True(t, found, "duration missing from %v", line)
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Logs(), 1)
+	var fields []string
+	for _, f := range spans[0].Logs()[0].Fields {
+		fields = append(fields, f.Key)
+	}
+	require.Contains(t, fields, "duration")
+}
@@ -1,14 +1,93 @@
 package unmarshal
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"time"
 
 	json "github.com/json-iterator/go"
 
 	"github.com/pao214/loki/pkg/logproto"
 )
 
+// DefaultMaxPushRequestBytes is the body size limit applied by DecodePushRequest.
+const DefaultMaxPushRequestBytes = 100 << 20
+
+// ErrPushRequestTooLarge is returned when the push request body exceeds the size limit.
+var ErrPushRequestTooLarge = errors.New("push request body too large")
+
 // DecodePushRequest directly decodes json to a logproto.PushRequest
 func DecodePushRequest(b io.Reader, r *logproto.PushRequest) error {
-	return json.NewDecoder(b).Decode(r)
+	return DecodePushRequestLimited(b, DefaultMaxPushRequestBytes, r)
+}
+
+// DecodePushRequestLimited is like DecodePushRequest but fails with ErrPushRequestTooLarge
+// without reading further once the body exceeds maxBytes.
+func DecodePushRequestLimited(b io.Reader, maxBytes int64, r *logproto.PushRequest) error {
+	// read one byte past the limit to tell a body of exactly maxBytes from a larger one.
+	lr := &io.LimitedReader{R: b, N: maxBytes + 1}
+	err := json.NewDecoder(lr).Decode(r)
+	if lr.N <= 0 {
+		return fmt.Errorf("%w: limit is %d bytes", ErrPushRequestTooLarge, maxBytes)
+	}
+	return err
+}
+
+// DuplicatePolicy tells how entries with the same timestamp and line as a previous entry of their stream are handled.
+type DuplicatePolicy int
+
+const (
+	// DuplicatesAllow keeps duplicate entries as is.
+	DuplicatesAllow DuplicatePolicy = iota
+	// DuplicatesReject fails the decoding with a DuplicateEntryError.
+	DuplicatesReject
+	// DuplicatesDedupe drops duplicate entries, keeping the first one.
+	DuplicatesDedupe
+)
+
+// DuplicateEntryError is returned by DecodePushRequestWithDuplicatePolicy when a stream has duplicate entries
+// and duplicates are rejected.
+type DuplicateEntryError struct {
+	Stream    string
+	Timestamp time.Time
+	Line      string
+}
+
+func (e *DuplicateEntryError) Error() string {
+	return fmt.Sprintf("duplicate entry in stream %s at %s: %q", e.Stream, e.Timestamp.Format(time.RFC3339Nano), e.Line)
+}
+
+// DecodePushRequestWithDuplicatePolicy is like DecodePushRequest but handles entries with the same timestamp
+// and line within a stream, as sent by agents shipping the same logs twice, according to policy.
+func DecodePushRequestWithDuplicatePolicy(b io.Reader, policy DuplicatePolicy, r *logproto.PushRequest) error {
+	if err := DecodePushRequest(b, r); err != nil {
+		return err
+	}
+	if policy == DuplicatesAllow {
+		return nil
+	}
+
+	type entryKey struct {
+		ts   int64
+		line string
+	}
+	for i := range r.Streams {
+		stream := &r.Streams[i]
+		seen := make(map[entryKey]struct{}, len(stream.Entries))
+		entries := stream.Entries[:0]
+		for _, e := range stream.Entries {
+			key := entryKey{ts: e.Timestamp.UnixNano(), line: e.Line}
+			if _, ok := seen[key]; ok {
+				if policy == DuplicatesReject {
+					return &DuplicateEntryError{Stream: stream.Labels, Timestamp: e.Timestamp, Line: e.Line}
+				}
+				continue
+			}
+			seen[key] = struct{}{}
+			entries = append(entries, e)
+		}
+		stream.Entries = entries
+	}
+	return nil
 }
@@ -66,3 +66,87 @@ func mustParse(l string, t string) time.Time {
 
 	return ret
 }
+
+func Test_DecodePushRequestLimited(t *testing.T) {
+	body := pushTests[0].actual
+
+	for _, tc := range []struct {
+		name        string
+		maxBytes    int64
+		expectedErr error
+	}{
+		{name: "under the limit", maxBytes: int64(len(body)) + 1},
+		{name: "exactly the limit", maxBytes: int64(len(body))},
+		{name: "over the limit", maxBytes: int64(len(body)) - 1, expectedErr: ErrPushRequestTooLarge},
+		{name: "way over the limit", maxBytes: 10, expectedErr: ErrPushRequestTooLarge},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var actual logproto.PushRequest
+			err := DecodePushRequestLimited(strings.NewReader(body), tc.maxBytes, &actual)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, pushTests[0].expected, actual.Streams)
+		})
+	}
+}
+
+func Test_DecodePushRequestWithDuplicatePolicy(t *testing.T) {
+	body := `{
+		"streams":[
+			{
+				"labels":"{app=\"foo\"}",
+				"entries":[
+					{"ts": "2019-09-13T18:32:22.380001319Z", "line": "first"},
+					{"ts": "2019-09-13T18:32:22.380001319Z", "line": "first"},
+					{"ts": "2019-09-13T18:32:22.380001319Z", "line": "second"},
+					{"ts": "2019-09-13T18:32:23Z", "line": "first"}
+				]
+			},
+			{
+				"labels":"{app=\"bar\"}",
+				"entries":[
+					{"ts": "2019-09-13T18:32:22.380001319Z", "line": "first"}
+				]
+			}
+		]
+	}`
+	ts := mustParse(time.RFC3339Nano, "2019-09-13T18:32:22.380001319Z")
+
+	t.Run("allow", func(t *testing.T) {
+		var actual logproto.PushRequest
+		require.NoError(t, DecodePushRequestWithDuplicatePolicy(strings.NewReader(body), DuplicatesAllow, &actual))
+		require.Len(t, actual.Streams[0].Entries, 4)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		var actual logproto.PushRequest
+		err := DecodePushRequestWithDuplicatePolicy(strings.NewReader(body), DuplicatesReject, &actual)
+		var dupErr *DuplicateEntryError
+		require.ErrorAs(t, err, &dupErr)
+		require.Equal(t, `{app="foo"}`, dupErr.Stream)
+		require.Equal(t, ts, dupErr.Timestamp)
+		require.Equal(t, "first", dupErr.Line)
+	})
+
+	t.Run("dedupe", func(t *testing.T) {
+		var actual logproto.PushRequest
+		require.NoError(t, DecodePushRequestWithDuplicatePolicy(strings.NewReader(body), DuplicatesDedupe, &actual))
+		require.Equal(t, []logproto.Stream{
+			{
+				Labels: `{app="foo"}`,
+				Entries: []logproto.Entry{
+					{Timestamp: ts, Line: "first"},
+					{Timestamp: ts, Line: "second"},
+					{Timestamp: mustParse(time.RFC3339Nano, "2019-09-13T18:32:23Z"), Line: "first"},
+				},
+			},
+			{
+				Labels:  `{app="bar"}`,
+				Entries: []logproto.Entry{{Timestamp: ts, Line: "first"}},
+			},
+		}, actual.Streams)
+	})
+}
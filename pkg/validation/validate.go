@@ -23,6 +23,9 @@ const (
 	// LineTooLong is a reason for discarding too long log lines.
 	LineTooLong         = "line_too_long"
 	LineTooLongErrorMsg = "Max entry size '%d' bytes exceeded for stream '%s' while adding an entry with length '%d' bytes"
+	// PushTooLarge is a reason for discarding a push request whose lines are too large in total
+	PushTooLarge         = "push_too_large"
+	PushTooLargeErrorMsg = "Max push request size '%d' bytes exceeded for user %s while pushing '%d' lines totaling '%d' bytes, split the push in smaller batches"
 	// StreamLimit is a reason for discarding lines when we can't create a new stream
 	// because the limit of active streams has been reached.
 	StreamLimit         = "stream_limit"